@@ -39,6 +39,16 @@ type Configuration struct {
 type ConfigMetaV2 struct {
 	CurrentSet string                     `yaml:"CurrentSet"`
 	Configs    map[string]ConfigurationV2 `yaml:"Configs"`
+	AnkoPolicy AnkoPolicy                 `yaml:"AnkoPolicy,omitempty"` // the allowed risk levels of the anko functions
+}
+
+// AnkoPolicy defines the maximum risk level (low, medium, high) of the anko functions,
+// depending on the source of the task. an empty level means the default is used.
+type AnkoPolicy struct {
+	Local         string   `yaml:"Local,omitempty"`         // tasks of the local template. default high
+	Trusted       string   `yaml:"Trusted,omitempty"`       // tasks of the trusted shared libs. default high
+	Untrusted     string   `yaml:"Untrusted,omitempty"`     // tasks of any other shared lib. default high
+	TrustedShared []string `yaml:"TrustedShared,omitempty"` // the names of the trusted shared libs. like swaros/ctx-git
 }
 
 type WorkspaceInfoV2 struct {
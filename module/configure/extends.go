@@ -0,0 +1,174 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package configure
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ResolveExtends resolves the extends definitions of all tasks.
+// the task is merged with the task it extends, by these rules:
+//   - values they are not set in the task, are taken from the parent
+//   - maps are merged. keys defined in the task are winning
+//   - lists are appended to the list of the parent. strings are added once only
+//   - script, cmd and mainparams are taken from the parent only if the task have none
+//   - id, aliases and the invisible flag are never inherited
+//   - a local task that extends a task of a shared lib, gets the source of the shared lib.
+//     so it runs with the risk level of the shared lib
+//
+// the parent itself can also extend another task. if there is a cycle, an error is returned.
+// if the id of the parent exists more than once, the first one is used.
+func ResolveExtends(config *RunConfig) error {
+	resolved := make(map[int]bool)
+	for index := range config.Task {
+		if err := resolveTaskExtends(config, index, resolved, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func resolveTaskExtends(config *RunConfig, index int, resolved map[int]bool, chain []int) error {
+	if resolved[index] {
+		return nil
+	}
+	task := config.Task[index]
+	extends := strings.TrimSpace(task.Extends)
+	if extends == "" {
+		resolved[index] = true
+		return nil
+	}
+
+	parentIndex := -1
+	for i, parent := range config.Task {
+		if parent.ID == extends {
+			parentIndex = i
+			break
+		}
+	}
+	if parentIndex < 0 {
+		return fmt.Errorf("task %s extends %s, but this task is not defined", task.ID, extends)
+	}
+
+	chain = append(chain, index)
+	for _, chained := range chain {
+		if chained == parentIndex {
+			var ids []string
+			for _, i := range chain {
+				ids = append(ids, config.Task[i].ID)
+			}
+			return fmt.Errorf("cycle in extends detected: %s -> %s", strings.Join(ids, " -> "), extends)
+		}
+	}
+
+	if err := resolveTaskExtends(config, parentIndex, resolved, chain); err != nil {
+		return err
+	}
+	config.Task[index] = MergeTasks(config.Task[parentIndex], task)
+	resolved[index] = true
+	return nil
+}
+
+// MergeTasks merges the child task into a copy of the parent task.
+// see ResolveExtends for the rules.
+func MergeTasks(parent, child Task) Task {
+	merged := child
+	mergeValues(reflect.ValueOf(&merged).Elem(), reflect.ValueOf(parent))
+
+	// these values are never inherited
+	merged.ID = child.ID
+	merged.Extends = child.Extends
+	merged.Aliases = child.Aliases
+	merged.Options.Invisible = child.Options.Invisible
+
+	// these are replaced as a whole
+	merged.Script = firstNotEmpty(child.Script, parent.Script)
+	merged.Cmd = firstNotEmpty(child.Cmd, parent.Cmd)
+	merged.Options.Mainparams = firstNotEmpty(child.Options.Mainparams, parent.Options.Mainparams)
+
+	// the task can contain commands of the parent, so the stricter source is used
+	if child.Source == "" {
+		merged.Source = parent.Source
+	}
+	return merged
+}
+
+func firstNotEmpty(first, second []string) []string {
+	if len(first) > 0 {
+		return first
+	}
+	return second
+}
+
+// mergeValues merges the src into the dst value.
+func mergeValues(dst, src reflect.Value) {
+	switch dst.Kind() {
+	case reflect.Struct:
+		for i := 0; i < dst.NumField(); i++ {
+			if dst.Field(i).CanSet() {
+				mergeValues(dst.Field(i), src.Field(i))
+			}
+		}
+	case reflect.Map:
+		if src.Len() == 0 {
+			return
+		}
+		newMap := reflect.MakeMapWithSize(dst.Type(), src.Len()+dst.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			newMap.SetMapIndex(iter.Key(), iter.Value())
+		}
+		iter = dst.MapRange()
+		for iter.Next() {
+			newMap.SetMapIndex(iter.Key(), iter.Value())
+		}
+		dst.Set(newMap)
+	case reflect.Slice:
+		if src.Len() == 0 {
+			return
+		}
+		newSlice := reflect.MakeSlice(dst.Type(), 0, src.Len()+dst.Len())
+		seen := make(map[string]bool)
+		for _, from := range []reflect.Value{src, dst} {
+			for i := 0; i < from.Len(); i++ {
+				entry := from.Index(i)
+				if entry.Kind() == reflect.String {
+					if seen[entry.String()] {
+						continue
+					}
+					seen[entry.String()] = true
+				}
+				newSlice = reflect.Append(newSlice, entry)
+			}
+		}
+		dst.Set(newSlice)
+	default:
+		if dst.IsZero() {
+			dst.Set(src)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package configure_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/swaros/contxt/module/configure"
+)
+
+func TestResolveExtends(t *testing.T) {
+	config := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID:        "base",
+				Aliases:   []string{"b"},
+				Variables: map[string]string{"target": "linux", "mode": "release"},
+				Requires: configure.Require{
+					Exists: []string{"go.mod"},
+				},
+				Options: configure.Options{
+					Displaycmd: true,
+					Invisible:  true,
+					WorkingDir: "src",
+				},
+				Listener: []configure.Listener{
+					{Trigger: configure.Trigger{OnoutContains: []string{"error"}}, Action: configure.Action{Target: "notify"}},
+				},
+				Needs:  []string{"prepare"},
+				Script: []string{"echo base"},
+			},
+			{
+				ID:        "build-windows",
+				Extends:   "base",
+				Variables: map[string]string{"target": "windows"},
+				Requires: configure.Require{
+					Exists: []string{"go.mod", "main.go"},
+				},
+				Needs: []string{"prepare", "generate"},
+			},
+			{
+				ID:      "build-arm",
+				Extends: "build-windows",
+				Options: configure.Options{
+					WorkingDir: "arm",
+				},
+				Script: []string{"echo arm"},
+			},
+		},
+	}
+	if err := configure.ResolveExtends(&config); err != nil {
+		t.Fatalf("Expected no error, got '%v'", err)
+	}
+	win := config.Task[1]
+	if win.Variables["target"] != "windows" || win.Variables["mode"] != "release" {
+		t.Errorf("Unexpected variables %v", win.Variables)
+	}
+	if strings.Join(win.Requires.Exists, ",") != "go.mod,main.go" {
+		t.Errorf("Unexpected exists requirement %v", win.Requires.Exists)
+	}
+	if strings.Join(win.Needs, ",") != "prepare,generate" {
+		t.Errorf("Unexpected needs %v", win.Needs)
+	}
+	if len(win.Listener) != 1 || win.Listener[0].Action.Target != "notify" {
+		t.Errorf("Unexpected listener %v", win.Listener)
+	}
+	if !win.Options.Displaycmd || win.Options.WorkingDir != "src" {
+		t.Errorf("Unexpected options %v", win.Options)
+	}
+	if win.Options.Invisible || len(win.Aliases) != 0 {
+		t.Error("invisible and aliases should not be inherited")
+	}
+	if strings.Join(win.Script, ",") != "echo base" {
+		t.Errorf("Unexpected script %v", win.Script)
+	}
+
+	// extended from an extended task
+	arm := config.Task[2]
+	if arm.Variables["target"] != "windows" || arm.Options.WorkingDir != "arm" {
+		t.Errorf("Unexpected values %v %v", arm.Variables, arm.Options.WorkingDir)
+	}
+	if strings.Join(arm.Script, ",") != "echo arm" {
+		t.Errorf("Unexpected script %v", arm.Script)
+	}
+	// the base is not changed
+	if config.Task[0].Variables["target"] != "linux" {
+		t.Error("the base task should not be changed")
+	}
+}
+
+func TestResolveExtendsErrors(t *testing.T) {
+	config := configure.RunConfig{
+		Task: []configure.Task{
+			{ID: "a", Extends: "b"},
+			{ID: "b", Extends: "c"},
+			{ID: "c", Extends: "a"},
+		},
+	}
+	err := configure.ResolveExtends(&config)
+	if err == nil {
+		t.Fatal("Expected a cycle error")
+	}
+	if !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Errorf("Unexpected error message '%v'", err)
+	}
+
+	config = configure.RunConfig{
+		Task: []configure.Task{
+			{ID: "a", Extends: "a"},
+		},
+	}
+	if err := configure.ResolveExtends(&config); err == nil {
+		t.Error("Expected a cycle error for extending itself")
+	}
+
+	config = configure.RunConfig{
+		Task: []configure.Task{
+			{ID: "a", Extends: "missing"},
+		},
+	}
+	if err := configure.ResolveExtends(&config); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Expected error about the missing task, got '%v'", err)
+	}
+}
+
+func TestResolveExtendsSource(t *testing.T) {
+	config := configure.RunConfig{
+		Task: []configure.Task{
+			{ID: "shared-base", Source: "someone/lib", Script: []string{"echo shared"}},
+			{ID: "local-base", Script: []string{"echo local"}},
+			{ID: "local", Extends: "shared-base"},
+			{ID: "shared", Extends: "local-base", Source: "someone/other"},
+		},
+	}
+	if err := configure.ResolveExtends(&config); err != nil {
+		t.Fatalf("Expected no error, got '%v'", err)
+	}
+	if config.Task[2].Source != "someone/lib" {
+		t.Errorf("the local task should get the source of the shared lib. got '%s'", config.Task[2].Source)
+	}
+	if config.Task[3].Source != "someone/other" {
+		t.Errorf("the shared task should keep its source. got '%s'", config.Task[3].Source)
+	}
+}
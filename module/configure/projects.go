@@ -0,0 +1,105 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package configure
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FindProjectPath returns the workspace info of the path in the current workspace,
+// that is matching the project reference.
+// see FindProjectInfo for the rules how the reference is matched.
+func (c *contxtConfigure) FindProjectPath(reference, fromProject string) (WorkspaceInfoV2, error) {
+	cfg, found := c.getCurrentConfig()
+	if !found {
+		return WorkspaceInfoV2{}, errors.New("no workspace is active")
+	}
+	return FindProjectInfo(cfg.Paths, reference, fromProject)
+}
+
+// FindProjectInfo searches the paths for the one, that is matching the reference.
+// the reference can be written as project/role, what have to match exactly.
+// otherwise it is compared with the role of the path. here the paths of the
+// fromProject are preferred.
+// if no role is matching, the reference is compared with the project name.
+// any reference they matches more than one path, is reported as an error.
+func FindProjectInfo(paths map[string]WorkspaceInfoV2, reference, fromProject string) (WorkspaceInfoV2, error) {
+	reference = strings.TrimSpace(reference)
+	if reference == "" {
+		return WorkspaceInfoV2{}, errors.New("empty project reference")
+	}
+
+	// sorted keys, so the result (and the error message) is always the same
+	keys := make([]string, 0, len(paths))
+	for key := range paths {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	filter := func(match func(info WorkspaceInfoV2) bool) []WorkspaceInfoV2 {
+		var found []WorkspaceInfoV2
+		for _, key := range keys {
+			if match(paths[key]) {
+				found = append(found, paths[key])
+			}
+		}
+		return found
+	}
+
+	var checks []func(info WorkspaceInfoV2) bool
+	if project, role, isFull := strings.Cut(reference, "/"); isFull {
+		checks = append(checks, func(info WorkspaceInfoV2) bool {
+			return info.Project == project && info.Role == role
+		})
+	} else {
+		checks = append(checks,
+			func(info WorkspaceInfoV2) bool {
+				return fromProject != "" && info.Project == fromProject && info.Role == reference
+			},
+			func(info WorkspaceInfoV2) bool { return info.Role == reference },
+			func(info WorkspaceInfoV2) bool { return info.Project == reference },
+		)
+	}
+
+	for _, check := range checks {
+		found := filter(check)
+		switch len(found) {
+		case 0:
+			continue
+		case 1:
+			return found[0], nil
+		default:
+			var names []string
+			for _, info := range found {
+				names = append(names, info.Project+"/"+info.Role)
+			}
+			return WorkspaceInfoV2{}, fmt.Errorf("project reference %s is ambiguous. it matches %s", reference, strings.Join(names, ", "))
+		}
+	}
+	return WorkspaceInfoV2{}, fmt.Errorf("no project found in the workspace for %s. did you run 'contxt workspace scan'?", reference)
+}
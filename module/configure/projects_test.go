@@ -0,0 +1,52 @@
+package configure_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/swaros/contxt/module/configure"
+)
+
+func TestFindProjectInfo(t *testing.T) {
+	paths := map[string]configure.WorkspaceInfoV2{
+		"0": {Path: "/shop/api", Project: "shop", Role: "api"},
+		"1": {Path: "/shop/frontend", Project: "shop", Role: "frontend"},
+		"2": {Path: "/blog/api", Project: "blog", Role: "api"},
+		"3": {Path: "/tools", Project: "tools", Role: "root"},
+		"4": {Path: "/unscanned"},
+	}
+
+	tests := []struct {
+		reference   string
+		fromProject string
+		path        string
+		errContains string
+	}{
+		{reference: "api", fromProject: "shop", path: "/shop/api"},
+		{reference: "api", fromProject: "blog", path: "/blog/api"},
+		{reference: "blog/api", fromProject: "shop", path: "/blog/api"},
+		{reference: "frontend", fromProject: "blog", path: "/shop/frontend"},
+		{reference: "tools", fromProject: "shop", path: "/tools"},
+		{reference: "api", fromProject: "tools", errContains: "ambiguous"},
+		{reference: "shop", fromProject: "", errContains: "ambiguous"},
+		{reference: "docs", fromProject: "shop", errContains: "no project found"},
+		{reference: "shop/docs", fromProject: "shop", errContains: "no project found"},
+		{reference: " ", fromProject: "shop", errContains: "empty"},
+	}
+	for _, test := range tests {
+		info, err := configure.FindProjectInfo(paths, test.reference, test.fromProject)
+		if test.errContains != "" {
+			if err == nil || !strings.Contains(err.Error(), test.errContains) {
+				t.Errorf("[%s] expected error containing '%s', got '%v'", test.reference, test.errContains, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("[%s] expected no error, got '%v'", test.reference, err)
+			continue
+		}
+		if info.Path != test.path {
+			t.Errorf("[%s] expected path '%s', got '%s'", test.reference, test.path, info.Path)
+		}
+	}
+}
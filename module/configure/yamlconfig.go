@@ -94,40 +94,66 @@ type Require struct {
 
 // Options are the per-task options
 type Options struct {
-	IgnoreCmdError bool     `yaml:"ignoreCmdError"`
-	Format         string   `yaml:"format"`
-	Stickcursor    bool     `yaml:"stickcursor"`
-	Colorcode      string   `yaml:"colorcode"`
-	Bgcolorcode    string   `yaml:"bgcolorcode"`
-	Panelsize      int      `yaml:"panelsize"`
-	Displaycmd     bool     `yaml:"displaycmd"`
-	Hideout        bool     `yaml:"hideout"`
-	Invisible      bool     `yaml:"invisible"`
-	Maincmd        string   `yaml:"maincmd"`
-	Mainparams     []string `yaml:"mainparams"`
-	NoAutoRunNeeds bool     `yaml:"noAutoRunNeeds"`
-	TimeoutNeeds   int      `yaml:"timeoutNeeds"`
-	CmdTimeout     int      `yaml:"cmdTimeout"` // timeout for the anko commands in milliseconds
-	TickTimeNeeds  int      `yaml:"tickTimeNeeds"`
-	WorkingDir     string   `yaml:"workingdir"`
+	IgnoreCmdError   bool     `yaml:"ignoreCmdError"`
+	Format           string   `yaml:"format"`
+	Stickcursor      bool     `yaml:"stickcursor"`
+	Colorcode        string   `yaml:"colorcode"`
+	Bgcolorcode      string   `yaml:"bgcolorcode"`
+	Panelsize        int      `yaml:"panelsize"`
+	Displaycmd       bool     `yaml:"displaycmd"`
+	Hideout          bool     `yaml:"hideout"`
+	Invisible        bool     `yaml:"invisible"`
+	Maincmd          string   `yaml:"maincmd"`
+	Mainparams       []string `yaml:"mainparams"`
+	NoAutoRunNeeds   bool     `yaml:"noAutoRunNeeds"`
+	TimeoutNeeds     int      `yaml:"timeoutNeeds"`
+	CmdTimeout       int      `yaml:"cmdTimeout"` // timeout for the anko commands in milliseconds
+	TickTimeNeeds    int      `yaml:"tickTimeNeeds"`
+	WorkingDir       string   `yaml:"workingdir"`
+	ScriptMode       string   `yaml:"scriptMode"`       // line (default) or file. file executes the whole script at once
+	Lock             string   `yaml:"lock"`             // none (default), exclusive or shared. locks the target across processes
+	LockWait         bool     `yaml:"lockWait"`         // wait for the lock instead of failing
+	LockTimeout      int      `yaml:"lockTimeout"`      // max time in milliseconds to wait for the lock. 0 means no limit
+	InterruptTimeout int      `yaml:"interruptTimeout"` // max time in milliseconds for the onInterrupt target
+	Hermetic         bool     `yaml:"hermetic"`         // run the commands with a controlled environment
+	HermeticCopy     bool     `yaml:"hermeticCopy"`     // hermetic commands are running in a throwaway copy of the working dir
+	EnvAllow         []string `yaml:"envAllow"`         // names of environment variables, that are passed to hermetic commands
+	Output           string   `yaml:"output"`           // stream (default) or grouped. grouped prints the output of the target as one block
+	OutputLiveErrors bool     `yaml:"outputLiveErrors"` // errors are printed at once, also in grouped mode
 }
 
 // Trigger are part of listener. The defines
 // some events they are triggered by executing scripts
 // most of them watching the output
 type Trigger struct {
-	Onerror        bool     `yaml:"onerror"`
-	OnoutcountLess int      `yaml:"onoutcountLess"`
-	OnoutcountMore int      `yaml:"onoutcountMore"`
-	OnoutContains  []string `yaml:"onoutContains"`
-	Now            bool     `yaml:"now"`
+	Onerror           bool     `yaml:"onerror"`
+	OnoutcountLess    int      `yaml:"onoutcountLess"`
+	OnoutcountMore    int      `yaml:"onoutcountMore"`
+	OnoutContains     []string `yaml:"onoutContains"`
+	Now               bool     `yaml:"now"`
+	OnoutRegex        []string `yaml:"onoutRegex,omitempty"`        // regular expressions. the groups of the match are set as variables
+	OnExitCode        []string `yaml:"onExitCode,omitempty"`        // exit codes of the command. like 1 or ranges like 2-5
+	OnStderr          bool     `yaml:"onStderr,omitempty"`          // only lines written to stderr are checked. without other output checks any of them matches
+	OnDurationExceeds int      `yaml:"onDurationExceeds,omitempty"` // milliseconds. matches once, if the command is running longer
 }
 
 // Action defines what should happens Next.
 type Action struct {
-	Target  string   `yaml:"target"`
-	Stopall bool     `yaml:"stopall"`
-	Script  []string `yaml:"script"`
+	Target       string            `yaml:"target"`
+	Stopall      bool              `yaml:"stopall"`
+	Script       []string          `yaml:"script"`
+	SetVar       map[string]string `yaml:"setVar,omitempty"`       // variables they are set. the captures of the trigger can be used as ${RUN.<task-id>.TRIGGER.1}
+	Stop         string            `yaml:"stop,omitempty"`         // target they is stopped
+	Restart      string            `yaml:"restart,omitempty"`      // target they is stopped and started again in the background
+	AppendToFile FileAppend        `yaml:"appendToFile,omitempty"` // a line they is appended to a file
+	Message      string            `yaml:"message,omitempty"`      // message they is shown by the output handler
+}
+
+// FileAppend is a line they is appended to a file.
+// the file is created if it not exists
+type FileAppend struct {
+	File string `yaml:"file"`
+	Line string `yaml:"line"`
 }
 
 // Listener are used for watching events
@@ -137,17 +163,57 @@ type Listener struct {
 	Action  Action  `yaml:"action"`
 }
 
+// Prompt defines a question they is asked, if the variable
+// is not set while the task is executed
+type Prompt struct {
+	Name     string   `yaml:"name"`               // the name of the variable
+	Question string   `yaml:"question"`           // the question that is shown to the user
+	Type     string   `yaml:"type,omitempty"`     // the type of the input. string (default), select, confirm
+	Choices  []string `yaml:"choices,omitempty"`  // possible values for the select type
+	Default  string   `yaml:"default,omitempty"`  // the default value
+	Validate string   `yaml:"validate,omitempty"` // regular expression to validate the value
+}
+
+// Output is a value the task provides to the tasks they need it.
+// the value is taken from one of the sources cmd, regex or file.
+type Output struct {
+	Name  string `yaml:"name"`            // the name of the value. used as ${needs.<task>.<name>}
+	Cmd   string `yaml:"cmd,omitempty"`   // the output of this command is the value
+	Regex string `yaml:"regex,omitempty"` // the first match in the output of the task. if the regex have a group, the first group is the value
+	File  string `yaml:"file,omitempty"`  // the content of this file is the value
+}
+
+// OutputFilter changes the output of a task, before it is printed.
+// any line that is matching the regex is hidden, colored, rewritten or used to set a variable.
+type OutputFilter struct {
+	Match   string `yaml:"match"`             // the regular expression the line is checked against
+	Hide    bool   `yaml:"hide,omitempty"`    // hide the matching line
+	Color   string `yaml:"color,omitempty"`   // ctxout markup for the matching line. like <f:red>
+	Replace string `yaml:"replace,omitempty"` // replacement for the matching text. groups can be used as $1
+	SetVar  string `yaml:"setVar,omitempty"`  // variable they gets the first group of the match, or the whole match
+}
+
 // Task is the main Script
 type Task struct {
-	ID          string            `yaml:"id"`
-	Variables   map[string]string `yaml:"variables,omitempty"`
-	Requires    Require           `yaml:"require"`
-	Stopreasons Trigger           `yaml:"stopreasons"`
-	Options     Options           `yaml:"options"`
-	Cmd         []string          `yaml:"cmd"`
-	Script      []string          `yaml:"script"`
-	Listener    []Listener        `yaml:"listener"`
-	Next        []string          `yaml:"next"`
-	RunTargets  []string          `yaml:"runTargets"`
-	Needs       []string          `yaml:"needs"`
+	ID            string            `yaml:"id"`
+	Description   string            `yaml:"description,omitempty"` // short description shown in listings and completion
+	Group         string            `yaml:"group,omitempty"`       // group name used to group the targets in listings
+	Aliases       []string          `yaml:"aliases,omitempty"`     // alternative names for the target
+	Extends       string            `yaml:"extends,omitempty"`     // id of the task they is used as base for this task
+	Source        string            `yaml:"-"`                     // the shared lib the task is loaded from. empty for the local template
+	Variables     map[string]string `yaml:"variables,omitempty"`
+	Env           map[string]string `yaml:"env,omitempty"` // environment variables for the commands of the task
+	Requires      Require           `yaml:"require"`
+	Stopreasons   Trigger           `yaml:"stopreasons"`
+	Options       Options           `yaml:"options"`
+	Cmd           []string          `yaml:"cmd"`
+	Script        []string          `yaml:"script"`
+	Listener      []Listener        `yaml:"listener"`
+	Next          []string          `yaml:"next"`
+	RunTargets    []string          `yaml:"runTargets"`
+	Needs         []string          `yaml:"needs"`
+	Prompts       []Prompt          `yaml:"prompts,omitempty"`
+	Outputs       []Output          `yaml:"outputs,omitempty"`
+	OutputFilters []OutputFilter    `yaml:"outputFilters,omitempty"` // rules they change the output of the task, before it is printed
+	OnInterrupt   string            `yaml:"onInterrupt,omitempty"`   // target they runs, if the task is interrupted by a signal
 }
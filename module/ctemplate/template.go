@@ -52,6 +52,8 @@ type Template struct {
 	user          *user.User                       // the current user (set in Init)
 	path          string                           // the current path (set in Init)
 	includeConfig configure.IncludePaths           // the include config contains all the files to include
+	includeDir    string                           // the directory of the loaded include file. relative include folders are resolved against it
+	basePath      string                           // the directory of the loaded template file
 	dataMap       sync.Map                         // the data map contains all key values they are used for parsing go/template files
 	tplParser     CtxTemplate                      // the template parser that is parsing any text file with go/template placeholders
 	linter        *yaclint.Linter                  // the linter is used to lint the template files
@@ -377,15 +379,51 @@ func (t *Template) Load() (configure.RunConfig, bool, error) {
 	if Template, err := t.LoadV2(); err != nil {
 		return configure.RunConfig{}, false, err
 	} else {
-		// if we have a callback function we call it here to let the user do some stuff
-		// and maybe change the template file
-		if t.onLoadFn != nil {
-			if err := t.onLoadFn(&Template); err != nil {
-				return Template, false, err
-			}
+		t.basePath = t.path
+		return t.afterLoad(Template)
+	}
+}
+
+// LoadFromPath loads the template file in the given directory and returns the parsed content.
+// it works like Load, but without depending on the current directory.
+// so templates of other projects can be loaded, while other tasks are running.
+func (t *Template) LoadFromPath(path string) (configure.RunConfig, bool, error) {
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+	fileName := filepath.Join(path, DefaultTemplateFile)
+	if _, err := os.Stat(fileName); err != nil {
+		return configure.RunConfig{}, false, nil
+	}
+	if Template, err := t.LoadV2ByAbsolutePath(fileName); err != nil {
+		return configure.RunConfig{}, false, err
+	} else {
+		t.basePath = path
+		return t.afterLoad(Template)
+	}
+}
+
+// GetBasePath returns the directory of the last loaded template file.
+// it is empty, if no template is loaded
+func (t *Template) GetBasePath() string {
+	return t.basePath
+}
+
+// afterLoad calls the onLoad callback and resolves the extends of the loaded template
+func (t *Template) afterLoad(Template configure.RunConfig) (configure.RunConfig, bool, error) {
+	// if we have a callback function we call it here to let the user do some stuff
+	// and maybe change the template file
+	if t.onLoadFn != nil {
+		if err := t.onLoadFn(&Template); err != nil {
+			return Template, false, err
 		}
-		return Template, true, nil
 	}
+	// the extends have to be resolved after the shared tasks are merged,
+	// so tasks can extend tasks from a shared library
+	if err := configure.ResolveExtends(&Template); err != nil {
+		return Template, false, err
+	}
+	return Template, true, nil
 }
 
 // LoadInclude loads the include files and returns the parsed content.
@@ -393,17 +431,18 @@ func (t *Template) Load() (configure.RunConfig, bool, error) {
 // any of these files can have template placeholders, they will processed
 func (t *Template) LoadInclude(path string) (configure.IncludePaths, bool, error) {
 	// just check if we have a include file
-	_, ok := t.FindIncludeFileName(path)
+	includeFile, ok := t.FindIncludeFileName(path)
 	if !ok {
 		return configure.IncludePaths{}, false, nil // no include file is also fine. so no error
 	}
 	var include configure.IncludePaths
 	// try to load the included files. can be json or yaml
-	t.logger.Info("load include file:", path)
-	if err := yacl.New(&include, yamc.NewYamlReader(), yamc.NewJsonReader()).SetSingleFile(DefaultIncludeFile).Load(); err != nil {
+	t.logger.Info("load include file:", includeFile)
+	if err := yacl.New(&include, yamc.NewYamlReader(), yamc.NewJsonReader()).SetFileAndPathsByFullFilePath(includeFile).Load(); err != nil {
 		return include, false, err
 	}
 	t.includeConfig = include
+	t.includeDir = filepath.Dir(includeFile)
 	if err := t.parseIncludes(); err != nil { // parse the include files
 		return include, false, err
 	}
@@ -422,6 +461,7 @@ func (t *Template) parseIncludes() error {
 
 			if len(keymaps.Paths) > 0 {
 				for _, path := range keymaps.Paths {
+					path = t.includePath(path)
 					t.logger.Debug("parse keyed Source include:", path)
 					mapOrigin := t.GetOriginMap()
 					if err := t.ReadValueFiles(path, &keyedmap); err != nil {
@@ -442,6 +482,7 @@ func (t *Template) parseIncludes() error {
 	}
 	if len(t.includeConfig.Include.Folders) > 0 {
 		for _, include := range t.includeConfig.Include.Folders {
+			include = t.includePath(include)
 			t.logger.Debug("parseIncludes:", include)
 			if mapData, err := t.ImportFolder(include); err != nil {
 				return err
@@ -454,6 +495,14 @@ func (t *Template) parseIncludes() error {
 	return nil
 }
 
+// includePath resolves a relative include folder against the directory of the include file
+func (t *Template) includePath(path string) string {
+	if t.includeDir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(t.includeDir, path)
+}
+
 func (t *Template) ReadValueFiles(startPath string, resultMap *map[string]interface{}) error {
 	err := filepath.Walk(startPath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -2,6 +2,7 @@ package ctemplate_test
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -62,6 +63,23 @@ func TestTemplateInclude(t *testing.T) {
 	popDir()
 }
 
+// templates with includes are loaded by the path, without changing the directory
+func TestTemplateLoadFromPath(t *testing.T) {
+	tmplte := ctemplate.New()
+	cfg, exists, err := tmplte.LoadFromPath("testdata/withInclude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, exists)
+	assert.Equal(t, "mars", cfg.Task[0].ID)
+	absPath, _ := filepath.Abs("testdata/withInclude")
+	assert.Equal(t, absPath, tmplte.GetBasePath())
+
+	_, exists, err = ctemplate.New().LoadFromPath("testdata")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
 func TestTemplateV2Include(t *testing.T) {
 	pushDir("testdata/withInclude")
 	tmplte := ctemplate.New()
@@ -160,3 +178,23 @@ task:
 
 	popDir()
 }
+
+func TestTemplateExtends(t *testing.T) {
+	pushDir("testdata/extends")
+	defer popDir()
+	tmplte := ctemplate.New()
+	if err := tmplte.Init(); err != nil {
+		t.Error(err)
+	}
+
+	cfg, found, err := tmplte.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, found)
+	assert.Equal(t, "build-windows", cfg.Task[1].ID)
+	assert.Equal(t, "windows", cfg.Task[1].Variables["target"])
+	assert.Equal(t, []string{"echo \"build for ${target}\""}, cfg.Task[1].Script)
+	assert.True(t, cfg.Task[1].Options.Displaycmd)
+	assert.False(t, cfg.Task[1].Options.Invisible)
+}
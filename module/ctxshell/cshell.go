@@ -46,33 +46,35 @@ const (
 )
 
 type Cshell struct {
-	cobraRootCmd         *cobra.Command     // the root command of the cobra command tree
-	navtiveCmds          []*NativeCmd       // commands that are not part of the cobra command tree
-	getPrompt            func(int) string   // function that returns the prompt string. the update type is passed as argument. if return is empty, the prompt is not updated
-	exitCmdStr           string             // the command string that exits the shell
-	rlInstance           *readline.Instance // the readline instance
-	asyncCobraExec       bool               // if true, cobra commands are executed in a separate goroutine. a general rule.
-	asyncNativeCmd       bool               // if true, native commands are executed in a separate goroutine. a general rule.
-	tickTimerDuration    time.Duration      // the duration of the tick timer for print the buffered messages
-	messages             *CshellMsgFifo     // the message buffer
-	neverAsncCmds        []string           // commands that are never executed in a separate goroutine
-	ignoreCobraCmds      []string           // commands that are ignored by the cobra command tree
-	updatePromptDuration time.Duration      // the period for updating the prompt
-	messageDisplayTime   time.Duration      // the time a message is displayed
-	updatePromptEnabled  bool               // if true, the prompt is updated periodically
-	lastInput            string             // the last input
-	StopOutput           bool               // stop printing the output to stdout
-	captureExitSignal    bool               // if true, the exit signal is captured
-	keyBindings          []KeyFunc          // key bindings
-	promptMessages       []Msg              // messages that are printed before the prompt
-	currentMessage       Msg                // the current message
-	currentMsgExpire     time.Time          // the time when the current message expires
-	runOnceCmds          []string           // commands that are executed only once
-	onShutDown           func()             // function that is called on shutdown
-	onErrorFn            func(error)        // function that is called on error
-	onUnknownCmd         func(string) error // function that is called on unknown command
-	noMessageDuplication bool               // if true, messages are not duplicated for notifications
-	hooks                []Hook             // hooks
+	cobraRootCmd         *cobra.Command         // the root command of the cobra command tree
+	navtiveCmds          []*NativeCmd           // commands that are not part of the cobra command tree
+	getPrompt            func(int) string       // function that returns the prompt string. the update type is passed as argument. if return is empty, the prompt is not updated
+	exitCmdStr           string                 // the command string that exits the shell
+	rlInstance           *readline.Instance     // the readline instance
+	asyncCobraExec       bool                   // if true, cobra commands are executed in a separate goroutine. a general rule.
+	asyncNativeCmd       bool                   // if true, native commands are executed in a separate goroutine. a general rule.
+	tickTimerDuration    time.Duration          // the duration of the tick timer for print the buffered messages
+	messages             *CshellMsgFifo         // the message buffer
+	neverAsncCmds        []string               // commands that are never executed in a separate goroutine
+	ignoreCobraCmds      []string               // commands that are ignored by the cobra command tree
+	updatePromptDuration time.Duration          // the period for updating the prompt
+	messageDisplayTime   time.Duration          // the time a message is displayed
+	updatePromptEnabled  bool                   // if true, the prompt is updated periodically
+	lastInput            string                 // the last input
+	StopOutput           bool                   // stop printing the output to stdout
+	captureExitSignal    bool                   // if true, the exit signal is captured
+	keyBindings          []KeyFunc              // key bindings
+	promptMessages       []Msg                  // messages that are printed before the prompt
+	currentMessage       Msg                    // the current message
+	currentMsgExpire     time.Time              // the time when the current message expires
+	runOnceCmds          []string               // commands that are executed only once
+	onShutDown           func()                 // function that is called on shutdown
+	onErrorFn            func(error)            // function that is called on error
+	onUnknownCmd         func(string) error     // function that is called on unknown command
+	noMessageDuplication bool                   // if true, messages are not duplicated for notifications
+	hooks                []Hook                 // hooks
+	autoCompleter        readline.AutoCompleter // if set, this completer is used instead of the completer for the commands
+	historyFile          string                 // if set, this file is used for the history
 
 }
 
@@ -95,6 +97,20 @@ func NewCshell() *Cshell {
 	}
 }
 
+// SetAutoCompleter sets the completer for the input.
+// this replaces the completion of the native and cobra commands.
+func (t *Cshell) SetAutoCompleter(completer readline.AutoCompleter) *Cshell {
+	t.autoCompleter = completer
+	return t
+}
+
+// SetHistoryFile sets the file for the history.
+// if not set, a file in the temp dir, depending on the binary name, is used
+func (t *Cshell) SetHistoryFile(file string) *Cshell {
+	t.historyFile = file
+	return t
+}
+
 // add a key binding
 // painic if the key is already in the list
 func (t *Cshell) AddKeyBinding(key rune, fn func() bool) *Cshell {
@@ -453,19 +469,26 @@ func (t *Cshell) getNativeCmd(cmd string) *NativeCmd {
 }
 
 func (t *Cshell) init() error {
-	completer := t.createCompleter()
-	// create a tempfile name that is based os the binary name
-	binFile := os.Args[0]
-	// just replace any slashes, spaces and colons with underscores
-	binFile = strings.ReplaceAll(binFile, "/", "_")
-	binFile = strings.ReplaceAll(binFile, " ", "_")
-	binFile = strings.ReplaceAll(binFile, "\\", "_")
-	binFile = strings.ReplaceAll(binFile, ":", "_")
+	var completer readline.AutoCompleter = t.createCompleter()
+	if t.autoCompleter != nil {
+		completer = t.autoCompleter
+	}
+	historyFile := t.historyFile
+	if historyFile == "" {
+		// create a tempfile name that is based os the binary name
+		binFile := os.Args[0]
+		// just replace any slashes, spaces and colons with underscores
+		binFile = strings.ReplaceAll(binFile, "/", "_")
+		binFile = strings.ReplaceAll(binFile, " ", "_")
+		binFile = strings.ReplaceAll(binFile, "\\", "_")
+		binFile = strings.ReplaceAll(binFile, ":", "_")
+		historyFile = filepath.Clean(os.TempDir() + "/cshell_history_" + binFile + ".tmp")
+	}
 
 	var err error
 	t.rlInstance, err = readline.NewEx(&readline.Config{
 		Prompt:              " > ",
-		HistoryFile:         filepath.Clean(os.TempDir() + "/cshell_history_" + binFile + ".tmp"),
+		HistoryFile:         historyFile,
 		AutoComplete:        completer,
 		InterruptPrompt:     "^C",
 		EOFPrompt:           "exit",
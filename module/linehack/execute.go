@@ -143,82 +143,72 @@ func (p *Parser) runSource() (interface{}, error) {
 	return myResult, runErr
 }
 
-type ConditionalChain struct {
-	group     []TokenSelfProvider
-	nextCheck ConditionChain
+// Condition parses the line as a condition and returns the result.
+// other than Execute, the line contains only the condition, without if and then.
+// like $VERSION == "1.0" && $OS != "windows"
+func (p *Parser) Condition(line string) (bool, error) {
+	p.trashTokenCount = 0
+	p.trashTokenTrace = nil
+	p.maxPosition = 0
+	p.Parse(line)
+	if p.trashTokenCount > 0 {
+		return false, fmt.Errorf("found %d trash tokens: %s", p.trashTokenCount, strings.Join(p.trashTokenTrace, ", "))
+	}
+	if len(p.source) == 0 {
+		return false, fmt.Errorf("no condition found in %s", line)
+	}
+	return p.ConditionWithChain(-1, p.maxPosition+1), nil
 }
 
+// ConditionWithChain checks the conditions between start and end.
+// the conditions are chained by &&, || and ^. && is checked first,
+// so a || b && c is the same as a || (b && c)
 func (p *Parser) ConditionWithChain(start int, end int) bool {
 	tokens := p.getTokensInBetween(start, end)
 	var group []TokenSelfProvider
-
-	var chains []ConditionalChain
-	var chainGroups [][]ConditionalChain
+	var results []bool
+	var chains []ConditionChain
 
 	for _, token := range tokens {
 		switch tkn := token.(type) {
 		case ConditionChain:
+			results = append(results, p.checkChainGroup(group))
+			chains = append(chains, tkn)
 			group = []TokenSelfProvider{}
-			chains = append(chains, ConditionalChain{group: group, nextCheck: tkn})
-			chainGroups = append(chainGroups, chains)
-			chains = []ConditionalChain{}
-
 		default:
 			group = append(group, token)
 		}
 	}
-	if len(group) > 0 {
-		chains = append(chains, ConditionalChain{group: group, nextCheck: nil})
-		chainGroups = append(chainGroups, chains)
-	}
-	p.Println("chainGroups", chainGroups)
-	//p.printDegugToken(groups)
-	boolRes := p.checkConditionalChain(chainGroups)
-
-	return boolRes
-
-}
-
-func (p *Parser) checkConditionalChain(chain [][]ConditionalChain) bool {
-	boolResult := true
-	runResult := false
-	var checkHndl ConditionChain
-	var keepForNext ConditionChain
-
-	for index, group := range chain {
-
-		checkHndl, runResult = p.checkConditionalChainGroup(group)
-		if checkHndl != nil {
-			keepForNext = checkHndl
-		}
-		if index == 0 {
-			boolResult = runResult
-		} else {
-			if keepForNext != nil {
-				args := []bool{boolResult, runResult}
-				boolResult = keepForNext.IsStillValid(args)
-				// invalidate the handler
-				keepForNext = nil
-			}
-			boolResult = boolResult && runResult
+	results = append(results, p.checkChainGroup(group))
+	p.Println("chain results", results, chains)
+
+	// first combine the results, they are chained by &&
+	combined := []bool{results[0]}
+	var others []ConditionChain
+	for i, chain := range chains {
+		if _, isAnd := chain.(*TAnd); isAnd {
+			last := len(combined) - 1
+			combined[last] = chain.IsStillValid([]bool{combined[last], results[i+1]})
+			continue
 		}
+		combined = append(combined, results[i+1])
+		others = append(others, chain)
 	}
-	return boolResult
-}
-
-func (p *Parser) checkConditionalChainGroup(group []ConditionalChain) (ConditionChain, bool) {
-	boolResult := true
-	var nextChecker ConditionChain
-	for _, chainCondition := range group {
-		boolResult = boolResult && p.checkChainGroup(chainCondition.group)
-		nextChecker = chainCondition.nextCheck
-
+	// then the remaining chains from left to right
+	boolResult := combined[0]
+	for i, chain := range others {
+		boolResult = chain.IsStillValid([]bool{boolResult, combined[i+1]})
 	}
-	return nextChecker, boolResult
+	return boolResult
 }
 
 func (p *Parser) checkChainGroup(group []TokenSelfProvider) bool {
 	boolResult := false
+	if len(group) == 1 {
+		if tkn, ok := group[0].(*TBool); ok {
+			return tkn.Value
+		}
+	}
 	for index, token := range group {
 		switch tkn := token.(type) {
 		case Condition:
@@ -715,6 +715,30 @@ func (t *TOrPrecedence) Copy() TokenSelfProvider {
 	return &TOrPrecedence{Pos: t.Pos}
 }
 
+type TAndPrecedence struct {
+	Pos int
+}
+
+func (t *TAndPrecedence) ItsMe(token ScanToken) bool {
+	return token.Value == "&"
+}
+
+func (t *TAndPrecedence) SetValue(token ScanToken) {
+	t.Pos = token.Pos
+}
+
+func (t *TAndPrecedence) maybeWrong() bool {
+	return true
+}
+
+func (t *TAndPrecedence) wrongValue() string {
+	return "&"
+}
+
+func (t *TAndPrecedence) Copy() TokenSelfProvider {
+	return &TAndPrecedence{Pos: t.Pos}
+}
+
 type TOr struct {
 	Pos int
 }
@@ -68,6 +68,7 @@ func BasicTokens() []TokenSelfProvider {
 		&TCurlyClose{},
 		&Then{},
 		&Else{},
+		&TBool{},
 		&TVariable{},
 		&TString{},
 		&TEqual{},
@@ -78,14 +79,26 @@ func BasicTokens() []TokenSelfProvider {
 		&TGreaterOrEqual{},
 		&TAnd{},
 		&TOr{},
-		&TBool{},
 		&TNot{},
 		&TOrPrecedence{},
+		&TAndPrecedence{},
+		&TAssign{},
 		&TXor{},
 		&TPrefixedVariable{},
 	}
 }
 
+// BasicNeighborTokens returns the tokens, they are build from two chars.
+// like == from two TAssign tokens, or && from two TAndPrecedence tokens
+func BasicNeighborTokens() []TokenSelfProvider {
+	return []TokenSelfProvider{
+		&TEqual{},
+		&TNotEqual{},
+		&TAnd{},
+		&TOr{},
+	}
+}
+
 func (p *Parser) Parse(line string) {
 	p.defaultsIfNotSet()
 	tokens := p.lineScan(line)
@@ -125,6 +138,10 @@ func (p *Parser) defaultsIfNotSet() {
 	if p.useTokens == nil {
 		p.useTokens = BasicTokens()
 	}
+
+	if p.neighborTokens == nil {
+		p.neighborTokens = BasicNeighborTokens()
+	}
 }
 
 // SetUseTokens sets the tokens that are used in the line
@@ -454,8 +471,9 @@ func (p *Parser) compileTokens(tokens map[int]TokenSelfProvider) map[int]TokenSe
 						for _, check := range p.neighborTokens {
 							if check.ItsMe(p.createScanToken(recheckValue, pos)) {
 								p.Println("    i am the right one", reflect.TypeOf(check).String(), check)
-								check.SetValue(p.createScanToken(recheckValue, pos))
-								newTokenmap[pos] = check
+								neighbor := check.Copy()
+								neighbor.SetValue(p.createScanToken(recheckValue, pos))
+								newTokenmap[pos] = neighbor
 							}
 						}
 					}
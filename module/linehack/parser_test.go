@@ -323,3 +323,30 @@ func TestVarSet(t *testing.T) {
 	assert.Equal(t, "hello", chck.Argurment)
 	assert.True(t, result.(bool))
 }
+
+func TestCondition(t *testing.T) {
+	vars := map[string]string{"version": "1.0", "os": "linux"}
+	conditions := map[string]bool{
+		`$version == "1.0"`:                                 true,
+		`$version != "1.0"`:                                 false,
+		`$version == "1.0" && $os == "windows"`:             false,
+		`$version == "1.0" && $os == "linux"`:               true,
+		`$version == "2.0" || $os == "linux"`:               true,
+		`$version == "2.0" || $os == "linux" && "a" == "b"`: false,
+		`$version == "1.0" || $os == "linux" && "a" == "b"`: true,
+		`true`:  true,
+		`false`: false,
+	}
+	for condition, expected := range conditions {
+		parser := linehack.NewParser()
+		parser.SetVariableRequester(func(name string) (interface{}, error) {
+			return vars[name], nil
+		})
+		result, err := parser.Condition(condition)
+		assert.NoError(t, err, condition)
+		assert.Equal(t, expected, result, condition)
+	}
+
+	_, err := linehack.NewParser().Condition(`$version ~ "1.0"`)
+	assert.Error(t, err)
+}
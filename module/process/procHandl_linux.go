@@ -59,6 +59,19 @@ func KillProcessTree(pid int) error {
 	return syscall.Kill(-pid, syscall.SIGKILL)
 }
 
+// SignalProcessGroup sends the signal to the process group of the process.
+// if the process is not a group leader, only the process itself gets the signal
+func SignalProcessGroup(pid int, sig os.Signal) error {
+	sysSig, ok := sig.(syscall.Signal)
+	if !ok {
+		return fmt.Errorf("unsupported signal %v", sig)
+	}
+	if err := syscall.Kill(-pid, sysSig); err != nil {
+		return syscall.Kill(pid, sysSig)
+	}
+	return nil
+}
+
 func ReadProc(pid int) (*ProcData, error) {
 	if pid == 0 {
 		return nil, errors.New("ReadProc: Error. pid can not be 0")
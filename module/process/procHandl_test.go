@@ -2,6 +2,7 @@ package process_test
 
 import (
 	"os"
+	"os/exec"
 	"runtime"
 	"sync"
 	"testing"
@@ -255,3 +256,31 @@ func TestWaitForStopWorking(t *testing.T) {
 	}
 	testLogger.LogsToTestLog(t)
 }
+
+func TestSignalProcessGroup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("process groups are not supported on windows")
+	}
+	// the child process of the shell is also part of the process group
+	cmd := exec.Command("bash", "-c", "sleep 30; echo done")
+	process.TryPid2Pgid(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	if err := process.SignalProcessGroup(cmd.Process.Pid, os.Interrupt); err != nil {
+		t.Error(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case <-done:
+		if time.Since(start) > 2*time.Second {
+			t.Error("process group stopped too late")
+		}
+	case <-time.After(5 * time.Second):
+		process.KillProcessTree(cmd.Process.Pid)
+		t.Error("process group was not stopped by the signal")
+	}
+}
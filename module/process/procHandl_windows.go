@@ -58,6 +58,12 @@ func KillProcessTree(pid int) error {
 	}
 }
 
+// SignalProcessGroup on windows there are no process groups and signals
+// we can send. so the process is killed
+func SignalProcessGroup(pid int, sig os.Signal) error {
+	return KillProcessTree(pid)
+}
+
 func ReadProc(pid int) (*ProcData, error) {
 	proc, err := ProcInfo(pid)
 	if err != nil {
@@ -70,6 +70,8 @@ type Process struct {
 	logger           mimiclog.Logger            // the logger to use
 	reportChildCount bool                       // whether or not to report the child count
 	onChildCntChange ProcChildCntChangeCallback // a callback for the process handler if the amount of processes changed
+	workingDir       string                     // the directory the process is started in. if empty, the current directory is used
+	env              []string                   // the environment of the process. if nil, the environment of the current process is used
 }
 
 var (
@@ -146,6 +148,19 @@ func (p *Process) SetCombinePipes(combine bool) {
 	p.combinePipes = combine
 }
 
+// SetWorkingDir sets the directory the process is started in.
+// if not set, the process is started in the current directory.
+func (p *Process) SetWorkingDir(dir string) {
+	p.workingDir = dir
+}
+
+// SetEnv sets the environment of the process in the form "key=value".
+// if not set (nil), the process gets the environment of the current process.
+// an empty slice starts the process with an empty environment.
+func (p *Process) SetEnv(env []string) {
+	p.env = env
+}
+
 // SetTimeout sets the timeout for the process. If the process is not stopped after the timeout, it will be stopped
 func (p *Process) SetTimeout(timeout time.Duration) {
 	p.timoutSet = true
@@ -406,6 +421,8 @@ func (p *Process) WaitUntilRunning(tickTime time.Duration) (time.Duration, error
 func (p *Process) Exec() (int, int, error) {
 
 	cmd := exec.Command(p.cmd, p.args...)
+	cmd.Dir = p.workingDir
+	cmd.Env = p.env
 	p.logger.Debug("starting process: ", cmd, cmd.Args)
 	// set the process group id to kill the whole process tree if possible
 	TryPid2Pgid(cmd)
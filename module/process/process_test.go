@@ -2,6 +2,7 @@ package process_test
 
 import (
 	"os"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"testing"
@@ -203,6 +204,50 @@ func TestExecWithBash(t *testing.T) {
 	}
 }
 
+func TestExecInWorkingDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("pwd output is different on windows")
+	}
+	dir := t.TempDir()
+	outputs := []string{}
+	proc := process.NewTerminal()
+	proc.SetWorkingDir(dir)
+	proc.AddStartCommands("pwd")
+	proc.SetOnOutput(func(msg string, err error) bool {
+		outputs = append(outputs, msg)
+		return true
+	})
+	if _, _, err := proc.Exec(); err != nil {
+		t.Error(err)
+	}
+	realDir, _ := filepath.EvalSymlinks(dir)
+	if len(outputs) < 1 || (outputs[0] != dir && outputs[0] != realDir) {
+		t.Error("expected the process running in", dir, "but got", outputs)
+	}
+	if cwd, _ := os.Getwd(); cwd == dir {
+		t.Error("the current directory of the process should not be changed")
+	}
+}
+
+func TestExecWithEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("env output is different on windows")
+	}
+	outputs := []string{}
+	proc := process.NewProcess("bash", "-c", "echo \"[$CTX_PROC_TEST][$USER]\"")
+	proc.SetEnv([]string{"CTX_PROC_TEST=hello", "PATH=" + os.Getenv("PATH")})
+	proc.SetOnOutput(func(msg string, err error) bool {
+		outputs = append(outputs, msg)
+		return true
+	})
+	if _, _, err := proc.Exec(); err != nil {
+		t.Error(err)
+	}
+	if len(outputs) < 1 || outputs[0] != "[hello][]" {
+		t.Error("expected only the given environment, but got", outputs)
+	}
+}
+
 func TestExecWithBashAndStayOpen(t *testing.T) {
 	outPuts := []string{}
 	proc := process.NewTerminal()
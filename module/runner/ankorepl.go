@@ -0,0 +1,239 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/mattn/anko/parser"
+	"github.com/swaros/contxt/module/ctxout"
+	"github.com/swaros/contxt/module/ctxshell"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+// AnkoRepl is an interactive session for anko commands.
+// the variables and functions are kept between the inputs.
+type AnkoRepl struct {
+	anko     *tasks.AnkoRunner
+	defines  []tasks.AnkoDefiner
+	baseSyms map[string]bool // the symbols they are defined before the first input. vars() ignores them
+	pending  []string        // the lines of an input they is not complete yet
+	out      func(string)
+}
+
+// NewAnkoRepl creates the session for the runner.
+// help(fn) and vars() are defined in addition to the functions of the runner.
+func NewAnkoRepl(anko *tasks.AnkoRunner) *AnkoRepl {
+	r := &AnkoRepl{
+		anko:     anko,
+		defines:  anko.GetDefaultDefines(),
+		baseSyms: make(map[string]bool),
+		out: func(msg string) {
+			ctxout.PrintLn(msg)
+		},
+	}
+	anko.SetOutputSupression(true)
+	anko.SetBufferHook(func(msg string) {
+		r.out(msg)
+	})
+	anko.Define("help", r.help)
+	anko.Define("vars", r.vars)
+	if env, err := anko.InitEnv(); err == nil {
+		for _, symbol := range env.GetValueSymbols() {
+			r.baseSyms[symbol] = true
+		}
+	}
+	return r
+}
+
+// SetOutput sets the function for the output of the session
+func (r *AnkoRepl) SetOutput(out func(string)) {
+	r.out = out
+}
+
+// Pending returns true, if the last input was not complete
+func (r *AnkoRepl) Pending() bool {
+	return len(r.pending) > 0
+}
+
+// Cancel drops the lines of an incomplete input
+func (r *AnkoRepl) Cancel() {
+	r.pending = nil
+}
+
+// Eval executes the input. if the input is not complete, like an open function body,
+// the input is kept and complete is false. the next input is added to them.
+func (r *AnkoRepl) Eval(input string) (result interface{}, complete bool, err error) {
+	r.pending = append(r.pending, input)
+	script := strings.Join(r.pending, "\n")
+	if incompleteScript(script) {
+		return nil, false, nil
+	}
+	r.pending = nil
+	// a previous exit() cancels the context. so any input gets a new one
+	r.anko.SetContext(context.Background())
+	r.anko.EnableCancelation()
+	result, err = r.anko.RunAnko(script)
+	return result, true, err
+}
+
+// incompleteScript checks if the parser fails, because the script ends too early
+func incompleteScript(script string) bool {
+	_, err := parser.ParseSrc(script)
+	pErr, ok := err.(*parser.Error)
+	if !ok {
+		return false
+	}
+	if pErr.Message == "unexpected EOF" {
+		return true
+	}
+	lines := strings.Split(script, "\n")
+	lastCol := len([]rune(lines[len(lines)-1])) + 1
+	return pErr.Pos.Line > len(lines) || (pErr.Pos.Line == len(lines) && pErr.Pos.Column >= lastCol)
+}
+
+// Do implements the readline.AutoCompleter interface.
+// it completes the symbol in front of the cursor.
+func (r *AnkoRepl) Do(line []rune, pos int) ([][]rune, int) {
+	start := pos
+	for start > 0 && (unicode.IsLetter(line[start-1]) || unicode.IsDigit(line[start-1]) || line[start-1] == '_') {
+		start--
+	}
+	prefix := string(line[start:pos])
+	if prefix == "" {
+		return nil, 0
+	}
+	var found [][]rune
+	for _, symbol := range r.Symbols() {
+		if strings.HasPrefix(symbol, prefix) && symbol != prefix {
+			found = append(found, []rune(symbol[len(prefix):]))
+		}
+	}
+	return found, len([]rune(prefix))
+}
+
+// Symbols returns the sorted names of all symbols they are defined
+func (r *AnkoRepl) Symbols() []string {
+	unique := make(map[string]bool)
+	for _, def := range r.defines {
+		unique[def.Symbol()] = true
+	}
+	for _, symbol := range r.anko.GetEnv().GetValueSymbols() {
+		unique[symbol] = true
+	}
+	symbols := []string{}
+	for symbol := range unique {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// help prints the help text of the function. the function can be the name, or the function itself.
+// without an argument, all functions are listed
+func (r *AnkoRepl) help(fn ...interface{}) {
+	if len(fn) == 0 {
+		for _, def := range r.defines {
+			r.out(ctxout.ToString(ctxout.NewMOWrap(), ctxout.ForeLightYellow, def.Symbol(), ctxout.ForeDarkGrey, " (", def.Risk(), ")", ctxout.CleanTag))
+		}
+		r.out("use help(\"name\") for the help text of a function")
+		return
+	}
+	for _, def := range r.defines {
+		if r.isDefine(def, fn[0]) {
+			r.out(ctxout.ToString(ctxout.NewMOWrap(), ctxout.ForeLightYellow, def.Symbol(), ctxout.ForeDarkGrey, " risk level ", def.Risk(), ctxout.CleanTag))
+			r.out(def.Help())
+			return
+		}
+	}
+	r.out(fmt.Sprintf("no help found for %v", fn[0]))
+}
+
+// isDefine checks if the argument is the name, or the function of the define
+func (r *AnkoRepl) isDefine(def tasks.AnkoDefiner, fn interface{}) bool {
+	if name, ok := fn.(string); ok {
+		return name == def.Symbol()
+	}
+	value, err := r.anko.GetEnv().Get(def.Symbol())
+	if err != nil || value == nil || fn == nil {
+		return false
+	}
+	fnValue, defValue := reflect.ValueOf(fn), reflect.ValueOf(value)
+	return fnValue.Kind() == reflect.Func && defValue.Kind() == reflect.Func && fnValue.Pointer() == defValue.Pointer()
+}
+
+// vars prints the variables they are defined in the session
+func (r *AnkoRepl) vars() {
+	symbols := r.anko.GetEnv().GetValueSymbols()
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		if r.baseSyms[symbol] {
+			continue
+		}
+		value, err := r.anko.GetEnv().Get(symbol)
+		if err != nil {
+			continue
+		}
+		r.out(ctxout.ToString(ctxout.NewMOWrap(), ctxout.ForeLightYellow, symbol, ctxout.ForeDarkGrey, " = ", ctxout.ForeWhite, fmt.Sprintf("%#v", value), ctxout.ForeDarkGrey, fmt.Sprintf(" (%T)", value), ctxout.CleanTag))
+	}
+}
+
+// Run starts the session. it ends by the exit command
+func (r *AnkoRepl) Run() error {
+	shell := ctxshell.NewCshell()
+	r.SetOutput(shell.Stdoutln)
+	shell.SetAutoCompleter(r).
+		SetHistoryFile(filepath.Join(os.TempDir(), "contxt_anko_history.tmp")).
+		SetExitCmdStr("exit").
+		SetPromptFunc(func(reason int) string {
+			if r.Pending() {
+				return ctxout.ToString(ctxout.NewMOWrap(), ctxout.ForeDarkGrey, "... ", ctxout.CleanTag)
+			}
+			return ctxout.ToString(ctxout.NewMOWrap(), ctxout.ForeLightBlue, "anko> ", ctxout.CleanTag)
+		})
+	shell.AddNativeCmd(ctxshell.NewNativeCmd(":cancel", "drop the lines of an incomplete input", func(args []string) error {
+		r.Cancel()
+		return nil
+	}))
+	shell.OnUnknownCmdFunc(func(line string) error {
+		result, complete, err := r.Eval(line)
+		if err != nil {
+			return r.anko.ErrorPos2Message(err)
+		}
+		if complete && result != nil {
+			shell.Stdoutln(ctxout.ToString(ctxout.NewMOWrap(), ctxout.ForeDarkGrey, "=> ", ctxout.ForeWhite, fmt.Sprintf("%v", result), ctxout.CleanTag))
+		}
+		return nil
+	})
+	shell.Stdoutln(ctxout.ToString(ctxout.NewMOWrap(), ctxout.ForeDarkGrey, "anko session. help() lists the functions, vars() the variables. exit to leave", ctxout.CleanTag))
+	return shell.Run()
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/swaros/contxt/module/dirhandle"
 	"github.com/swaros/contxt/module/mimiclog"
 	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
 )
 
 type SessionCobra struct {
@@ -57,6 +58,10 @@ type CobraOptions struct {
 	ShowVars             bool              // show the variables
 	ShowVarsPattern      string            // show the variables with a pattern
 	OutputHandler        string            // set the output handler by name
+	ListTargets          bool              // list the targets with descriptions
+	Hermetic             bool              // run all tasks in hermetic mode
+	OutputMode           string            // the output mode for all tasks (stream or grouped)
+	OutputLiveErrors     bool              // print errors at once in grouped mode for all tasks
 }
 
 // this is the main entry point for the cobra command
@@ -109,6 +114,8 @@ func (c *SessionCobra) Init(cmd CmdExecutor) error {
 		c.GetVariablesCmd(),
 		c.GetCreateCmd(),
 		c.GetAnkoRunCmd(),
+		c.GetAnkoTestCmd(),
+		c.GetLocksCmd(),
 	)
 	c.RootCmd.SilenceUsage = true
 	return nil
@@ -161,9 +168,23 @@ func (c *SessionCobra) GetAnkoRunCmd() *cobra.Command {
 like: ctx anko 'println("hello world")'
 
 use -f <filename> to set a file, that contains the anko commands
-like: ctx anko -f my-anko-script.ank`,
+like: ctx anko -f my-anko-script.ank
+
+use --list-functions to show the functions they can be used in the cmd section of a task,
+together with their risk level and help text.
+
+use --repl to start an interactive session. the functions of the cmd section, and the
+variables of the current template can be used there. help("name") shows the help text
+of a function, vars() lists the variables of the session.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			c.checkDefaultFlags(cmd, args)
+			if listFns, _ := cmd.Flags().GetBool("list-functions"); listFns {
+				c.ExternalCmdHndl.PrintAnkoFunctions()
+				return nil
+			}
+			if repl, _ := cmd.Flags().GetBool("repl"); repl {
+				return c.ExternalCmdHndl.RunAnkoRepl()
+			}
 			if len(args) == 0 {
 				if incFile, err := cmd.Flags().GetString("file"); err != nil {
 					return err
@@ -196,6 +217,32 @@ like: ctx anko -f my-anko-script.ank`,
 		},
 	}
 	cmd.Flags().StringP("file", "f", "", "set file path to execute")
+	cmd.Flags().Bool("list-functions", false, "list the functions of the cmd section with their risk level")
+	cmd.Flags().Bool("repl", false, "start an interactive anko session")
+	return cmd
+}
+
+func (c *SessionCobra) GetAnkoTestCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test [paths...]",
+		Short: "run the tests of anko test files",
+		Long: `runs the tests of the anko test files (*_test.ank) in the given paths.
+without a path, the current directory and any sub directory is used.
+like: ctx test
+like: ctx test scripts/math_test.ank
+
+any function they starts with test_ is a test, and runs in a fresh anko runner.
+the assertions assertEqual(expected, actual), assertTrue(condition), assertError(err)
+and fail(message) can be used in the tests.
+
+use --format to set the format of the report: plain, json or junit`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.checkDefaultFlags(cmd, args)
+			format, _ := cmd.Flags().GetString("format")
+			return c.ExternalCmdHndl.RunAnkoTests(args, format)
+		},
+	}
+	cmd.Flags().String("format", tasks.AnkoTestFormatPlain, "format of the report. plain, json or junit")
 	return cmd
 }
 
@@ -605,22 +652,39 @@ func (c *SessionCobra) GetRunCmd() *cobra.Command {
 		Long:  "run a command in the context of a project",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			c.checkDefaultFlags(cmd, args)
-			if len(args) > 0 {
-				c.log().Debug("run command in context of project", args)
-				if err := c.ExternalCmdHndl.InitExecuter(); err != nil {
+			if c.Options.ListTargets {
+				c.Options.ListTargets = false // one time usage
+				c.ExternalCmdHndl.PrintTargetList(false)
+				return nil
+			}
+			defer func() { // one time usage
+				c.Options.Hermetic = false
+				c.Options.OutputMode = ""
+				c.Options.OutputLiveErrors = false
+			}()
+			if len(args) == 0 {
+				// without a target, the targets can be selected in a terminal
+				picked, interactive, err := c.ExternalCmdHndl.PickTargets()
+				if err != nil {
 					return err
 				}
-				for _, p := range args {
-					if err := c.ExternalCmdHndl.RunTargets(p, true); err != nil {
-						return err
+				if !interactive {
+					for _, p := range c.ExternalCmdHndl.GetTargets(false) {
+						c.println(p)
 					}
+					return nil
 				}
+				args = picked
 			} else {
-				targets := c.ExternalCmdHndl.GetTargets(false)
-				for _, p := range targets {
-					c.println(p)
+				c.log().Debug("run command in context of project", args)
+				if err := c.ExternalCmdHndl.InitExecuter(); err != nil {
+					return err
+				}
+			}
+			for _, p := range args {
+				if err := c.ExternalCmdHndl.RunTargets(p, true); err != nil {
+					return err
 				}
-				return nil
 			}
 			return nil
 		},
@@ -628,10 +692,14 @@ func (c *SessionCobra) GetRunCmd() *cobra.Command {
 			if len(args) != 0 {
 				return nil, cobra.ShellCompDirectiveNoFileComp
 			}
-			targets := c.ExternalCmdHndl.GetTargets(false)
+			targets := TargetCompletions(c.ExternalCmdHndl.GetTargetInfos(false))
 			return targets, cobra.ShellCompDirectiveNoFileComp
 		},
 	}
+	rCmd.Flags().BoolVarP(&c.Options.ListTargets, "list", "l", false, "list all targets grouped and with description")
+	rCmd.Flags().BoolVar(&c.Options.Hermetic, "hermetic", false, "run all tasks with a controlled environment, like the hermetic option is set for any task")
+	rCmd.Flags().StringVar(&c.Options.OutputMode, "output", "", "set the output mode for all tasks. stream or grouped (any target output is printed as one block)")
+	rCmd.Flags().BoolVar(&c.Options.OutputLiveErrors, "live-errors", false, "print errors at once in grouped output mode, like outputLiveErrors is set for any task")
 	rCmd.AddCommand(c.GetRunAtAllCmd())
 	return rCmd
 }
@@ -993,6 +1061,21 @@ func (c *SessionCobra) GetVariablesCmd() *cobra.Command {
 	return vCmd
 }
 
+func (c *SessionCobra) GetLocksCmd() *cobra.Command {
+	lCmd := &cobra.Command{
+		Use:   "locks",
+		Short: "shows the current target locks",
+		Long: `list all targets, that are locked by a running contxt process.
+targets are locked, if they have the lock option set to exclusive or shared.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c.checkDefaultFlags(cmd, args)
+			c.ExternalCmdHndl.PrintLocks()
+			return nil
+		},
+	}
+	return lCmd
+}
+
 func (c *SessionCobra) GetVersionCmd() *cobra.Command {
 	vCmd := &cobra.Command{
 		Use:   "version",
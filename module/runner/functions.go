@@ -33,9 +33,11 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/ctemplate"
 	"github.com/swaros/contxt/module/ctxout"
 	"github.com/swaros/contxt/module/dirhandle"
 	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/shellcmd"
 	"github.com/swaros/contxt/module/systools"
 	"github.com/swaros/contxt/module/tasks"
 	"github.com/swaros/contxt/module/yaclint"
@@ -200,6 +202,17 @@ func (c *CmdExecutorImpl) CallBackNewWs(newWs string) {
 // set the default runtime variables depeding the predefined variables from
 // the main init, and the given variables depending the task and environment
 func (c *CmdExecutorImpl) SetStartupVariables(dataHndl *tasks.CombinedDh, template *configure.RunConfig) {
+	currentDir, err := os.Getwd()
+	if err != nil {
+		c.session.Log.Logger.Error("error while getting current dir", err)
+	}
+	c.setStartupVariablesInPath(dataHndl, template, currentDir, c.session.TemplateHndl)
+}
+
+// setStartupVariablesInPath sets the startup variables for a template in the given path.
+// the path is used as BASEPATH, and the imports of the template are resolved against it.
+// an empty path means the current directory
+func (c *CmdExecutorImpl) setStartupVariablesInPath(dataHndl *tasks.CombinedDh, template *configure.RunConfig, path string, templateHndl *ctemplate.Template) {
 	// first apply logger if poosible
 	mimiclog.ApplyLogger(c.session.Log.Logger, dataHndl)
 
@@ -210,13 +223,10 @@ func (c *CmdExecutorImpl) SetStartupVariables(dataHndl *tasks.CombinedDh, templa
 		dataHndl.SetPH(k, v)
 	}
 
-	currentDir, err := os.Getwd()
-	if err != nil {
-		c.session.Log.Logger.Error("error while getting current dir", err)
-	} else {
-		// we will override the current dir from the predefined ones, with the current dir
-		dataHndl.SetPH("CTX_PWD", currentDir)
-		dataHndl.SetPH("BASEPATH", currentDir)
+	if path != "" {
+		// we will override the current dir from the predefined ones, with the path of the template
+		dataHndl.SetPH("CTX_PWD", path)
+		dataHndl.SetPH("BASEPATH", path)
 	}
 
 	// template depending variables
@@ -234,7 +244,7 @@ func (c *CmdExecutorImpl) SetStartupVariables(dataHndl *tasks.CombinedDh, templa
 	c.session.Log.Logger.Debug("set startup variables for ws2", keys)
 	dataHndl.SetPH("CTX_WS_KEYS", keys)
 	// read the imports from the template and set them to the datahandler
-	c.handleImports(dataHndl, template)
+	c.handleImports(dataHndl, template, path, templateHndl)
 }
 
 // taking care about the imports.
@@ -271,10 +281,11 @@ func (c *CmdExecutorImpl) SetStartupVariables(dataHndl *tasks.CombinedDh, templa
 //	  - imports.txt txt
 //
 // this can be used in the tasks as ${txt}
-func (c *CmdExecutorImpl) handleImports(dataHndl *tasks.CombinedDh, template *configure.RunConfig) {
+func (c *CmdExecutorImpl) handleImports(dataHndl *tasks.CombinedDh, template *configure.RunConfig, path string, templateHndl *ctemplate.Template) {
 	c.session.Log.Logger.Info("handle imports")
-	importHndlr := NewImportHandler(c.session.Log.Logger, dataHndl, c.session.TemplateHndl)
+	importHndlr := NewImportHandler(c.session.Log.Logger, dataHndl, templateHndl)
 	importHndlr.SetImports(template.Config.Imports)
+	importHndlr.SetBasePath(path)
 	if err := importHndlr.HandleImports(); err != nil {
 		c.Println(ctxout.ForeRed, "error while handling imports", ctxout.ForeYellow, err)
 		c.session.Log.Logger.Error("error while handling imports", err)
@@ -338,6 +349,22 @@ func (c *CmdExecutorImpl) runAsyncTargets(targets []string, force bool) error {
 	return nil
 }
 
+// ankoModules returns the anko modules of the project, the template is loaded from.
+// if no template is loaded, the modules of the current directory are used
+func (c *CmdExecutorImpl) ankoModules(template configure.RunConfig) *tasks.AnkoModules {
+	return c.session.SharedHelper.AnkoModules(c.session.TemplateHndl.GetBasePath(), template)
+}
+
+// riskPolicy returns the policy for the risk level of the anko functions, from the user configuration
+func (c *CmdExecutorImpl) riskPolicy() (*tasks.RiskPolicy, error) {
+	policy, err := tasks.NewRiskPolicy(configure.GetGlobalConfig().UsedV2Config.AnkoPolicy)
+	if err != nil {
+		c.session.Log.Logger.Error("invalid anko policy", err)
+		return nil, fmt.Errorf("invalid AnkoPolicy in the user configuration: %w", err)
+	}
+	return policy, nil
+}
+
 func (c *CmdExecutorImpl) setDefaultOutHandlers() {
 	c.addOutHandler(NewTableOutput())
 	c.addOutHandler(NewPlainOutput())
@@ -352,26 +379,42 @@ func (c *CmdExecutorImpl) InitExecuter() error {
 		c.session.Log.Logger.Error("template not exists")
 		return errors.New("no contxt template found in current directory")
 	} else {
+		return c.initExecuterWith(template)
+	}
+}
 
-		c.dataHandl = tasks.NewCombinedDataHandler()
-		c.SetStartupVariables(c.dataHandl, &template)
+// initExecuterWith creates the executer for the given template
+func (c *CmdExecutorImpl) initExecuterWith(template configure.RunConfig) error {
+	c.dataHandl = tasks.NewCombinedDataHandler()
+	c.SetStartupVariables(c.dataHandl, &template)
 
-		c.setDefaultOutHandlers() // register any outputhandler
-		outputHndl, err := c.setOutHandler(c.usedHandler)
-		if err != nil {
-			return err
-		}
+	c.setDefaultOutHandlers() // register any outputhandler
+	outputHndl, err := c.setOutHandler(c.usedHandler)
+	if err != nil {
+		return err
+	}
 
-		requireHndl := tasks.NewDefaultRequires(c.dataHandl, c.session.Log.Logger)
-		c.executer = tasks.NewTaskListExec(
-			template,
-			c.dataHandl,
-			requireHndl,
-			outputHndl,
-			tasks.ShellCmd,
-		)
-		c.executer.SetLogger(c.session.Log.Logger)
+	riskPolicy, err := c.riskPolicy()
+	if err != nil {
+		return err
 	}
+
+	requireHndl := tasks.NewDefaultRequires(c.dataHandl, c.session.Log.Logger)
+	c.executer = tasks.NewTaskListExec(
+		template,
+		c.dataHandl,
+		requireHndl,
+		outputHndl,
+		tasks.ShellCmd,
+		NewShellPrompter(),
+		NewProjectRunner(c),
+		c.ankoModules(template),
+		riskPolicy,
+	)
+	c.executer.SetLogger(c.session.Log.Logger)
+	c.executer.SetHermeticToAllTasks(c.session.Cobra.Options.Hermetic)
+	c.executer.SetOutputModeToAllTasks(c.session.Cobra.Options.OutputMode)
+	c.executer.SetOutputLiveErrorsToAllTasks(c.session.Cobra.Options.OutputLiveErrors)
 	return nil
 }
 
@@ -400,6 +443,13 @@ func (c *CmdExecutorImpl) RunTargets(target string, force bool) error {
 	c.dataHandl.SetPH("CTX_TARGET", target)
 	c.dataHandl.SetPH("CTX_FORCE", strconv.FormatBool(force))
 
+	// while targets are running, an interrupt is forwarded to the tasks
+	// instead of exiting the app at once
+	if activeRuns.Add(1) == 1 {
+		tasks.ResetInterrupt()
+	}
+	defer activeRuns.Add(-1)
+
 	c.executer.SetLogger(c.session.Log.Logger)
 	code := c.executer.RunTarget(target, force)
 	switch code {
@@ -421,6 +471,12 @@ func (c *CmdExecutorImpl) RunTargets(target string, force bool) error {
 	case systools.ExitByUnsupportedVersion:
 		c.session.Log.Logger.Error("unsupported version")
 		return errors.New("unsupported version")
+	case systools.ExitByMissingInput:
+		c.session.Log.Logger.Error("missing input for target ", target)
+		return errors.New("missing input for target:" + target)
+	case systools.ExitByInterrupt:
+		c.session.Log.Logger.Info("target interrupted ", target)
+		return ErrInterrupted
 	default:
 		c.session.Log.Logger.Error("unexpected exit code:", code)
 		return errors.New("unexpected exit code:" + fmt.Sprintf("%d", code))
@@ -821,6 +877,378 @@ func TemplateTargetsAsMap(template configure.RunConfig, showInvTarget bool) ([]s
 	return targets, found
 }
 
+// TargetInfo contains the informations about a target, that are used for
+// listings and the shell completion
+type TargetInfo struct {
+	ID          string
+	Description string
+	Group       string
+	Aliases     []string
+}
+
+// TemplateTargetInfos returns the informations of any target in the template.
+// targets they are defined more than once, are merged.
+// the result is sorted by group and then by id
+func TemplateTargetInfos(template configure.RunConfig, showInvTarget bool) []TargetInfo {
+	var infos []TargetInfo
+	indexById := make(map[string]int)
+	for _, task := range template.Task {
+		if task.Options.Invisible && !showInvTarget {
+			continue
+		}
+		id := strings.TrimSpace(task.ID)
+		index, exists := indexById[id]
+		if !exists {
+			infos = append(infos, TargetInfo{ID: id})
+			index = len(infos) - 1
+			indexById[id] = index
+		}
+		if infos[index].Description == "" {
+			infos[index].Description = strings.TrimSpace(task.Description)
+		}
+		if infos[index].Group == "" {
+			infos[index].Group = strings.TrimSpace(task.Group)
+		}
+		for _, alias := range task.Aliases {
+			alias = strings.TrimSpace(alias)
+			if alias != "" && !systools.SliceContains(infos[index].Aliases, alias) {
+				infos[index].Aliases = append(infos[index].Aliases, alias)
+			}
+		}
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		if infos[i].Group != infos[j].Group {
+			return infos[i].Group < infos[j].Group
+		}
+		return infos[i].ID < infos[j].ID
+	})
+	return infos
+}
+
+// TargetCompletions returns the targets and aliases with the description
+// as completion entries. the description is separated by a tab, as expected by cobra
+func TargetCompletions(infos []TargetInfo) []string {
+	var completions []string
+	for _, info := range infos {
+		if info.Description != "" {
+			completions = append(completions, info.ID+"\t"+info.Description)
+		} else {
+			completions = append(completions, info.ID)
+		}
+		for _, alias := range info.Aliases {
+			completions = append(completions, alias+"\talias for "+info.ID)
+		}
+	}
+	return completions
+}
+
+// TargetSelectEntries returns the targets as entries for the interactive selection.
+// the description contains the requirement status of the target. a target is ready,
+// if the requirements of at least one of the tasks are matching.
+// the requirements are checked in the working dir of the task. a relative working dir
+// is resolved against the BASEPATH variable.
+func TargetSelectEntries(template configure.RunConfig, requires tasks.Requires, vars tasks.PlaceHolder) []shellcmd.SelectEntry {
+	var entries []shellcmd.SelectEntry
+	targets, found := TemplateTargetsAsMap(template, false)
+	if !found {
+		return entries
+	}
+	descriptions := make(map[string]string)
+	for _, info := range TemplateTargetInfos(template, false) {
+		descriptions[info.ID] = info.Description
+	}
+	baseDir := ""
+	if vars != nil {
+		baseDir, _ = vars.GetPHExists("BASEPATH")
+	}
+	for _, target := range targets {
+		status := ""
+		for _, task := range template.Task {
+			if strings.TrimSpace(task.ID) != target {
+				continue
+			}
+			var canRun bool
+			var message string
+			if dirRequires, ok := requires.(tasks.RequiresInDir); ok {
+				canRun, message = dirRequires.CheckRequirementsInDir(task.Requires, tasks.ResolveWorkingDir(task, baseDir, vars))
+			} else {
+				canRun, message = requires.CheckRequirements(task.Requires)
+			}
+			if canRun {
+				status = ""
+				break
+			}
+			status = "requirements not matching: " + message
+		}
+		description := descriptions[target]
+		switch {
+		case status != "" && description != "":
+			description += " (" + status + ")"
+		case status != "":
+			description = status
+		}
+		entries = append(entries, shellcmd.SelectEntry{Title: target, Description: description})
+	}
+	return entries
+}
+
+// PickTargets shows the targets in a list, they can be filtered and selected.
+// the second return value is false if we are not running in a terminal.
+// if the selection is aborted, the list of targets is empty.
+// an error is returned, if the template can not be loaded or the selection can not be shown
+func (c *CmdExecutorImpl) PickTargets() ([]string, bool, error) {
+	if !NewShellPrompter().IsInteractive() {
+		return nil, false, nil
+	}
+	template, exists, err := c.session.TemplateHndl.Load()
+	if err != nil {
+		return nil, true, err
+	}
+	if !exists {
+		return nil, true, errors.New("no contxt template found in current directory")
+	}
+	if err := c.InitExecuter(); err != nil {
+		return nil, true, err
+	}
+	entries := TargetSelectEntries(template, tasks.NewDefaultRequires(c.dataHandl, c.session.Log.Logger), c.dataHandl)
+	if len(entries) == 0 {
+		return nil, false, nil
+	}
+	selected, ok, err := shellcmd.SelectMany("select the targets to run", entries)
+	if err != nil {
+		return nil, true, err
+	}
+	if ok {
+		return selected, true, nil
+	}
+	return []string{}, true, nil
+}
+
+func (c *CmdExecutorImpl) GetTargetInfos(incInvisible bool) []TargetInfo {
+	if template, exists, err := c.session.TemplateHndl.Load(); err != nil {
+		c.session.Log.Logger.Error("error while loading template", err)
+		c.tryExplainError(err)
+	} else if !exists {
+		c.session.Log.Logger.Debug("template not exists", err)
+	} else {
+		return TemplateTargetInfos(template, incInvisible)
+	}
+	return nil
+}
+
+// PrintTargetList prints all targets grouped by the group name,
+// together with the aliases and the description
+func (c *CmdExecutorImpl) PrintTargetList(incInvisible bool) {
+	infos := c.GetTargetInfos(incInvisible)
+	if len(infos) == 0 {
+		c.Println(ctxout.ForeDarkGrey, "no targets defined", ctxout.CleanTag)
+		return
+	}
+	currentGroup := ""
+	c.Print("<table>")
+	for i, info := range infos {
+		if i == 0 || info.Group != currentGroup {
+			currentGroup = info.Group
+			groupName := currentGroup
+			if groupName == "" {
+				groupName = "targets"
+			}
+			c.Print("<row>", ctxout.BoldTag, ctxout.ForeLightBlue, "<tab size='100' fill=' '>", groupName, "</tab>", ctxout.CleanTag, "</row>")
+		}
+		c.Print(
+			ctxout.Row(
+				ctxout.TD(
+					"  "+info.ID,
+					ctxout.Prop(ctxout.AttrSize, 25),
+					ctxout.Prop(ctxout.AttrPrefix, ctxout.ForeLightYellow),
+					ctxout.Prop(ctxout.AttrSuffix, ctxout.CleanTag),
+				),
+				ctxout.TD(
+					strings.Join(info.Aliases, ", "),
+					ctxout.Prop(ctxout.AttrSize, 20),
+					ctxout.Prop(ctxout.AttrPrefix, ctxout.ForeDarkGrey),
+					ctxout.Prop(ctxout.AttrSuffix, ctxout.CleanTag),
+				),
+				ctxout.TD(
+					info.Description,
+					ctxout.Prop(ctxout.AttrSize, 54),
+					ctxout.Prop(ctxout.AttrOverflow, "wordwrap"),
+					ctxout.Prop(ctxout.AttrPrefix, ctxout.ForeWhite),
+					ctxout.Prop(ctxout.AttrSuffix, ctxout.CleanTag),
+				),
+			),
+		)
+	}
+	c.Println("</table>")
+}
+
+// PrintLocks prints all targets they are currently locked by any contxt process
+func (c *CmdExecutorImpl) PrintLocks() {
+	locks, err := tasks.ListTargetLocks()
+	if err != nil {
+		c.Println(ctxout.ForeRed, "error while reading the locks: ", ctxout.CleanTag, err.Error())
+		return
+	}
+	if len(locks) == 0 {
+		c.Println(ctxout.ForeDarkGrey, "no targets locked", ctxout.CleanTag)
+		return
+	}
+	c.Print("<table>")
+	for _, lock := range locks {
+		c.Print(
+			ctxout.Row(
+				ctxout.TD(
+					lock.Target,
+					ctxout.Prop(ctxout.AttrSize, 20),
+					ctxout.Prop(ctxout.AttrPrefix, ctxout.ForeLightYellow),
+					ctxout.Prop(ctxout.AttrSuffix, ctxout.CleanTag),
+				),
+				ctxout.TD(
+					lock.Mode,
+					ctxout.Prop(ctxout.AttrSize, 10),
+					ctxout.Prop(ctxout.AttrPrefix, ctxout.ForeLightBlue),
+					ctxout.Prop(ctxout.AttrSuffix, ctxout.CleanTag),
+				),
+				ctxout.TD(
+					"pid "+strconv.Itoa(lock.Pid),
+					ctxout.Prop(ctxout.AttrSize, 12),
+					ctxout.Prop(ctxout.AttrPrefix, ctxout.ForeWhite),
+					ctxout.Prop(ctxout.AttrSuffix, ctxout.CleanTag),
+				),
+				ctxout.TD(
+					lock.Since.Format("2006-01-02 15:04:05"),
+					ctxout.Prop(ctxout.AttrSize, 20),
+					ctxout.Prop(ctxout.AttrPrefix, ctxout.ForeDarkGrey),
+					ctxout.Prop(ctxout.AttrSuffix, ctxout.CleanTag),
+				),
+				ctxout.TD(
+					lock.Project,
+					ctxout.Prop(ctxout.AttrSize, 37),
+					ctxout.Prop(ctxout.AttrOverflow, "ignore"),
+					ctxout.Prop(ctxout.AttrPrefix, ctxout.ForeWhite),
+					ctxout.Prop(ctxout.AttrSuffix, ctxout.CleanTag),
+				),
+			),
+		)
+	}
+	c.Println("</table>")
+}
+
+// RunAnkoRepl starts an interactive anko session.
+// the functions of the cmd section, and the variables of the template in the current directory can be used.
+// without a template, only the default variables are set.
+func (c *CmdExecutorImpl) RunAnkoRepl() error {
+	template, exists, err := c.session.TemplateHndl.Load()
+	if err != nil {
+		c.tryExplainError(err)
+		return err
+	}
+	if !exists {
+		template = configure.RunConfig{}
+	}
+	if err := c.initExecuterWith(template); err != nil {
+		return err
+	}
+	return NewAnkoRepl(c.executer.NewAnkoRunner()).Run()
+}
+
+// RunAnkoTests runs the test functions of the anko test files, they are found in the paths.
+// without a path, the current directory is used.
+// the runners are created like in the cmd section of the template in the current directory.
+// the report is written to stdout in the given format.
+func (c *CmdExecutorImpl) RunAnkoTests(paths []string, format string) error {
+	if err := tasks.CheckAnkoTestFormat(format); err != nil {
+		return err
+	}
+	template, exists, err := c.session.TemplateHndl.Load()
+	if err != nil {
+		c.tryExplainError(err)
+		return err
+	}
+	if !exists {
+		template = configure.RunConfig{}
+	}
+	if err := c.initExecuterWith(template); err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	var files []string
+	for _, path := range paths {
+		found, err := tasks.FindAnkoTests(path)
+		if err != nil {
+			return err
+		}
+		files = append(files, found...)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no anko test files (*%s) found in %s", tasks.AnkoTestSuffix, strings.Join(paths, ", "))
+	}
+	// any test gets its own copy of the data, so the tests can not change the data of each other
+	tester := tasks.NewAnkoTester(func(file string) *tasks.AnkoRunner {
+		return c.executer.NewAnkoRunnerWithData(c.dataHandl.Copy())
+	})
+	results := tester.Run(files)
+	if err := tasks.WriteAnkoTestReport(os.Stdout, format, results); err != nil {
+		return err
+	}
+	if failed := tasks.AnkoTestsFailed(results); failed > 0 {
+		return fmt.Errorf("%d of %d anko tests failed", failed, len(results))
+	}
+	return nil
+}
+
+// PrintAnkoFunctions prints the functions they can be used in the cmd section of a task.
+// the risk level is compared with the allowed level of the policy
+func (c *CmdExecutorImpl) PrintAnkoFunctions() {
+	policy, err := c.riskPolicy()
+	if err != nil {
+		c.Println(ctxout.ForeRed, err.Error(), ctxout.CleanTag)
+		policy = tasks.NewDefaultRiskPolicy()
+	}
+	c.Println(
+		ctxout.ForeDarkGrey, "allowed risk level: local ", ctxout.ForeWhite, policy.Local,
+		ctxout.ForeDarkGrey, ", trusted shared ", ctxout.ForeWhite, policy.Trusted,
+		ctxout.ForeDarkGrey, ", untrusted shared ", ctxout.ForeWhite, policy.Untrusted,
+		ctxout.CleanTag,
+	)
+	c.Print("<table>")
+	for _, fn := range tasks.ListAnkoFunctions() {
+		riskColor := ctxout.ForeGreen
+		switch fn.Risk() {
+		case tasks.RISK_LEVEL_MEDIUM:
+			riskColor = ctxout.ForeYellow
+		case tasks.RISK_LEVEL_HIGH:
+			riskColor = ctxout.ForeRed
+		}
+		c.Print(
+			ctxout.Row(
+				ctxout.TD(
+					fn.Symbol(),
+					ctxout.Prop(ctxout.AttrSize, 20),
+					ctxout.Prop(ctxout.AttrPrefix, ctxout.ForeLightYellow),
+					ctxout.Prop(ctxout.AttrSuffix, ctxout.CleanTag),
+				),
+				ctxout.TD(
+					fn.Risk().String(),
+					ctxout.Prop(ctxout.AttrSize, 8),
+					ctxout.Prop(ctxout.AttrPrefix, riskColor),
+					ctxout.Prop(ctxout.AttrSuffix, ctxout.CleanTag),
+				),
+				ctxout.TD(
+					fn.Help(),
+					ctxout.Prop(ctxout.AttrSize, 71),
+					ctxout.Prop(ctxout.AttrOverflow, "wordwrap"),
+					ctxout.Prop(ctxout.AttrPrefix, ctxout.ForeWhite),
+					ctxout.Prop(ctxout.AttrSuffix, ctxout.CleanTag),
+				),
+			),
+		)
+	}
+	c.Println("</table>")
+}
+
 func (c *CmdExecutorImpl) Lint(showAll bool) error {
 	c.Println("linting...")
 	c.session.TemplateHndl.SetLinting(true)
@@ -961,6 +1389,11 @@ func (c *CmdExecutorImpl) SetPreValue(name string, value string) {
 func (c *CmdExecutorImpl) RunAnkoScript(args []string) error {
 	runner := tasks.NewAnkoRunner()
 	runner.SetLogger(c.session.Log.Logger)
+	if template, exists, err := c.session.TemplateHndl.Load(); err == nil && exists {
+		runner.SetModules(c.ankoModules(template))
+	} else {
+		runner.SetModules(c.ankoModules(configure.RunConfig{}))
+	}
 
 	script := strings.Join(args, "\n")
 	_, err := runner.RunAnko(script)
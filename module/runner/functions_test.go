@@ -1,6 +1,7 @@
 package runner_test
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -10,7 +11,9 @@ import (
 
 	"github.com/swaros/contxt/module/configure"
 	"github.com/swaros/contxt/module/ctxout"
+	"github.com/swaros/contxt/module/mimiclog"
 	"github.com/swaros/contxt/module/runner"
+	"github.com/swaros/contxt/module/tasks"
 )
 
 // quicktesting the app messagehandler
@@ -436,6 +439,36 @@ func TestRunBasic2(t *testing.T) {
 	}
 }
 
+func TestRunListAndAlias(t *testing.T) {
+	app, output, appErr := SetupTestApp("task2", "ctx_test_basic.yml")
+	if appErr != nil {
+		t.Errorf("Expected no error, got '%v'", appErr)
+	}
+	defer cleanAllFiles()
+	defer output.ClearAndLog()
+	output.Clear()
+
+	if err := os.Chdir(getAbsolutePath("task2")); err != nil {
+		t.Errorf("Expected no error, got '%v'", err)
+	}
+
+	if err := runCobraCmd(app, "run --list"); err != nil {
+		t.Errorf("Expected no error, got '%v'", err)
+	}
+	assertInMessage(t, output, "tests")
+	assertInMessage(t, output, "the first test target")
+	assertInMessage(t, output, "t1")
+	assertInMessage(t, output, "test2")
+	assertNotInMessage(t, output, "testing-1-working")
+	output.ClearAndLog()
+
+	if err := runCobraCmd(app, "run t1"); err != nil {
+		t.Errorf("Expected no error, got '%v'", err)
+	}
+	assertInMessage(t, output, "testing-1-working")
+	assertNotInMessage(t, output, "testing-2-working")
+}
+
 // testing the ctx_pwd default variable
 // and a defiened variable in the context file
 func TestRunAndVariables(t *testing.T) {
@@ -1275,6 +1308,31 @@ func TestAnkoFileExecuteWrongFile(t *testing.T) {
 	}
 }
 
+func TestAnkoTestWrongFormat(t *testing.T) {
+	ChangeToRuntimeDir(t)
+	app, output, appErr := SetupTestApp("workspace0", time.Now().Format(time.RFC3339)+"ctx_projects.yml")
+	if appErr != nil {
+		t.Errorf("Expected no error, got '%v'", appErr)
+	}
+
+	output.SetKeepNewLines(true)
+	defer cleanAllFiles()
+	defer output.ClearAndLog()
+	output.Clear()
+	logFileName := "testAnkoTestWrongFormat_" + time.Now().Format(time.RFC3339) + ".log"
+	output.SetLogFile(getAbsolutePath(logFileName))
+
+	// the path does not exists, so the format have to be checked before the tests are searched
+	if err := runCobraCmd(app, "test --format xml not-existing-path"); err == nil {
+		t.Error("Expected an error, got none")
+	} else {
+		expectedError := "unknown report format xml"
+		if !strings.Contains(err.Error(), expectedError) {
+			t.Errorf("Expected error '%v', got '%v'", expectedError, err)
+		}
+	}
+}
+
 func TestTemplateImportsWithTplIgnore(t *testing.T) {
 
 	ChangeToRuntimeDir(t)
@@ -1312,3 +1370,359 @@ func TestTemplateImportsWithTplIgnore(t *testing.T) {
 	}
 
 }
+
+func TestTemplateTargetInfos(t *testing.T) {
+	template := configure.RunConfig{
+		Task: []configure.Task{
+			{ID: "build-ci", Group: "build", Description: "build for the ci pipeline"},
+			{ID: "build-int", Group: "build", Description: "build for integration tests", Aliases: []string{"bi"}},
+			{ID: "build-int", Aliases: []string{"bi", "integration"}},
+			{ID: "clean", Description: "remove build artifacts"},
+			{ID: "hidden", Options: configure.Options{Invisible: true}},
+		},
+	}
+	infos := runner.TemplateTargetInfos(template, false)
+	if len(infos) != 3 {
+		t.Fatalf("Expected 3 targets, got %d", len(infos))
+	}
+	// no group first, then sorted by group and id
+	expectedIds := []string{"clean", "build-ci", "build-int"}
+	for i, id := range expectedIds {
+		if infos[i].ID != id {
+			t.Errorf("Expected target %d to be '%s', got '%s'", i, id, infos[i].ID)
+		}
+	}
+	if strings.Join(infos[2].Aliases, ",") != "bi,integration" {
+		t.Errorf("Expected merged aliases 'bi,integration', got '%v'", infos[2].Aliases)
+	}
+
+	completions := runner.TargetCompletions(infos)
+	expected := []string{
+		"clean\tremove build artifacts",
+		"build-ci\tbuild for the ci pipeline",
+		"build-int\tbuild for integration tests",
+		"bi\talias for build-int",
+		"integration\talias for build-int",
+	}
+	if strings.Join(completions, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected completions '%v', got '%v'", expected, completions)
+	}
+
+	if len(runner.TemplateTargetInfos(template, true)) != 4 {
+		t.Error("Expected 4 targets, if invisible targets are included")
+	}
+}
+
+func TestTargetSelectEntries(t *testing.T) {
+	missing := configure.Require{Exists: []string{"/this/file/does/not/exists"}}
+	template := configure.RunConfig{
+		Task: []configure.Task{
+			{ID: "build", Description: "build the project"},
+			{ID: "deploy", Description: "deploy the project", Requires: missing},
+			{ID: "release", Requires: missing},
+			{ID: "release"}, // the second task of the target can run
+			{ID: "test", Requires: missing},
+			{ID: "hidden", Options: configure.Options{Invisible: true}},
+		},
+	}
+	requires := tasks.NewDefaultRequires(tasks.NewCombinedDataHandler(), mimiclog.NewNullLogger())
+	entries := runner.TargetSelectEntries(template, requires, tasks.NewCombinedDataHandler())
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 entries, got %v", entries)
+	}
+	expected := []string{
+		"build:build the project",
+		"deploy:deploy the project (requirements not matching: required file (/this/file/does/not/exists) not found )",
+		"release:",
+		"test:requirements not matching: required file (/this/file/does/not/exists) not found ",
+	}
+	for i, entry := range entries {
+		if entry.Title+":"+entry.Description != expected[i] {
+			t.Errorf("Expected entry '%s', got '%s:%s'", expected[i], entry.Title, entry.Description)
+		}
+	}
+}
+
+// the requirements are checked in the working dir of the task
+func TestTargetSelectEntriesWorkingDir(t *testing.T) {
+	baseDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(baseDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(baseDir, "sub", "marker.txt"), []byte("marker"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	marker := configure.Require{Exists: []string{"marker.txt"}}
+	template := configure.RunConfig{
+		Task: []configure.Task{
+			{ID: "inside", Requires: marker, Options: configure.Options{WorkingDir: "sub"}},
+			{ID: "outside", Requires: marker},
+		},
+	}
+	vars := tasks.NewCombinedDataHandler()
+	vars.SetPH("BASEPATH", baseDir)
+	requires := tasks.NewDefaultRequires(vars, mimiclog.NewNullLogger())
+	entries := runner.TargetSelectEntries(template, requires, vars)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %v", entries)
+	}
+	if entries[0].Title != "inside" || entries[0].Description != "" {
+		t.Errorf("Expected the requirements of 'inside' are matching, got '%s:%s'", entries[0].Title, entries[0].Description)
+	}
+	if entries[1].Title != "outside" || !strings.Contains(entries[1].Description, "requirements not matching") {
+		t.Errorf("Expected the requirements of 'outside' are not matching, got '%s:%s'", entries[1].Title, entries[1].Description)
+	}
+}
+
+// testing the needs of other projects in the same workspace.
+// the frontend needs the target generate-client from the api project.
+func TestRunProjectNeeds(t *testing.T) {
+	ChangeToRuntimeDir(t)
+	app, output, appErr := SetupTestApp("projects01", time.Now().Format(time.RFC3339)+"ctx_projects.yml")
+	if appErr != nil {
+		t.Errorf("Expected no error, got '%v'", appErr)
+	}
+	defer cleanAllFiles()
+	defer output.ClearAndLog()
+	output.Clear()
+	logFileName := "lina_" + time.Now().Format(time.RFC3339) + ".log"
+	output.SetLogFile(getAbsolutePath(logFileName))
+
+	if err := runCobraCmd(app, "workspace new lina"); err != nil {
+		t.Errorf("Expected no error, got '%v'", err)
+	}
+	// add both projects to the workspace
+	for _, dir := range []string{"projects01/project_lina/api", "projects01/project_lina/frontend"} {
+		if err := os.Chdir(getAbsolutePath(dir)); err != nil {
+			t.Errorf("Expected no error, got '%v'", err)
+		}
+		if err := runCobraCmd(app, "dir add"); err != nil {
+			t.Errorf("Expected no error, got '%v'", err)
+		}
+	}
+	output.ClearAndLog()
+
+	// we are still in the frontend directory
+	if err := runCobraCmd(app, "run build"); err != nil {
+		t.Errorf("Expected no error, got '%v'", err)
+	}
+	// the api target is using his own variables
+	assertInMessage(t, output, "generate client in api")
+	assertInMessage(t, output, "build frontend in frontend")
+	output.ClearAndLog()
+
+	// a failing need of the other project stops the target
+	if err := runCobraCmd(app, "run build-broken"); err == nil {
+		t.Error("Expected an error, got none")
+	}
+	assertInMessage(t, output, "broken api")
+	assertInMessage(t, output, "need @api:broken failed")
+	assertNotInMessage(t, output, "should not be executed")
+
+	// we are still in the frontend directory
+	if dir, err := os.Getwd(); err != nil || dir != getAbsolutePath("projects01/project_lina/frontend") {
+		t.Errorf("Expected to be in the frontend directory, got '%v' '%v'", dir, err)
+	}
+	output.ClearAndLog()
+}
+
+func TestRunLocksCmd(t *testing.T) {
+	oldDir := tasks.TargetLockDir
+	tasks.TargetLockDir = t.TempDir()
+	defer func() { tasks.TargetLockDir = oldDir }()
+
+	app, output, appErr := SetupTestApp("config", "ctx_test_config.yml")
+	if appErr != nil {
+		t.Errorf("Expected no error, got '%v'", appErr)
+	}
+	defer cleanAllFiles()
+	output.ClearAndLog()
+
+	if err := runCobraCmd(app, "locks"); err != nil {
+		t.Errorf("Expected no error, got '%v'", err)
+	}
+	assertInMessage(t, output, "no targets locked")
+	output.ClearAndLog()
+
+	// the holder info of this process
+	info := tasks.TargetLockInfo{Pid: os.Getpid(), Project: "/project/lina", Target: "migrate", Mode: tasks.LockExclusive, Since: time.Now()}
+	data, _ := json.Marshal(info)
+	if err := os.WriteFile(filepath.Join(tasks.TargetLockDir, "lina_migrate.holder.json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCobraCmd(app, "locks"); err != nil {
+		t.Errorf("Expected no error, got '%v'", err)
+	}
+	assertInMessage(t, output, "migrate")
+	assertInMessage(t, output, "exclusive")
+	assertInMessage(t, output, "/project/lina")
+}
+
+func TestRunHermetic(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	t.Setenv("CTX_RUNNER_SECRET", "visible")
+	app, output, appErr := SetupTestApp("hermetic01", "ctx_test_basic.yml")
+	if appErr != nil {
+		t.Errorf("Expected no error, got '%v'", appErr)
+	}
+	defer cleanAllFiles()
+	defer output.ClearAndLog()
+	output.Clear()
+	logFileName := "hermetic_" + time.Now().Format(time.RFC3339) + ".log"
+	output.SetLogFile(getAbsolutePath(logFileName))
+
+	if err := os.Chdir(getAbsolutePath("hermetic01")); err != nil {
+		t.Errorf("Expected no error, got '%v'", err)
+	}
+	if err := runCobraCmd(app, "run show-env"); err != nil {
+		t.Errorf("Expected no error, got '%v'", err)
+	}
+	assertInMessage(t, output, "secret:[visible] declared:[declared-value]")
+	output.ClearAndLog()
+
+	if err := runCobraCmd(app, "run --hermetic show-env"); err != nil {
+		t.Errorf("Expected no error, got '%v'", err)
+	}
+	assertInMessage(t, output, "secret:[] declared:[declared-value]")
+}
+
+func TestAnkoReplEval(t *testing.T) {
+	dmc := tasks.NewCombinedDataHandler()
+	dmc.SetPH("PROJECT", "contxt")
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	executer := tasks.NewTaskListExec(configure.RunConfig{}, dmc, req, tasks.ShellCmd)
+	repl := runner.NewAnkoRepl(executer.NewAnkoRunner())
+	output := []string{}
+	repl.SetOutput(func(msg string) {
+		output = append(output, msg)
+	})
+
+	// the placeholders are the same as for the tasks
+	result, complete, err := repl.Eval(`varGet("PROJECT")`)
+	if err != nil || !complete {
+		t.Fatal("unexpected result", complete, err)
+	}
+	if result != "contxt" {
+		t.Error("expected contxt, got", result)
+	}
+	if _, _, err := repl.Eval(`varSet("FROM_REPL", "yes")`); err != nil {
+		t.Error(err)
+	}
+	if dmc.GetPH("FROM_REPL") != "yes" {
+		t.Error("the variable should be set in the placeholder store")
+	}
+
+	// multi line input
+	if _, complete, _ := repl.Eval(`func double(a) {`); complete || !repl.Pending() {
+		t.Error("the function is not complete")
+	}
+	if _, complete, _ := repl.Eval(`return a * 2`); complete {
+		t.Error("the function is still not complete")
+	}
+	result, complete, err = repl.Eval(`}`)
+	if err != nil || !complete {
+		t.Fatal("the function should be complete", err)
+	}
+	result, _, err = repl.Eval(`double(21)`)
+	if err != nil || result != int64(42) {
+		t.Error("expected 42, got", result, err)
+	}
+
+	// variables and help
+	if _, _, err := repl.Eval(`name = "world"`); err != nil {
+		t.Error(err)
+	}
+	repl.Eval(`vars()`)
+	repl.Eval(`help("varGet")`)
+	repl.Eval(`help(varSet)`)
+	all := strings.Join(output, "\n")
+	for _, expected := range []string{"name", `"world"`, "double", "get a variable. e.g. value = varGet('key')", "set a variable. e.g. varSet('key','value')"} {
+		if !strings.Contains(all, expected) {
+			t.Errorf("expected output to contain %q\n%s", expected, all)
+		}
+	}
+	if strings.Contains(all, "varGet = ") {
+		t.Error("vars() should not list the functions of the runner")
+	}
+}
+
+func TestAnkoReplComplete(t *testing.T) {
+	executer := tasks.NewStdTaskListExec(configure.RunConfig{})
+	repl := runner.NewAnkoRepl(executer.NewAnkoRunner())
+	repl.Eval(`varCounter = 1`)
+
+	line := []rune(`x = varG`)
+	found, length := repl.Do(line, len(line))
+	if length != 4 {
+		t.Error("expected the length of the prefix 4, got", length)
+	}
+	suffixes := []string{}
+	for _, f := range found {
+		suffixes = append(suffixes, string(f))
+	}
+	if len(suffixes) != 1 || suffixes[0] != "et" {
+		t.Error("expected the completion of varGet, got", suffixes)
+	}
+
+	line = []rune(`println(varC`)
+	found, _ = repl.Do(line, len(line))
+	if len(found) != 1 || string(found[0]) != "ounter" {
+		t.Error("expected the completion of varCounter, got", found)
+	}
+}
+
+func TestAnkoTestsWithCmdFunctions(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "vars_test.ank")
+	source := "func test_project() {\n  assertEqual(\"contxt\", varGet(\"PROJECT\"))\n}\n\nfunc test_wrong() {\n  assertEqual(\"other\", varGet(\"PROJECT\"))\n}\n"
+	if err := os.WriteFile(file, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	dmc.SetPH("PROJECT", "contxt")
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	executer := tasks.NewTaskListExec(configure.RunConfig{}, dmc, req, tasks.ShellCmd)
+	tester := tasks.NewAnkoTester(func(file string) *tasks.AnkoRunner {
+		return executer.NewAnkoRunner()
+	})
+	results := tester.RunFile(file)
+	if len(results) != 2 {
+		t.Fatal("expected 2 results, got", len(results))
+	}
+	if !results[0].Passed {
+		t.Error("test_project should pass:", results[0].Message)
+	}
+	if results[1].Passed || results[1].Line != 6 {
+		t.Errorf("test_wrong should fail in line 6, got %+v", results[1])
+	}
+}
+
+func TestAnkoTestsUseOwnData(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "data_test.ank")
+	source := "func test_change() {\n  varSet(\"PROJECT\", \"changed\")\n  assertEqual(\"changed\", varGet(\"PROJECT\"))\n}\n\nfunc test_unchanged() {\n  assertEqual(\"contxt\", varGet(\"PROJECT\"))\n}\n"
+	if err := os.WriteFile(file, []byte(source), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	dmc.SetPH("PROJECT", "contxt")
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	executer := tasks.NewTaskListExec(configure.RunConfig{}, dmc, req, tasks.ShellCmd)
+	tester := tasks.NewAnkoTester(func(file string) *tasks.AnkoRunner {
+		return executer.NewAnkoRunnerWithData(dmc.Copy())
+	})
+	results := tester.RunFile(file)
+	if len(results) != 2 {
+		t.Fatal("expected 2 results, got", len(results))
+	}
+	for _, res := range results {
+		if !res.Passed {
+			t.Errorf("%s should pass: %s", res.Name, res.Message)
+		}
+	}
+	if value := dmc.GetPH("PROJECT"); value != "contxt" {
+		t.Error("the data of the executer should not be changed by the tests. got", value)
+	}
+}
@@ -25,6 +25,7 @@
 package runner
 
 import (
+	"path/filepath"
 	"strings"
 
 	"github.com/swaros/contxt/module/ctemplate"
@@ -39,6 +40,7 @@ type ImportHandler struct {
 	logger   mimiclog.Logger
 	dataHndl *tasks.CombinedDh
 	template *ctemplate.Template
+	basePath string // relative imports are resolved against this path. if empty, the current directory is used
 }
 
 func NewImportHandler(logger mimiclog.Logger, dataHndl *tasks.CombinedDh, template *ctemplate.Template) *ImportHandler {
@@ -53,6 +55,11 @@ func (ih *ImportHandler) SetImports(imports []string) {
 	ih.imports = imports
 }
 
+// SetBasePath sets the path, relative imports are resolved against
+func (ih *ImportHandler) SetBasePath(path string) {
+	ih.basePath = path
+}
+
 func (ih *ImportHandler) HandleImports() error {
 	if ih.imports == nil {
 		ih.logger.Debug("no imports to handle")
@@ -66,8 +73,12 @@ func (ih *ImportHandler) handleFileImportsToVars(imports []string) error {
 		var keyname string
 		parts := strings.Split(filenameFull, " ")
 		filename := parts[0]
+		importPath := filename
+		if ih.basePath != "" && !filepath.IsAbs(importPath) {
+			importPath = filepath.Join(ih.basePath, importPath)
+		}
 		// loading the file ad parses the template markup inside
-		if content, err := ih.template.TryHandleTemplate(filename); err != nil {
+		if content, err := ih.template.TryHandleTemplate(importPath); err != nil {
 			ih.logger.Error("error while loading import", filename)
 			return err
 		} else {
@@ -76,7 +87,7 @@ func (ih *ImportHandler) handleFileImportsToVars(imports []string) error {
 				keyname = parts[1]
 			}
 			var lastErr error
-			dirhandle.FileTypeHandler(filename, func(jsonBaseName string) {
+			dirhandle.FileTypeHandler(importPath, func(jsonBaseName string) {
 				ih.logger.Info("loading json File as second level variables:", mimiclog.Fields{"filename": filename, "keyname": keyname, "content-len": len(content)})
 				if keyname == "" {
 					keyname = jsonBaseName
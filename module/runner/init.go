@@ -22,8 +22,11 @@
 package runner
 
 import (
+	"errors"
 	"os"
 	"runtime"
+	"sync/atomic"
+	"syscall"
 
 	"github.com/sirupsen/logrus"
 	"github.com/swaros/contxt/module/configure"
@@ -32,7 +35,16 @@ import (
 	"github.com/swaros/contxt/module/tasks"
 )
 
-func setShutDownBehavior() {
+var (
+	// ErrInterrupted is returned if a target is stopped by an interrupt signal
+	ErrInterrupted = errors.New("interrupted")
+	// the number of targets they are currently running
+	activeRuns atomic.Int32
+)
+
+// setShutDownBehavior adds the exit listeners and starts forwarding the interrupt signals to the tasks.
+// the returned function stops forwarding the signals.
+func setShutDownBehavior() (stopSignals func()) {
 	// add exit listener for shutting down all processes
 	systools.AddExitListener("main", func(code int) systools.ExitBehavior {
 		ctxout.PrintLn(ctxout.NewMOWrap(), ctxout.ForeDarkGrey, " stop all tasks: ", ctxout.CleanTag)
@@ -91,15 +103,22 @@ func setShutDownBehavior() {
 		})
 		return systools.Continue
 	})
-	// capture the sigterm signal so we are able to cleanup all processes
-	// nil means that we use the default behavior for the exit control flow
-	// so any systool.Exit() call will trigger the exit listeners
-	// this is experimental and is only enabled if the env CTX_SUTDOWN_BEHAVIOR is set to "true"
-	// this is a workaround for the problem that the exit listeners are not called if the application
-	// is killed by the system.
-	if os.Getenv("CTX_SUTDOWN_BEHAVIOR") == "true" {
-		systools.WatchSigTerm(nil)
+	// capture the interrupt and terminate signals.
+	// the commands of the tasks are running in their own process group, so they
+	// will not get the signal from the terminal. we forward it to them instead.
+	// the tasks can then run their onInterrupt targets.
+	// if nothing is running, or we get the signal twice, we exit at once.
+	// this is always enabled. the former opt-in by CTX_SUTDOWN_BEHAVIOR is not needed anymore.
+	return systools.WatchSignals(handleInterrupt, os.Interrupt, syscall.SIGTERM)
+}
+
+// handleInterrupt forwards the signal to all running tasks.
+func handleInterrupt(sig os.Signal) {
+	if activeRuns.Load() == 0 || !tasks.Interrupt(sig) {
+		systools.Exit(systools.ExitByInterrupt)
+		return
 	}
+	ctxout.PrintLn(ctxout.NewMOWrap(), ctxout.ForeDarkGrey, " interrupted. stopping tasks. (repeat to exit now)", ctxout.CleanTag)
 }
 
 // Init initializes the application
@@ -163,13 +182,18 @@ func Init() error {
 	}
 
 	// set the shutdown behavior
-	setShutDownBehavior()
+	stopSignals := setShutDownBehavior()
+	defer stopSignals()
 	// initialize the cobra commands
 	if err := app.Cobra.Init(functions); err != nil {
 		return err
 	}
 	// and execute the root command
 	if err := app.Cobra.RootCmd.Execute(); err != nil {
+		// interrupted targets are reported by the exit code
+		if errors.Is(err, ErrInterrupted) {
+			systools.Exit(systools.ExitByInterrupt)
+		}
 		return err
 	}
 	// show variables if the verbose flag is set
@@ -41,6 +41,9 @@ type CmdExecutor interface {
 	InitExecuter() error                                              // initialize the executer
 	RunTargets(string, bool) error                                    // run targets
 	GetTargets(incInvisible bool) []string                            // return all targets. optional include invisible targets
+	GetTargetInfos(incInvisible bool) []TargetInfo                    // return all targets with description, group and aliases
+	PrintTargetList(incInvisible bool)                                // print all targets grouped with the description
+	PickTargets() ([]string, bool, error)                             // select targets interactive. false if we are not in a terminal
 	CallBackNewWs(string)                                             // callback for new workspace
 	CallBackOldWs(string) bool                                        // callback for old workspace
 	FindWorkspaceInfoByTemplate(updateFn func(workspace string, cnt int, update bool, info configure.WorkspaceInfoV2)) (allCount int, updatedCount int)
@@ -58,4 +61,8 @@ type CmdExecutor interface {
 	AddIncludePath(path string) error                  // add a path to the include section
 	CreateContxtFile() error                           // create a new contxt file
 	RunAnkoScript(args []string) error                 // run an anko script
+	PrintAnkoFunctions()                               // print out the anko functions and their risk level
+	RunAnkoRepl() error                                // run an interactive anko session
+	RunAnkoTests(paths []string, format string) error  // run the tests of the anko test files
+	PrintLocks()                                       // print out all target locks and the holders
 }
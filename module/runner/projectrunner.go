@@ -0,0 +1,144 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package runner
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/ctemplate"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+// ProjectRunner runs targets of other projects in the current workspace.
+// these are needs like @api:generate-client.
+// the path of the project is resolved by the project and role, they are
+// stored in the workspace. the template of this path is loaded and the
+// target is executed there, with his own variables.
+type ProjectRunner struct {
+	cmd   *CmdExecutorImpl
+	chain []string // the project targets they are already running. used to detect cycles
+}
+
+func NewProjectRunner(cmd *CmdExecutorImpl) *ProjectRunner {
+	return &ProjectRunner{
+		cmd: cmd,
+	}
+}
+
+// RunProjectTarget runs the target in the path of the project and returns the exit code
+func (p *ProjectRunner) RunProjectTarget(fromProject, project, target string) int {
+	logger := p.cmd.session.Log.Logger
+	key := project + ":" + target
+	for _, running := range p.chain {
+		if running == key {
+			logger.Error("cycle in project needs detected", p.chain, key)
+			p.cmd.Println("cycle in project needs detected: ", strings.Join(append(p.chain, key), " -> "))
+			return systools.ExitAlreadyRunning
+		}
+	}
+	wsInfo, err := configure.GetGlobalConfig().FindProjectPath(project, fromProject)
+	if err != nil {
+		logger.Error("can not resolve project", err)
+		p.cmd.Println("can not resolve project ", project, ": ", err)
+		return systools.ExitByNoTargetExists
+	}
+	logger.Info("run target of project", mimiclog.Fields{"project": project, "target": target, "path": wsInfo.Path})
+
+	executer, err := p.newExecuter(key, wsInfo.Path)
+	if err != nil {
+		logger.Error("can not load the template of project", project, err)
+		p.cmd.Println("can not load the template of project ", project, ": ", err)
+		return systools.ExitByNoTargetExists
+	}
+	// the BASEPATH of the executer is the path of the project,
+	// so the tasks are executed there without changing the directory
+	return executer.RunTarget(target, false)
+}
+
+// newExecuter creates a task executer for the template in the given path.
+// it is using a new data handler, so the variables are not shared with the
+// project they requires the target.
+// the project runner of the new executer knows the key of the running target,
+// so it can detect cycles.
+func (p *ProjectRunner) newExecuter(key, path string) (*tasks.TaskListExec, error) {
+	dataHandl := tasks.NewCombinedDataHandler()
+	template, err := p.loadInPath(path, dataHandl)
+	if err != nil {
+		return nil, err
+	}
+
+	outputHndl, err := p.cmd.setOutHandler(p.cmd.usedHandler)
+	if err != nil {
+		return nil, err
+	}
+	riskPolicy, err := p.cmd.riskPolicy()
+	if err != nil {
+		return nil, err
+	}
+	executer := tasks.NewTaskListExec(
+		template,
+		dataHandl,
+		tasks.NewDefaultRequires(dataHandl, p.cmd.session.Log.Logger),
+		outputHndl,
+		tasks.ShellCmd,
+		NewShellPrompter(),
+		tasks.NewWatchman(), // the targets of the project are tracked separately
+		&ProjectRunner{cmd: p.cmd, chain: append(append([]string{}, p.chain...), key)},
+		p.cmd.session.SharedHelper.AnkoModules(path, template),
+		riskPolicy,
+	)
+	executer.SetLogger(p.cmd.session.Log.Logger)
+	executer.SetHermeticToAllTasks(p.cmd.session.Cobra.Options.Hermetic)
+	executer.SetOutputModeToAllTasks(p.cmd.session.Cobra.Options.OutputMode)
+	executer.SetOutputLiveErrorsToAllTasks(p.cmd.session.Cobra.Options.OutputLiveErrors)
+	return executer, nil
+}
+
+// loadInPath loads the template and sets the startup variables for the path.
+// any call is using his own template handler, and the template is loaded by the path.
+// so the current directory is never changed, and parallel targets are not affected.
+func (p *ProjectRunner) loadInPath(path string, dataHandl *tasks.CombinedDh) (configure.RunConfig, error) {
+	logger := p.cmd.session.Log.Logger
+	templateHndl := ctemplate.New()
+	templateHndl.SetIgnoreHndl(true)
+	mimiclog.ApplyLogger(logger, templateHndl)
+	templateHndl.SetOnLoad(func(template *configure.RunConfig) error {
+		return p.cmd.session.SharedHelper.MergeRequiredPaths(template, templateHndl)
+	})
+
+	template, exists, err := templateHndl.LoadFromPath(path)
+	if err != nil {
+		return template, err
+	}
+	if !exists {
+		return template, errors.New("no contxt template found in " + path)
+	}
+	p.cmd.setStartupVariablesInPath(dataHandl, &template, path, templateHndl)
+	return template, nil
+}
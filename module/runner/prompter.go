@@ -0,0 +1,72 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package runner
+
+import (
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/swaros/contxt/module/shellcmd"
+	"github.com/swaros/contxt/module/systools"
+)
+
+// ShellPrompter is asking the user for values by using the shellcmd
+// input and selection ui.
+type ShellPrompter struct {
+	askLock sync.Mutex // tasks can run in parallel, but we can only ask one question at the time
+}
+
+func NewShellPrompter() *ShellPrompter {
+	return &ShellPrompter{}
+}
+
+// IsInteractive returns true if we are running in a terminal,
+// and not in a CI environment
+func (p *ShellPrompter) IsInteractive() bool {
+	if os.Getenv("CI") != "" {
+		return false
+	}
+	return systools.IsStdInTerminal() && systools.IsStdOutTerminal()
+}
+
+func (p *ShellPrompter) Input(question, defaultValue string) (string, error) {
+	p.askLock.Lock()
+	defer p.askLock.Unlock()
+	value, err := shellcmd.TextInput(question, defaultValue, 128, 25)
+	if err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+func (p *ShellPrompter) Select(question string, choices []string) (string, error) {
+	p.askLock.Lock()
+	defer p.askLock.Unlock()
+	if value, ok := shellcmd.SelectOne(question, choices); ok {
+		return value, nil
+	}
+	return "", errors.New("selection aborted: " + question)
+}
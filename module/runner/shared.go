@@ -432,6 +432,22 @@ func (sh *SharedHelper) takeCareAboutRepo(pathTouse string, config configure.Git
 	return config
 }
 
+// AnkoModules returns the anko modules, they can be imported by the anko commands of the template.
+// the modules of the project are searched first. then the modules of the shared contents
+// they are used or required by the template, in the order they are defined.
+// shared contents are not downloaded here. this is already done while loading the template.
+func (sh *SharedHelper) AnkoModules(projectPath string, template configure.RunConfig) *tasks.AnkoModules {
+	modules := tasks.NewDefaultAnkoModules(projectPath)
+	for _, shared := range append(append([]string{}, template.Config.Use...), template.Config.Require...) {
+		path := filepath.Join(sh.getSourcePath(sh.GetSharedPath(shared)), tasks.AnkoModuleDir)
+		if exists, _ := dirhandle.Exists(path); exists {
+			sh.logger.Debug("shared: add anko modules", path)
+			modules.AddPath(path)
+		}
+	}
+	return modules
+}
+
 // Merged the required paths into the given template.
 // this is loading the .contxt.yml from the required path, located in the shared folder
 // and merges them into the given template.
@@ -446,7 +462,13 @@ func (sh *SharedHelper) MergeRequiredPaths(ctemplate *configure.RunConfig, templ
 				sh.logger.Debug("shared: merge required", fullPath)
 				subTemplate, tError := templateHandler.LoadV2ByAbsolutePath(fullPath + string(os.PathSeparator) + DefaultExecYaml)
 				if tError == nil {
-					return mergo.Merge(ctemplate, subTemplate, mergo.WithOverride, mergo.WithAppendSlice)
+					// remember the source of the tasks. the risk policy of the anko commands depends on it
+					for i := range subTemplate.Task {
+						subTemplate.Task[i].Source = reqSource
+					}
+					if err := mergo.Merge(ctemplate, subTemplate, mergo.WithOverride, mergo.WithAppendSlice); err != nil {
+						return err
+					}
 				} else {
 					return tError
 				}
@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/ctemplate"
 	"github.com/swaros/contxt/module/runner"
 )
 
@@ -55,3 +57,35 @@ func TestCheckOrCreateUseConfigNotExists(t *testing.T) {
 		}
 	}
 }
+
+func TestMergeRequiredPathsSource(t *testing.T) {
+	path := t.TempDir()
+	shared := runner.NewSharedHelperWithPath(path)
+	for _, lib := range []string{"someone/first", "someone/second"} {
+		source := filepath.Join(shared.GetSharedPath(lib), "source")
+		if err := os.MkdirAll(source, 0755); err != nil {
+			t.Fatal(err)
+		}
+		content := "task:\n  - id: " + filepath.Base(lib) + "\n    script:\n      - echo " + lib + "\n"
+		if err := os.WriteFile(filepath.Join(source, ".contxt.yml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	template := configure.RunConfig{
+		Config: configure.Config{Require: []string{"someone/first", "someone/second"}},
+		Task:   []configure.Task{{ID: "local"}},
+	}
+	if err := shared.MergeRequiredPaths(&template, ctemplate.New()); err != nil {
+		t.Fatal(err)
+	}
+	sources := map[string]string{}
+	for _, task := range template.Task {
+		sources[task.ID] = task.Source
+	}
+	expected := map[string]string{"local": "", "first": "someone/first", "second": "someone/second"}
+	for id, source := range expected {
+		if got, ok := sources[id]; !ok || got != source {
+			t.Errorf("task %s: expected source '%s', got '%s' (merged: %v)", id, source, got, ok)
+		}
+	}
+}
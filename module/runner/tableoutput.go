@@ -240,6 +240,33 @@ func (t *TableOutput) GetOutHandler(c *CmdExecutorImpl) func(msg ...interface{})
 						ctxout.BaseSignInfo+" ",
 						ctxout.ForeBlue,
 					)
+				case "trigger_message":
+					t.drawRow(
+						tm.Target,
+						targetColor.ColorMarkup(),
+						tm.Info,
+						ctxout.ForeLightYellow,
+						ctxout.BaseSignInfo+" ",
+						ctxout.ForeYellow,
+					)
+				case "output_group_start":
+					t.drawRow(
+						tm.Target,
+						targetColor.ColorMarkup(),
+						"grouped output "+tm.Info,
+						ctxout.ForeDarkGrey,
+						ctxout.BaseSignInfo+" ",
+						ctxout.ForeBlue,
+					)
+				case "output_group_end":
+					t.drawRow(
+						tm.Target,
+						targetColor.ColorMarkup(),
+						tm.Info,
+						ctxout.ForeDarkGrey,
+						ctxout.BaseSignSuccess+" ",
+						ctxout.ForeGreen,
+					)
 				case "needs_ignored_runs_already":
 					t.drawRow(
 						ctxout.BaseSignInfo+" "+tm.Target,
@@ -0,0 +1,162 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package shellcmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/swaros/contxt/module/systools"
+)
+
+// SelectEntry is one entry of the multi selection
+type SelectEntry struct {
+	Title       string
+	Description string
+}
+
+type multiSelectItem struct {
+	entry   SelectEntry
+	checked bool
+}
+
+func (i multiSelectItem) Title() string {
+	if i.checked {
+		return "[x] " + i.entry.Title
+	}
+	return "[ ] " + i.entry.Title
+}
+func (i multiSelectItem) Description() string { return i.entry.Description }
+func (i multiSelectItem) FilterValue() string { return i.entry.Title + " " + i.entry.Description }
+
+var toggleKey = key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "select"))
+
+type multiSelectModel struct {
+	list     list.Model
+	aborted  bool
+	selected []string
+}
+
+func (m multiSelectModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m multiSelectModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		h, v := docStyle.GetFrameSize()
+		m.list.SetSize(msg.Width-h, msg.Height-v)
+
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			m.aborted = true
+			return m, tea.Quit
+		}
+		// while the filter is typed, any key belongs to the filter
+		if m.list.SettingFilter() {
+			break
+		}
+		switch msg.String() {
+		case "esc":
+			if !m.list.IsFiltered() {
+				m.aborted = true
+				return m, tea.Quit
+			}
+		case " ":
+			if itm, ok := m.list.SelectedItem().(multiSelectItem); ok {
+				itm.checked = !itm.checked
+				return m, m.list.SetItem(m.list.GlobalIndex(), itm)
+			}
+		case "enter":
+			m.selected = m.checkedTitles()
+			// without any checked entry, the current one is used
+			if len(m.selected) == 0 {
+				if itm, ok := m.list.SelectedItem().(multiSelectItem); ok {
+					m.selected = []string{itm.entry.Title}
+				}
+			}
+			return m, tea.Quit
+		}
+	}
+
+	var cmd tea.Cmd
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+// checkedTitles returns the titles of all checked entries, in the order of the list
+func (m multiSelectModel) checkedTitles() []string {
+	var titles []string
+	for _, listItem := range m.list.Items() {
+		if itm, ok := listItem.(multiSelectItem); ok && itm.checked {
+			titles = append(titles, itm.entry.Title)
+		}
+	}
+	return titles
+}
+
+func (m multiSelectModel) View() string {
+	if m.aborted || m.selected != nil {
+		return ""
+	}
+	return docStyle.Render(m.list.View())
+}
+
+// newMultiSelectModel creates the list model for the entries
+func newMultiSelectModel(title string, entries []SelectEntry, width, height int) multiSelectModel {
+	listItems := []list.Item{}
+	for _, entry := range entries {
+		listItems = append(listItems, multiSelectItem{entry: entry})
+	}
+	l := list.New(listItems, list.NewDefaultDelegate(), width, height)
+	l.Title = title
+	l.Styles.Title = selectionTitleStyle
+	l.DisableQuitKeybindings()
+	l.AdditionalShortHelpKeys = func() []key.Binding { return []key.Binding{toggleKey} }
+	l.AdditionalFullHelpKeys = func() []key.Binding { return []key.Binding{toggleKey} }
+	return multiSelectModel{list: l}
+}
+
+// SelectMany shows the entries as a list they can be filtered by typing "/".
+// any number of entries can be selected by space. enter confirms the selection.
+// if nothing is selected by space, the current entry is used.
+// the second return value is false, if the selection was aborted.
+// an error is returned, if the list can not be shown.
+func SelectMany(title string, entries []SelectEntry) ([]string, bool, error) {
+	w, h, _ := systools.GetStdOutTermSize()
+	model := newMultiSelectModel(title, entries, w, h-2)
+
+	result, err := tea.NewProgram(model, tea.WithAltScreen()).Run()
+	if err != nil {
+		return nil, false, fmt.Errorf("can not show the selection: %w", err)
+	}
+	final, ok := result.(multiSelectModel)
+	if !ok || final.aborted || len(final.selected) == 0 {
+		return nil, false, nil
+	}
+	return final.selected, true, nil
+}
@@ -158,3 +158,13 @@ func simpleSelect(title string, selectable []string) selectResult {
 
 	return selected
 }
+
+// SelectOne shows the selectable entries as a list and returns the selected one.
+// the second return value is false, if the selection was aborted.
+func SelectOne(title string, selectable []string) (string, bool) {
+	result := simpleSelect(title, selectable)
+	if !result.isSelected || result.aborted {
+		return "", false
+	}
+	return result.item.title, true
+}
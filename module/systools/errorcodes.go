@@ -45,4 +45,7 @@ const (
 	ExitByUnsupportedVersion = 109 // ExitByWongVersion means the version is not matching. it needs to be equal or higher
 	ExitNoTasks              = 110 // ExitNoTasks means there are no tasks to run
 	ErrorInvalidTargetName   = 111 // ErrorInvalidTargetName means the target name is not valid
+	ExitByMissingInput       = 112 // ExitByMissingInput means a required value is not set and could not be asked
+	ExitByLocked             = 113 // ExitByLocked means the target is locked by another process
+	ExitByInterrupt          = 114 // ExitByInterrupt means the execution was stopped by an interrupt signal like Ctrl-C
 )
@@ -80,3 +80,27 @@ func WatchSigTerm(callback func(os.Signal)) {
 		}
 	}() // exit
 }
+
+// WatchSignals calls the callback for any of the given signals
+// until the returned stop function is called.
+// while watching, the signals will not stop the app.
+// this have to be done by the callback, if needed.
+func WatchSignals(callback func(os.Signal), signals ...os.Signal) (stop func()) {
+	c := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(c, signals...)
+	go func() {
+		for {
+			select {
+			case sig := <-c:
+				callback(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(c)
+		close(done)
+	}
+}
@@ -0,0 +1,125 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package systools
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrLockTimeout is returned if the lock could not be acquired in time
+	ErrLockTimeout = errors.New("timeout while waiting for the lock")
+	// LockPollInterval is the time between two attempts to get a lock while waiting
+	LockPollInterval = 50 * time.Millisecond
+)
+
+// FileLock is an advisory lock, based on a file, that works across processes.
+// the lock is bound to the open file, so it is released by the system
+// if the process ends without calling Unlock.
+type FileLock struct {
+	path string
+	file *os.File
+	mu   sync.Mutex
+}
+
+// NewFileLock creates a new lock for the given path.
+// the file and the parent directory are created if needed on locking.
+func NewFileLock(path string) *FileLock {
+	return &FileLock{path: path}
+}
+
+// Path returns the path of the lock file
+func (l *FileLock) Path() string {
+	return l.path
+}
+
+// TryLock tries to get the lock without waiting.
+// a shared lock can be held by more than one process at the same time,
+// an exclusive lock only by one.
+// returns false if the lock is held by someone else.
+func (l *FileLock) TryLock(shared bool) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		return false, errors.New("lock is already held by this instance: " + l.path)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return false, err
+	}
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_RDWR, 0o666)
+	if err != nil {
+		return false, err
+	}
+	locked, err := lockFile(file, shared)
+	if err != nil || !locked {
+		file.Close()
+		return false, err
+	}
+	l.file = file
+	return true, nil
+}
+
+// Lock waits until the lock is acquired.
+// a timeout <= 0 means waiting without limit.
+// ErrLockTimeout is returned if the timeout is reached.
+func (l *FileLock) Lock(shared bool, timeout time.Duration) error {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	for {
+		locked, err := l.TryLock(shared)
+		if err != nil {
+			return err
+		}
+		if locked {
+			return nil
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return ErrLockTimeout
+		}
+		time.Sleep(LockPollInterval)
+	}
+}
+
+// Unlock releases the lock. the lock file itself is kept,
+// because removing it would break the lock for any process waiting on it.
+func (l *FileLock) Unlock() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := unlockFile(l.file)
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	l.file = nil
+	return err
+}
@@ -0,0 +1,69 @@
+package systools_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/swaros/contxt/module/systools"
+)
+
+func TestFileLockExclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locks", "test.lock")
+	first := systools.NewFileLock(path)
+	second := systools.NewFileLock(path)
+
+	if ok, err := first.TryLock(false); !ok || err != nil {
+		t.Fatalf("expected to get the lock. got %v, %v", ok, err)
+	}
+	if ok, err := second.TryLock(false); ok || err != nil {
+		t.Fatalf("expected the lock to be held already. got %v, %v", ok, err)
+	}
+	if ok, _ := second.TryLock(true); ok {
+		t.Fatal("expected shared lock to fail while an exclusive lock is held")
+	}
+	if err := second.Lock(false, 100*time.Millisecond); !errors.Is(err, systools.ErrLockTimeout) {
+		t.Fatalf("expected timeout error. got %v", err)
+	}
+	if err := first.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Lock(false, 100*time.Millisecond); err != nil {
+		t.Fatalf("expected to get the lock after unlock. got %v", err)
+	}
+	second.Unlock()
+}
+
+func TestFileLockShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shared.lock")
+	first := systools.NewFileLock(path)
+	second := systools.NewFileLock(path)
+	exclusive := systools.NewFileLock(path)
+
+	if ok, _ := first.TryLock(true); !ok {
+		t.Fatal("expected to get the first shared lock")
+	}
+	if ok, _ := second.TryLock(true); !ok {
+		t.Fatal("expected to get the second shared lock")
+	}
+	if ok, _ := exclusive.TryLock(false); ok {
+		t.Fatal("expected exclusive lock to fail while shared locks are held")
+	}
+	first.Unlock()
+	second.Unlock()
+	if ok, _ := exclusive.TryLock(false); !ok {
+		t.Fatal("expected exclusive lock after all shared locks are released")
+	}
+	exclusive.Unlock()
+}
+
+func TestIsProcessAlive(t *testing.T) {
+	if !systools.IsProcessAlive(os.Getpid()) {
+		t.Error("expected the current process to be alive")
+	}
+	if systools.IsProcessAlive(0) {
+		t.Error("pid 0 should not be reported as alive")
+	}
+}
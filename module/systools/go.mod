@@ -6,11 +6,11 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/swaros/contxt/module/dirhandle v0.0.0-20230531064521-09943a54576e
 	github.com/swaros/manout v0.2.1
+	golang.org/x/sys v0.34.0
 	golang.org/x/term v0.33.0
 )
 
 require (
 	github.com/swaros/contxt/module/configure v0.0.0-20230531064521-09943a54576e // indirect
 	github.com/swaros/outinject v0.0.4 // indirect
-	golang.org/x/sys v0.34.0 // indirect
 )
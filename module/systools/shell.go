@@ -35,6 +35,10 @@ func IsStdOutTerminal() bool {
 	return term.IsTerminal(int(os.Stdout.Fd()))
 }
 
+func IsStdInTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
 func GetStdOutTermSize() (width, height int, err error) {
 	return term.GetSize(int(os.Stdout.Fd()))
 }
@@ -26,7 +26,11 @@ package tasks
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/mattn/anko/env"
 	"github.com/mattn/anko/parser"
 )
 
@@ -58,3 +62,54 @@ func (av *AnkVerifier) VerifyLines(lines []string) ([]VerifiedLines, error) {
 	}
 	return result, nil
 }
+
+// VerifyFile verifies the script in the given file.
+// errors are reported as AnkoModuleError, so they contains the file name
+func (av *AnkVerifier) VerifyFile(file string) error {
+	_, err := av.verifyFile(strings.TrimSuffix(filepath.Base(file), AnkoModuleExt), file)
+	return err
+}
+
+// VerifyModule verifies the module with the given name, and any module they is imported by them.
+// errors are reported as AnkoModuleError, so they contains the file name of the module
+func (av *AnkVerifier) VerifyModule(modules *AnkoModules, name string) error {
+	return av.verifyModule(modules, name, make(map[string]bool))
+}
+
+func (av *AnkVerifier) verifyModule(modules *AnkoModules, name string, verified map[string]bool) error {
+	file, found := modules.Find(name)
+	if !found {
+		// could be a go package
+		if _, ok := env.Packages[name]; ok {
+			return nil
+		}
+		return fmt.Errorf("module or package not found: %s (searched in %s)", name, strings.Join(modules.GetPaths(), ", "))
+	}
+	if verified[file] {
+		return nil
+	}
+	verified[file] = true
+	imports, err := av.verifyFile(name, file)
+	if err != nil {
+		return err
+	}
+	for _, imported := range imports {
+		if err := av.verifyModule(modules, imported, verified); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyFile parses the file, and returns the names of the imported modules
+func (av *AnkVerifier) verifyFile(name, file string) ([]string, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, &AnkoModuleError{Module: name, File: file, Err: err}
+	}
+	source, imports := rewriteImports(string(content))
+	if _, err := parser.ParseSrc(source); err != nil {
+		return nil, moduleError(name, file, err)
+	}
+	return imports, nil
+}
@@ -0,0 +1,363 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/mattn/anko/ast"
+	"github.com/mattn/anko/env"
+	"github.com/mattn/anko/parser"
+	"github.com/mattn/anko/vm"
+)
+
+const (
+	AnkoModuleExt  = ".ank"
+	ankoImportFunc = "Import" // the builtin the import keyword is rewritten to. same length, so positions are kept
+)
+
+// AnkoModuleDir is the directory of the project, they contains the anko modules
+var AnkoModuleDir = filepath.Join(".contxt", "anko")
+
+// AnkoModuleError is an error in a module. it contains the file of the module
+// and the position of the error in this file
+type AnkoModuleError struct {
+	Module string
+	File   string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *AnkoModuleError) Error() string {
+	return fmt.Sprintf("module %s (%s:%d:%d): %v", e.Module, e.File, e.Line, e.Column, e.Err)
+}
+
+func (e *AnkoModuleError) Unwrap() error {
+	return e.Err
+}
+
+// moduleRef is a module they is currently loaded
+type moduleRef struct {
+	name string
+	file string
+}
+
+// AnkoModules finds and parses the modules for the anko runners of one run.
+// a module is parsed once, and the result is shared by all runners.
+type AnkoModules struct {
+	mu      sync.Mutex
+	paths   []string
+	sources map[string]ast.Stmt
+}
+
+// NewAnkoModules creates the module loader. the paths are searched in the given order
+func NewAnkoModules(paths ...string) *AnkoModules {
+	return &AnkoModules{
+		paths:   paths,
+		sources: make(map[string]ast.Stmt),
+	}
+}
+
+// NewDefaultAnkoModules creates the module loader for the modules of the project
+// in the base path. the path is resolved while the loader is created, so a later
+// change of the current directory does not change the modules.
+// an empty base path is the current directory
+func NewDefaultAnkoModules(basePath string) *AnkoModules {
+	if absPath, err := filepath.Abs(basePath); err == nil {
+		basePath = absPath
+	}
+	return NewAnkoModules(filepath.Join(basePath, AnkoModuleDir))
+}
+
+// AddPath adds a path that will be searched for modules
+func (m *AnkoModules) AddPath(path string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.paths = append(m.paths, path)
+}
+
+// GetPaths returns the paths, they are searched for modules
+func (m *AnkoModules) GetPaths() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string{}, m.paths...)
+}
+
+// Find returns the file of the module.
+// the name can contain slashes, to use modules in sub directories.
+func (m *AnkoModules) Find(name string) (string, bool) {
+	if name == "" || strings.Contains(name, "..") {
+		return "", false
+	}
+	fileName := filepath.FromSlash(name) + AnkoModuleExt
+	for _, path := range m.GetPaths() {
+		file := filepath.Join(path, fileName)
+		if stat, err := os.Stat(file); err == nil && !stat.IsDir() {
+			if abs, err := filepath.Abs(file); err == nil {
+				return abs, true
+			}
+			return file, true
+		}
+	}
+	return "", false
+}
+
+// parse returns the parsed source of the module file.
+// the source is parsed only once.
+func (m *AnkoModules) parse(name, file string) (ast.Stmt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stmt, ok := m.sources[file]; ok {
+		return stmt, nil
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, &AnkoModuleError{Module: name, File: file, Err: err}
+	}
+	source, _ := rewriteImports(string(content))
+	stmt, err := parser.ParseSrc(source)
+	if err != nil {
+		return nil, moduleError(name, file, err)
+	}
+	m.sources[file] = stmt
+	return stmt, nil
+}
+
+// moduleError adds the module file to the error.
+// errors from imported modules are kept as they are, so they point to the
+// module that contains the error.
+func moduleError(name, file string, err error) error {
+	switch tErr := err.(type) {
+	case *AnkoModuleError:
+		return tErr
+	case *vm.Error:
+		return &AnkoModuleError{Module: name, File: file, Line: tErr.Pos.Line, Column: tErr.Pos.Column, Err: fmt.Errorf("%s", tErr.Message)}
+	case *parser.Error:
+		return &AnkoModuleError{Module: name, File: file, Line: tErr.Pos.Line, Column: tErr.Pos.Column, Err: fmt.Errorf("%s", tErr.Message)}
+	default:
+		return &AnkoModuleError{Module: name, File: file, Err: err}
+	}
+}
+
+// rewriteImports replaces the import keyword by the Import builtin,
+// because the keyword of anko only knows go packages.
+// it also returns the names of the modules, they are imported by a string literal.
+// if the source can not be scanned, it is returned as it is, so the parser reports the error.
+func rewriteImports(source string) (string, []string) {
+	var names []string
+	var positions []ast.Position
+	scanner := &parser.Scanner{}
+	scanner.Init(source)
+	lastImport := false
+	for {
+		tok, lit, pos, err := scanner.Scan()
+		if err != nil {
+			return source, nil
+		}
+		if tok == parser.EOF {
+			break
+		}
+		if tok == parser.IMPORT {
+			positions = append(positions, pos)
+			lastImport = true
+			continue
+		}
+		if lastImport && tok == parser.STRING {
+			names = append(names, lit)
+		}
+		lastImport = lastImport && tok == '('
+	}
+	if len(positions) == 0 {
+		return source, names
+	}
+
+	runes := []rune(source)
+	lineStarts := []int{0}
+	for i, r := range runes {
+		if r == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	for _, pos := range positions {
+		offset := lineStarts[pos.Line-1] + pos.Column - 1
+		copy(runes[offset:], []rune(ankoImportFunc))
+	}
+	return string(runes), names
+}
+
+// errorType is the type, anko expects for errors of the vm functions
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// importBuiltin is the Import builtin of the runner.
+// it uses the vm function format of anko, so an error stops the script.
+func (ar *AnkoRunner) importBuiltin(ctx context.Context, name reflect.Value) (reflect.Value, reflect.Value) {
+	errValue := reflect.New(errorType).Elem()
+	if name.Kind() == reflect.Interface {
+		name = name.Elem()
+	}
+	if name.Kind() != reflect.String {
+		errValue.Set(reflect.ValueOf(fmt.Errorf("import needs the name of a module or package. got %v", name)))
+		return reflect.Value{}, errValue
+	}
+	exports, err := ar.importModule(ctx, name.String())
+	if err != nil {
+		errValue.Set(reflect.ValueOf(err))
+		return reflect.Value{}, errValue
+	}
+	return reflect.ValueOf(exports), errValue
+}
+
+// loadBuiltin replaces load of anko core. the file runs in the scope of the runner,
+// with the same rewrite of the imports as any other script.
+func (ar *AnkoRunner) loadBuiltin(ctx context.Context, file reflect.Value) (reflect.Value, reflect.Value) {
+	errValue := reflect.New(errorType).Elem()
+	name := fmt.Sprint(ankoValue(file))
+	content, err := os.ReadFile(name)
+	if err != nil {
+		errValue.Set(reflect.ValueOf(err))
+		return reflect.Value{}, errValue
+	}
+	source, _ := rewriteImports(string(content))
+	result, err := vm.ExecuteContext(ctx, ar.env, &ar.options, source)
+	if err != nil {
+		errValue.Set(reflect.ValueOf(moduleError(name, name, err)))
+		return reflect.Value{}, errValue
+	}
+	return reflect.ValueOf(result), errValue
+}
+
+// importModule loads the module with the given name, and returns the exported symbols.
+// any symbol they not starts with an underscore is exported.
+// if there is no module with this name, the go package is imported, like anko would do.
+func (ar *AnkoRunner) importModule(ctx context.Context, name string) (*env.Env, error) {
+	file, found := "", false
+	if ar.modules != nil {
+		file, found = ar.modules.Find(name)
+	}
+	if !found {
+		return ar.importPackage(name)
+	}
+	if exports, ok := ar.loaded[file]; ok {
+		return exports, nil
+	}
+	for i, loading := range ar.loading {
+		if loading.file == file {
+			chain := []string{}
+			for _, l := range ar.loading[i:] {
+				chain = append(chain, l.name)
+			}
+			chain = append(chain, name)
+			return nil, &AnkoModuleError{Module: name, File: file, Err: fmt.Errorf("circular import %s", strings.Join(chain, " -> "))}
+		}
+	}
+
+	stmt, err := ar.modules.parse(name, file)
+	if err != nil {
+		return nil, err
+	}
+	ar.loading = append(ar.loading, moduleRef{name: name, file: file})
+	defer func() {
+		ar.loading = ar.loading[:len(ar.loading)-1]
+	}()
+
+	// the module runs in his own scope, but can use all the functions of the runner
+	modEnv := ar.env.NewEnv()
+	if _, err := vm.RunContext(ctx, modEnv, &ar.options, stmt); err != nil {
+		return nil, moduleError(name, file, err)
+	}
+	exports := env.NewEnv()
+	for _, symbol := range modEnv.GetValueSymbols() {
+		if strings.HasPrefix(symbol, "_") {
+			continue
+		}
+		value, err := modEnv.GetValue(symbol)
+		if err != nil {
+			return nil, moduleError(name, file, err)
+		}
+		if err := exports.DefineValue(symbol, value); err != nil {
+			return nil, moduleError(name, file, err)
+		}
+	}
+	ar.loaded[file] = exports
+	ar.logger.Debug("anko module loaded", name, file)
+	return exports, nil
+}
+
+// ankoSafePackages are the go packages, they can be imported at any risk level.
+// they have no side effects outside of the script. any other package, like os or os/exec,
+// needs RISK_LEVEL_HIGH. otherwise they could be used to bypass the risk policy
+var ankoSafePackages = map[string]bool{
+	"bytes":         true,
+	"encoding/json": true,
+	"errors":        true,
+	"fmt":           true,
+	"math":          true,
+	"math/big":      true,
+	"math/rand":     true,
+	"net/url":       true,
+	"path":          true,
+	"regexp":        true,
+	"sort":          true,
+	"strconv":       true,
+	"strings":       true,
+	"time":          true,
+}
+
+// importPackage imports the go package with the given name
+func (ar *AnkoRunner) importPackage(name string) (*env.Env, error) {
+	methods, ok := env.Packages[name]
+	if ok && !ankoSafePackages[name] && ar.riskLevel < RISK_LEVEL_HIGH {
+		ar.logger.Warn("anko package import blocked", name, ar.riskLevel)
+		return nil, &AnkoException{
+			Err: fmt.Errorf("package %s is %w: it needs the risk level %s, but only %s is allowed", name, ErrRiskBlocked, RISK_LEVEL_HIGH, ar.riskLevel),
+		}
+	}
+	if !ok {
+		paths := []string{}
+		if ar.modules != nil {
+			paths = ar.modules.GetPaths()
+		}
+		return nil, fmt.Errorf("module or package not found: %s (searched in %s)", name, strings.Join(paths, ", "))
+	}
+	pack := ar.env.NewEnv()
+	for methodName, methodValue := range methods {
+		if err := pack.DefineValue(methodName, methodValue); err != nil {
+			return nil, err
+		}
+	}
+	for typeName, typeValue := range env.PackageTypes[name] {
+		if err := pack.DefineReflectType(typeName, typeValue); err != nil {
+			return nil, err
+		}
+	}
+	return pack, nil
+}
@@ -0,0 +1,245 @@
+package tasks_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+// writeModules writes the modules to a new temp directory and returns the directory
+func writeModules(t *testing.T, modules map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, source := range modules {
+		file := filepath.Join(dir, filepath.FromSlash(name)+tasks.AnkoModuleExt)
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(source), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestAnkoImportModule(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"greet":      "_prefix = \"Hello \"\nfunc greet(name) {\n  return _prefix + name\n}",
+		"util/twice": "func twice(n) {\n  return n * 2\n}",
+	})
+	ar := tasks.NewAnkoRunner()
+	ar.SetOutputSupression(true)
+	ar.SetModules(tasks.NewAnkoModules(dir))
+	_, err := ar.RunAnko(`g = import("greet")
+t = import("util/twice")
+strings = import("strings")
+println(strings.ToUpper(g.greet("world")))
+println(t.twice(21))`)
+	assertNoError(t, err)
+	buff := ar.GetBuffer()
+	assertSliceContains(t, buff, "HELLO WORLD")
+	assertSliceContains(t, buff, "42")
+
+	// symbols with an underscore are not exported
+	_, err = ar.RunAnko(`g = import("greet")
+println(g._prefix)`)
+	if err == nil {
+		t.Error("expected an error for the private symbol")
+	}
+}
+
+func TestDefaultAnkoModulesResolvedOnCreate(t *testing.T) {
+	base := t.TempDir()
+	file := filepath.Join(base, tasks.AnkoModuleDir, "helper"+tasks.AnkoModuleExt)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte("func double(n) {\n  return n * 2\n}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(cwd)
+
+	// the base path is used, and not the current directory
+	if found, ok := tasks.NewDefaultAnkoModules(base).Find("helper"); !ok || found != file {
+		t.Errorf("expected the module %s, got %s", file, found)
+	}
+
+	// the current directory is resolved while the modules are created
+	if err := os.Chdir(base); err != nil {
+		t.Fatal(err)
+	}
+	modules := tasks.NewDefaultAnkoModules("")
+	if err := os.Chdir(cwd); err != nil {
+		t.Fatal(err)
+	}
+	if found, ok := modules.Find("helper"); !ok || found != file {
+		t.Errorf("expected the module %s after changing the directory, got %s", file, found)
+	}
+}
+
+func TestAnkoImportModuleCached(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"counter": "println(\"loading counter\")\ncount = 0\nfunc inc() {\n  count = count + 1\n  return count\n}",
+		"user":    "c = import(\"counter\")\nfunc next() {\n  return c.inc()\n}",
+	})
+	ar := tasks.NewAnkoRunner()
+	ar.SetOutputSupression(true)
+	ar.SetModules(tasks.NewAnkoModules(dir))
+	_, err := ar.RunAnko(`c = import("counter")
+u = import("user")
+c.inc()
+println(u.next())`)
+	assertNoError(t, err)
+	buff := ar.GetBuffer()
+	assertContainsCount(t, buff, "loading counter", 1)
+	assertSliceContains(t, buff, "2")
+}
+
+func TestAnkoImportCircular(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"first":  "s = import(\"second\")",
+		"second": "f = import(\"first\")",
+	})
+	ar := tasks.NewAnkoRunner()
+	ar.SetOutputSupression(true)
+	ar.SetModules(tasks.NewAnkoModules(dir))
+	_, err := ar.RunAnko(`import("first")`)
+	if err == nil {
+		t.Fatal("expected an error for the circular import")
+	}
+	if !strings.Contains(err.Error(), "circular import first -> second -> first") {
+		t.Error("unexpected error:", err)
+	}
+}
+
+func TestAnkoImportErrorHasFile(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"broken": "a = 1\nb = a + undefinedThing",
+	})
+	ar := tasks.NewAnkoRunner()
+	ar.SetOutputSupression(true)
+	ar.SetModules(tasks.NewAnkoModules(dir))
+	_, err := ar.RunAnko(`import("broken")`)
+	if err == nil {
+		t.Fatal("expected an error from the module")
+	}
+	if !strings.Contains(err.Error(), "broken.ank:2:") {
+		t.Error("the error should contain the file and line of the module:", err)
+	}
+
+	_, err = ar.RunAnko(`import("missing")`)
+	if err == nil || !strings.Contains(err.Error(), "module or package not found: missing") {
+		t.Error("expected a not found error, got", err)
+	}
+}
+
+func TestAnkoVerifyModule(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"main":   "h = import(\"helper\")\nstrings = import(\"strings\")",
+		"helper": "func ok() {\n  return 1\n}\nfunc broken( {",
+	})
+	av := tasks.NewAnkVerifier()
+	err := av.VerifyModule(tasks.NewAnkoModules(dir), "main")
+	var modErr *tasks.AnkoModuleError
+	if !errors.As(err, &modErr) {
+		t.Fatal("expected a module error, got", err)
+	}
+	assertStringEqual(t, "helper", modErr.Module)
+	assertStringEqual(t, filepath.Join(dir, "helper.ank"), modErr.File)
+	assertIntEqual(t, 4, modErr.Line)
+
+	assertNoError(t, av.VerifyFile(filepath.Join(dir, "main.ank")))
+}
+
+func TestAnkoImportInTask(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"greet": "func greet(name) {\n  return \"Hello \" + name\n}",
+	})
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID: "first",
+				Cmd: []string{
+					`g = import("greet")`,
+					`println(g.greet("first"))`,
+				},
+			},
+			{
+				ID: "second",
+				Cmd: []string{
+					`g = import("greet")`,
+					`println(g.greet("second"))`,
+				},
+			},
+		},
+	}
+	outputs := []string{}
+	outHandler := func(msg ...interface{}) {
+		for _, m := range msg {
+			if s, ok := m.(tasks.MsgExecOutput); ok {
+				outputs = append(outputs, strings.TrimSpace(s.Output))
+			}
+		}
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, req, tasks.NewWatchman(), tasks.NewAnkoModules(dir))
+	tsk.SetHardExistToAllTasks(false)
+	tsk.RunTarget("first", false)
+	tsk.RunTarget("second", false)
+	assertSliceContains(t, outputs, "Hello first")
+	assertSliceContains(t, outputs, "Hello second")
+}
+
+func TestAnkoImportPackageRiskLevel(t *testing.T) {
+	dir := t.TempDir()
+	victim := filepath.Join(dir, "victim.txt")
+	if err := os.WriteFile(victim, []byte("keep me"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded := filepath.Join(dir, "loaded.ank")
+	if err := os.WriteFile(loaded, []byte("o = import(\"os\")\no.Remove(\""+filepath.ToSlash(victim)+"\")"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	scripts := []string{
+		"o = import(\"os\")\no.Remove(\"" + filepath.ToSlash(victim) + "\")",
+		"e = import(\"os/exec\")\ne.Command(\"rm\", \"" + filepath.ToSlash(victim) + "\").Run()",
+		"load(\"" + filepath.ToSlash(loaded) + "\")",
+	}
+	for _, script := range scripts {
+		ar := tasks.NewAnkoRunner()
+		ar.SetOutputSupression(true)
+		ar.SetRiskLevel(tasks.RISK_LEVEL_LOW)
+		_, err := ar.RunAnko(script)
+		if err == nil || !strings.Contains(err.Error(), "blocked by the risk policy: it needs the risk level high, but only low is allowed") {
+			t.Errorf("expected the import to be blocked for %s. got %v", script, err)
+		}
+		assertFileExists(t, victim)
+	}
+
+	// packages without side effects can be imported at any level
+	ar := tasks.NewAnkoRunner()
+	ar.SetOutputSupression(true)
+	ar.SetRiskLevel(tasks.RISK_LEVEL_LOW)
+	_, err := ar.RunAnko("strings = import(\"strings\")\nprintln(strings.ToUpper(\"low\"))")
+	assertNoError(t, err)
+	assertSliceContains(t, ar.GetBuffer(), "LOW")
+
+	ar = tasks.NewAnkoRunner()
+	ar.SetOutputSupression(true)
+	ar.SetRiskLevel(tasks.RISK_LEVEL_HIGH)
+	_, err = ar.RunAnko("o = import(\"os\")\no.Remove(\"" + filepath.ToSlash(victim) + "\")")
+	assertNoError(t, err)
+	assertFileNotExists(t, victim)
+}
@@ -0,0 +1,516 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mattn/anko/env"
+	"github.com/mattn/anko/parser"
+)
+
+const (
+	AnkoTestSuffix = "_test.ank" // the suffix of the files, they contains anko tests
+	AnkoTestPrefix = "test_"     // the prefix of the functions, they are tests
+
+	ankoAssertAt = "__at" // the builtin that binds the assertions to a line of the test file
+)
+
+// the report formats of the test results
+const (
+	AnkoTestFormatPlain = "plain"
+	AnkoTestFormatJson  = "json"
+	AnkoTestFormatJUnit = "junit"
+)
+
+// ankoAssertions are the assertion builtins of the test mode
+var ankoAssertions = []string{"assertEqual", "assertTrue", "assertError", "fail"}
+
+var ankoTestFuncRegex = regexp.MustCompile(`^\s*func\s+(` + AnkoTestPrefix + `\w+)\s*\(`)
+
+// AnkoTestResult is the result of one test function
+type AnkoTestResult struct {
+	File     string        `json:"file"`
+	Name     string        `json:"name"`
+	Line     int           `json:"line"`
+	Passed   bool          `json:"passed"`
+	Message  string        `json:"message,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Output   []string      `json:"output,omitempty"`
+}
+
+// AnkoTestFailure is the error of a failed assertion.
+// the line is the line of the assertion in the test file, or 0 if it is unknown
+type AnkoTestFailure struct {
+	Line    int
+	Message string
+}
+
+func (f *AnkoTestFailure) Error() string {
+	return f.Message
+}
+
+// ankoTestFunc is a test function, found in a test file
+type ankoTestFunc struct {
+	name string
+	line int
+}
+
+// ankoTestState is the state of one running test.
+// the first failed assertion is kept, because anko reports errors
+// of a called function with the position of the function
+type ankoTestState struct {
+	failure *AnkoTestFailure
+}
+
+// AnkoTester runs the test functions of anko test files.
+// any test runs in a fresh AnkoRunner, created by the factory.
+type AnkoTester struct {
+	newRunner func(file string) *AnkoRunner
+}
+
+// NewAnkoTester creates a tester, they uses the factory to create the runner for each test.
+// if the factory is nil, a plain AnkoRunner is used.
+func NewAnkoTester(newRunner func(file string) *AnkoRunner) *AnkoTester {
+	if newRunner == nil {
+		newRunner = func(file string) *AnkoRunner {
+			return NewAnkoRunner()
+		}
+	}
+	return &AnkoTester{newRunner: newRunner}
+}
+
+// FindAnkoTests returns the test files below the root, sorted by name.
+// if the root is a file, it is returned as it is.
+func FindAnkoTests(root string) ([]string, error) {
+	stat, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.IsDir() {
+		return []string{root}, nil
+	}
+	var files []string
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(info.Name(), AnkoTestSuffix) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// Run runs the tests of all the files
+func (at *AnkoTester) Run(files []string) []AnkoTestResult {
+	var results []AnkoTestResult
+	for _, file := range files {
+		results = append(results, at.RunFile(file)...)
+	}
+	return results
+}
+
+// RunFile runs any test function of the file.
+// if the file could not be parsed, there is one failed result for the whole file.
+func (at *AnkoTester) RunFile(file string) []AnkoTestResult {
+	if err := NewAnkVerifier().VerifyFile(file); err != nil {
+		return []AnkoTestResult{at.fileFailure(file, err)}
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return []AnkoTestResult{at.fileFailure(file, err)}
+	}
+	source := rewriteAssertions(string(content))
+	var results []AnkoTestResult
+	for _, fn := range findAnkoTestFuncs(string(content)) {
+		results = append(results, at.runTest(file, source, fn))
+	}
+	return results
+}
+
+func (at *AnkoTester) fileFailure(file string, err error) AnkoTestResult {
+	res := AnkoTestResult{File: file, Name: filepath.Base(file), Message: err.Error()}
+	if modErr, ok := err.(*AnkoModuleError); ok {
+		res.Line = modErr.Line
+		res.Message = modErr.Err.Error()
+	}
+	return res
+}
+
+// runTest runs the source of the test file, and then the test function.
+func (at *AnkoTester) runTest(file, source string, fn ankoTestFunc) AnkoTestResult {
+	res := AnkoTestResult{File: file, Name: fn.name, Line: fn.line}
+	anko := at.newRunner(file)
+	// modules next to the test file can be imported by the tests
+	modules := NewAnkoModules(filepath.Dir(file))
+	if current := anko.GetModules(); current != nil {
+		for _, path := range current.GetPaths() {
+			modules.AddPath(path)
+		}
+	}
+	anko.SetModules(modules)
+	anko.SetOutputSupression(true)
+
+	state := &ankoTestState{}
+	if err := at.defineAssertions(anko, state); err != nil {
+		res.Message = err.Error()
+		return res
+	}
+
+	start := time.Now()
+	_, err := anko.RunAnko(source)
+	if err != nil {
+		// the error is in the file itself, so the position is right
+		res.Message = anko.ErrorPos2Message(err).Error()
+		if pos, ok := anko.Error2MsgErrorDebug(fn.name, err); ok {
+			res.Line = pos.Line
+		}
+	} else if _, err = anko.RunAnko(fn.name + "()"); err != nil {
+		res.Message = err.Error()
+		if state.failure != nil {
+			res.Message = state.failure.Message
+			if state.failure.Line > 0 {
+				res.Line = state.failure.Line
+			}
+		}
+	}
+	res.Duration = time.Since(start)
+	res.Output = anko.GetBuffer()
+	res.Passed = err == nil
+	return res
+}
+
+// defineAssertions defines the assertion builtins for the test.
+// the rewritten source calls them by __at(line), so they know the line of the call.
+func (at *AnkoTester) defineAssertions(anko *AnkoRunner, state *ankoTestState) error {
+	if _, err := anko.InitEnv(); err != nil {
+		return err
+	}
+	for symbol, fn := range at.assertions(state, 0) {
+		if err := anko.Define(symbol, fn); err != nil {
+			return err
+		}
+	}
+	return anko.Define(ankoAssertAt, func(line int64) *env.Env {
+		bound := env.NewEnv()
+		for symbol, fn := range at.assertions(state, int(line)) {
+			bound.Define(symbol, fn)
+		}
+		return bound
+	})
+}
+
+// assertions returns the assertion builtins, bound to the line.
+// they are using the vm function format of anko, so a failure stops the test.
+func (at *AnkoTester) assertions(state *ankoTestState, line int) map[string]interface{} {
+	failed := func(format string, args ...interface{}) (reflect.Value, reflect.Value) {
+		failure := &AnkoTestFailure{Line: line, Message: fmt.Sprintf(format, args...)}
+		if state.failure == nil {
+			state.failure = failure
+		}
+		errValue := reflect.New(errorType).Elem()
+		errValue.Set(reflect.ValueOf(failure))
+		return reflect.Value{}, errValue
+	}
+	passed := func() (reflect.Value, reflect.Value) {
+		return reflect.ValueOf(true), reflect.New(errorType).Elem()
+	}
+	return map[string]interface{}{
+		"assertEqual": func(ctx context.Context, expected, actual reflect.Value) (reflect.Value, reflect.Value) {
+			exp, act := ankoValue(expected), ankoValue(actual)
+			if !ankoEqual(exp, act) {
+				return failed("expected %v (%T), but got %v (%T)", exp, exp, act, act)
+			}
+			return passed()
+		},
+		"assertTrue": func(ctx context.Context, condition reflect.Value) (reflect.Value, reflect.Value) {
+			if cond, ok := ankoValue(condition).(bool); !ok || !cond {
+				return failed("expected true, but got %v", ankoValue(condition))
+			}
+			return passed()
+		},
+		"assertError": func(ctx context.Context, value reflect.Value) (reflect.Value, reflect.Value) {
+			if _, ok := ankoValue(value).(error); !ok {
+				return failed("expected an error, but got %v", ankoValue(value))
+			}
+			return passed()
+		},
+		"fail": func(ctx context.Context, message reflect.Value) (reflect.Value, reflect.Value) {
+			return failed("%v", ankoValue(message))
+		},
+	}
+}
+
+// ankoValue returns the value of an argument of a vm function
+func ankoValue(v reflect.Value) interface{} {
+	for v.IsValid() && (v.Kind() == reflect.Interface || v.Type() == reflect.TypeOf(reflect.Value{})) {
+		if v.Kind() == reflect.Interface {
+			if v.IsNil() {
+				return nil
+			}
+			v = v.Elem()
+			continue
+		}
+		v = v.Interface().(reflect.Value)
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// ankoEqual compares the values. numbers are equal if they have the same value,
+// so 1 is equal to 1.0, and an int64 of anko is equal to an int of a go function.
+func ankoEqual(expected, actual interface{}) bool {
+	if reflect.DeepEqual(expected, actual) {
+		return true
+	}
+	exp, expOk := ankoNumber(expected)
+	act, actOk := ankoNumber(actual)
+	return expOk && actOk && exp == act
+}
+
+func ankoNumber(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// findAnkoTestFuncs returns the test functions of the source, with the line they are declared
+func findAnkoTestFuncs(source string) []ankoTestFunc {
+	var funcs []ankoTestFunc
+	for i, line := range strings.Split(source, "\n") {
+		if match := ankoTestFuncRegex.FindStringSubmatch(line); match != nil {
+			funcs = append(funcs, ankoTestFunc{name: match[1], line: i + 1})
+		}
+	}
+	return funcs
+}
+
+// rewriteAssertions binds the calls of the assertions to the line of the call.
+// assertEqual(a, b) in line 12 becomes __at(12).assertEqual(a, b).
+// the lines are not changed, so any other error still points to the right line.
+func rewriteAssertions(source string) string {
+	type call struct {
+		line   int
+		column int
+	}
+	var calls []call
+	scanner := &parser.Scanner{}
+	scanner.Init(source)
+	var prev, current rune
+	var currentLit string
+	var currentPos call
+	for {
+		tok, lit, pos, err := scanner.Scan()
+		if err != nil {
+			return source
+		}
+		if tok == '(' && current == parser.IDENT && prev != '.' && isAnkoAssertion(currentLit) {
+			calls = append(calls, currentPos)
+		}
+		if tok == parser.EOF {
+			break
+		}
+		prev, current, currentLit, currentPos = current, rune(tok), lit, call{pos.Line, pos.Column}
+	}
+	if len(calls) == 0 {
+		return source
+	}
+
+	lines := strings.Split(source, "\n")
+	// from the end, so the columns of the calls before are not moved
+	for i := len(calls) - 1; i >= 0; i-- {
+		c := calls[i]
+		runes := []rune(lines[c.line-1])
+		prefix := []rune(fmt.Sprintf("%s(%d).", ankoAssertAt, c.line))
+		offset := c.column - 1
+		runes = append(runes[:offset], append(prefix, runes[offset:]...)...)
+		lines[c.line-1] = string(runes)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isAnkoAssertion(name string) bool {
+	for _, assertion := range ankoAssertions {
+		if assertion == name {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteAnkoTestReport writes the results in the format plain, json or junit
+func WriteAnkoTestReport(w io.Writer, format string, results []AnkoTestResult) error {
+	if err := CheckAnkoTestFormat(format); err != nil {
+		return err
+	}
+	switch format {
+	case "", AnkoTestFormatPlain:
+		return writeAnkoTestPlain(w, results)
+	case AnkoTestFormatJson:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	case AnkoTestFormatJUnit:
+		return writeAnkoTestJUnit(w, results)
+	}
+	return nil
+}
+
+// CheckAnkoTestFormat returns an error, if the format of the report is unknown.
+// an empty format is the plain format
+func CheckAnkoTestFormat(format string) error {
+	switch format {
+	case "", AnkoTestFormatPlain, AnkoTestFormatJson, AnkoTestFormatJUnit:
+		return nil
+	}
+	return fmt.Errorf("unknown report format %s. use %s, %s or %s", format, AnkoTestFormatPlain, AnkoTestFormatJson, AnkoTestFormatJUnit)
+}
+
+// AnkoTestsFailed returns the number of failed tests
+func AnkoTestsFailed(results []AnkoTestResult) int {
+	failed := 0
+	for _, res := range results {
+		if !res.Passed {
+			failed++
+		}
+	}
+	return failed
+}
+
+func writeAnkoTestPlain(w io.Writer, results []AnkoTestResult) error {
+	for _, res := range results {
+		if res.Passed {
+			fmt.Fprintf(w, "ok   %s (%s) %v\n", res.Name, res.File, res.Duration)
+			continue
+		}
+		fmt.Fprintf(w, "FAIL %s (%s:%d) %v\n", res.Name, res.File, res.Line, res.Duration)
+		fmt.Fprintf(w, "     %s\n", res.Message)
+		for _, out := range res.Output {
+			fmt.Fprintf(w, "     | %s\n", out)
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d tests, %d failed\n", len(results), AnkoTestsFailed(results))
+	return err
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr"`
+	Line      int           `xml:"line,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+// writeAnkoTestJUnit writes the results as junit xml. any file is a test suite.
+func writeAnkoTestJUnit(w io.Writer, results []AnkoTestResult) error {
+	report := junitTestSuites{}
+	suites := map[string]int{}
+	durations := map[string]time.Duration{}
+	for _, res := range results {
+		idx, ok := suites[res.File]
+		if !ok {
+			idx = len(report.Suites)
+			suites[res.File] = idx
+			report.Suites = append(report.Suites, junitTestSuite{Name: res.File})
+		}
+		tc := junitTestCase{
+			Name:      res.Name,
+			ClassName: strings.TrimSuffix(filepath.Base(res.File), AnkoTestSuffix),
+			File:      res.File,
+			Line:      res.Line,
+			Time:      fmt.Sprintf("%.3f", res.Duration.Seconds()),
+			SystemOut: strings.Join(res.Output, "\n"),
+		}
+		if !res.Passed {
+			tc.Failure = &junitFailure{Message: res.Message, Text: fmt.Sprintf("%s:%d: %s", res.File, res.Line, res.Message)}
+			report.Suites[idx].Failures++
+		}
+		report.Suites[idx].Tests++
+		report.Suites[idx].Cases = append(report.Suites[idx].Cases, tc)
+		durations[res.File] += res.Duration
+	}
+	for i := range report.Suites {
+		report.Suites[i].Time = fmt.Sprintf("%.3f", durations[report.Suites[i].Name].Seconds())
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,116 @@
+package tasks_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/swaros/contxt/module/tasks"
+)
+
+const ankoTestSource = `helper = import("helper")
+
+func test_passing() {
+  println("running passing")
+  assertEqual(4, helper.double(2))
+  assertTrue(helper.double(1) == 2)
+}
+
+func test_failing() {
+  x = 1
+  assertEqual(2, x)
+  fail("not reached")
+}
+
+func test_error() {
+  errors = import("errors")
+  assertError(errors.New("nan"))
+}
+
+func test_fail() {
+  fail("failed by intention")
+  println("not reached")
+}
+
+func notATest() {
+  fail("should never run")
+}
+`
+
+func TestAnkoTestRunFile(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"math_test": ankoTestSource,
+		"helper":    "func double(n) {\n  return n * 2\n}",
+	})
+	files, err := tasks.FindAnkoTests(dir)
+	assertNoError(t, err)
+	assertIntEqual(t, 1, len(files))
+
+	results := tasks.NewAnkoTester(nil).Run(files)
+	assertIntEqual(t, 4, len(results))
+	assertIntEqual(t, 2, tasks.AnkoTestsFailed(results))
+
+	byName := map[string]tasks.AnkoTestResult{}
+	for _, res := range results {
+		byName[res.Name] = res
+	}
+	if !byName["test_passing"].Passed {
+		t.Errorf("expected test_passing to pass, got %s", byName["test_passing"].Message)
+	}
+	assertSliceContains(t, byName["test_passing"].Output, "running passing")
+
+	failing := byName["test_failing"]
+	assertIntEqual(t, 11, failing.Line)
+	if !strings.Contains(failing.Message, "expected 2") {
+		t.Errorf("unexpected message %s", failing.Message)
+	}
+	assertIntEqual(t, 21, byName["test_fail"].Line)
+	assertStringEqual(t, "failed by intention", byName["test_fail"].Message)
+	assertSliceNotContains(t, byName["test_fail"].Output, "not reached")
+	if _, ok := byName["notATest"]; ok {
+		t.Error("notATest should not run as a test")
+	}
+}
+
+func TestAnkoTestSyntaxError(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"broken_test": "func test_broken() {\n  x = (1 + \n}",
+	})
+	results := tasks.NewAnkoTester(nil).Run([]string{filepath.Join(dir, "broken_test.ank")})
+	assertIntEqual(t, 1, len(results))
+	if results[0].Passed || results[0].Line == 0 {
+		t.Errorf("expected a failure with the line of the syntax error, got %+v", results[0])
+	}
+}
+
+func TestAnkoTestReports(t *testing.T) {
+	results := []tasks.AnkoTestResult{
+		{File: "a_test.ank", Name: "test_ok", Line: 1, Passed: true},
+		{File: "a_test.ank", Name: "test_bad", Line: 7, Message: "expected 1"},
+	}
+
+	var plain bytes.Buffer
+	assertNoError(t, tasks.WriteAnkoTestReport(&plain, tasks.AnkoTestFormatPlain, results))
+	if !strings.Contains(plain.String(), "FAIL test_bad (a_test.ank:7)") || !strings.Contains(plain.String(), "2 tests, 1 failed") {
+		t.Errorf("unexpected plain report:\n%s", plain.String())
+	}
+
+	var js bytes.Buffer
+	assertNoError(t, tasks.WriteAnkoTestReport(&js, tasks.AnkoTestFormatJson, results))
+	var decoded []tasks.AnkoTestResult
+	assertNoError(t, json.Unmarshal(js.Bytes(), &decoded))
+	assertIntEqual(t, 2, len(decoded))
+
+	var junit bytes.Buffer
+	assertNoError(t, tasks.WriteAnkoTestReport(&junit, tasks.AnkoTestFormatJUnit, results))
+	if !strings.Contains(junit.String(), `<testsuite name="a_test.ank" tests="2" failures="1"`) ||
+		!strings.Contains(junit.String(), `<failure message="expected 1">a_test.ank:7: expected 1</failure>`) {
+		t.Errorf("unexpected junit report:\n%s", junit.String())
+	}
+
+	if err := tasks.WriteAnkoTestReport(&plain, "yaml", results); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 
@@ -54,13 +55,39 @@ type AnkoDefiner struct {
 	help   string      // a help text for the symbol
 }
 
+// Symbol returns the name of the define
+func (d AnkoDefiner) Symbol() string {
+	return d.symbol
+}
+
+// Risk returns the risk level of the define
+func (d AnkoDefiner) Risk() RiskLevel {
+	return d.risk
+}
+
+// Help returns the help text of the define
+func (d AnkoDefiner) Help() string {
+	return d.help
+}
+
 type BufferHook func(msg string)
 
+// ErrRiskBlocked is the error of a function, they is blocked by the risk level
+var ErrRiskBlocked = errors.New("blocked by the risk policy")
+
 type AnkoException struct {
 	Script string
 	Err    error
 }
 
+func (e *AnkoException) Error() string {
+	return e.Err.Error()
+}
+
+func (e *AnkoException) Unwrap() error {
+	return e.Err
+}
+
 type AnkoRunner struct {
 	env           *env.Env
 	defaults      []AnkoDefiner
@@ -80,6 +107,12 @@ type AnkoRunner struct {
 	exceptions    []AnkoException
 	riskLevel     RiskLevel
 	logger        mimiclog.Logger
+	workingDir    string              // relative paths used by the file functions are resolved against this directory
+	cmdEnv        []string            // the environment for commands started by exec. nil means the environment of contxt
+	modules       *AnkoModules        // the modules they can be imported. nil means only go packages can be imported
+	loaded        map[string]*env.Env // the exports of the modules they are already loaded, by file
+	loading       []moduleRef         // the modules they are currently loading. used to detect circular imports
+	blocked       []AnkoDefiner       // the defines with a higher risk level than the runner. calling them raises an exception
 }
 
 func NewAnkoRunner() *AnkoRunner {
@@ -100,6 +133,7 @@ func NewAnkoRunner() *AnkoRunner {
 		exceptions:    []AnkoException{},
 		riskLevel:     RISK_LEVEL_LOW,
 		logger:        mimiclog.NewNullLogger(),
+		loaded:        make(map[string]*env.Env),
 	}
 }
 
@@ -118,12 +152,49 @@ func (ar *AnkoRunner) AddDefaultDefine(symbol string, value interface{}, risk Ri
 	return nil
 }
 
+// SetModules sets the modules, they can be imported by the scripts
+func (ar *AnkoRunner) SetModules(modules *AnkoModules) {
+	ar.modules = modules
+}
+
+// GetModules returns the modules, they can be imported by the scripts
+func (ar *AnkoRunner) GetModules() *AnkoModules {
+	return ar.modules
+}
+
 // SetRiskLevel sets the risk level for the AnkoRunner.
 // so any DefaultDefine that is added must be at the same or lower risk level
 func (ar *AnkoRunner) SetRiskLevel(risk RiskLevel) {
 	ar.riskLevel = risk
 }
 
+// SetWorkingDir sets the directory that is used by commands and file functions.
+// if not set, the current directory is used.
+func (ar *AnkoRunner) SetWorkingDir(dir string) {
+	ar.workingDir = dir
+}
+
+// GetWorkingDir returns the directory that is used by commands and file functions
+func (ar *AnkoRunner) GetWorkingDir() string {
+	return ar.workingDir
+}
+
+// SetCmdEnv sets the environment for the commands started by exec.
+// if not set, the environment of contxt is used.
+func (ar *AnkoRunner) SetCmdEnv(env []string) {
+	ar.cmdEnv = env
+}
+
+// GetCmdEnv returns the environment for the commands started by exec
+func (ar *AnkoRunner) GetCmdEnv() []string {
+	return ar.cmdEnv
+}
+
+// ResolvePath returns the path relative to the working dir, if the path is not absolute
+func (ar *AnkoRunner) ResolvePath(path string) string {
+	return resolvePath(ar.workingDir, path)
+}
+
 func (ar *AnkoRunner) SetTimeOut(to time.Duration) {
 	ar.timeOut = to
 	ar.conTxt, ar.timeoutCancel = context.WithTimeout(ar.conTxt, ar.timeOut)
@@ -191,8 +262,15 @@ func (ar *AnkoRunner) ClearBuffer() {
 	ar.outBuffer = []string{}
 }
 
+// AddDefaultDefines adds the defines to the runner.
+// different to AddDefaultDefine, defines with a higher risk level than the runner are not an error.
+// they are not defined, but calling them raises an AnkoException.
 func (ar *AnkoRunner) AddDefaultDefines(defs []AnkoDefiner) error {
 	for _, def := range defs {
+		if def.risk > ar.riskLevel && !ar.lazyInit {
+			ar.blocked = append(ar.blocked, def)
+			continue
+		}
 		err := ar.AddDefaultDefine(def.symbol, def.value, def.risk, def.help)
 		if err != nil {
 			return err
@@ -201,6 +279,31 @@ func (ar *AnkoRunner) AddDefaultDefines(defs []AnkoDefiner) error {
 	return nil
 }
 
+// GetDefaultDefines returns all defines of the runner. the builtins, the defaults and the blocked ones
+func (ar *AnkoRunner) GetDefaultDefines() []AnkoDefiner {
+	defs := append(ar.BuiltinDefines(), ar.defaults...)
+	return append(defs, ar.blocked...)
+}
+
+// GetBlocked returns the defines they are blocked by the risk level
+func (ar *AnkoRunner) GetBlocked() []AnkoDefiner {
+	return ar.blocked
+}
+
+// blockedFn returns the function for a blocked define.
+// it uses the vm function format of anko, so the exception stops the script.
+func (ar *AnkoRunner) blockedFn(def AnkoDefiner) func(context.Context, ...interface{}) (reflect.Value, reflect.Value) {
+	return func(_ context.Context, _ ...interface{}) (reflect.Value, reflect.Value) {
+		err := &AnkoException{
+			Err: fmt.Errorf("%s is %w: it needs the risk level %s, but only %s is allowed", def.symbol, ErrRiskBlocked, def.risk, ar.riskLevel),
+		}
+		ar.logger.Warn("anko function blocked", def.symbol, def.risk, ar.riskLevel)
+		errValue := reflect.New(errorType).Elem()
+		errValue.Set(reflect.ValueOf(err))
+		return reflect.Value{}, errValue
+	}
+}
+
 func (ar *AnkoRunner) handleBufferAdd(str string) {
 	ar.bufferPrepStr += str
 	// now put anything in the buffer, what ends with a newline
@@ -221,31 +324,69 @@ func (ar *AnkoRunner) DefaultDefine() error {
 			return err
 		}
 	}
+	for _, def := range ar.blocked {
+		if err := ar.env.Define(def.symbol, ar.blockedFn(def)); err != nil {
+			return err
+		}
+	}
 	ar.defaultCmds() // anything we want to have as base functions (or redefined like print/println)
 	ar.lazyInit = true
 	return nil
 }
 
 func (ar *AnkoRunner) defaultCmds() {
-	// set output handler to capture output
-	ar.env.Define("print", func(msg ...interface{}) {
-		ar.handleBufferAdd(fmt.Sprint(msg...))
-		if !ar.supressOutput {
-			fmt.Print(msg...)
-		}
-
-	})
-	ar.env.Define("println", func(msg ...interface{}) {
-		ar.handleBufferAdd(fmt.Sprintln(msg...))
-		if !ar.supressOutput {
-			fmt.Println(msg...)
-		}
-	})
+	for _, def := range ar.BuiltinDefines() {
+		ar.env.Define(def.symbol, def.value)
+	}
+}
 
-	// a sleep function. always good to have
-	ar.env.Define("sleep", func(milliSeconds int) {
-		time.Sleep(time.Duration(milliSeconds) * time.Millisecond)
-	})
+// BuiltinDefines returns the functions, they are defined for any script.
+// they are defined after the defaults, so they can not be replaced.
+func (ar *AnkoRunner) BuiltinDefines() []AnkoDefiner {
+	return []AnkoDefiner{
+		// set output handler to capture output
+		{"print",
+			func(msg ...interface{}) {
+				ar.handleBufferAdd(fmt.Sprint(msg...))
+				if !ar.supressOutput {
+					fmt.Print(msg...)
+				}
+			},
+			RISK_LEVEL_LOW,
+			"print the arguments. e.g. print('hello ', name)",
+		},
+		{"println",
+			func(msg ...interface{}) {
+				ar.handleBufferAdd(fmt.Sprintln(msg...))
+				if !ar.supressOutput {
+					fmt.Println(msg...)
+				}
+			},
+			RISK_LEVEL_LOW,
+			"print the arguments and a newline. e.g. println('hello ', name)",
+		},
+		// import is a keyword of anko. the scripts are rewritten to use this function instead
+		{ankoImportFunc,
+			ar.importBuiltin,
+			RISK_LEVEL_LOW,
+			`import a module from .contxt/anko, or a go package. e.g. m = import('mymodule')`,
+		},
+		// load of anko core runs a file without rewriting the imports. so the imports would
+		// not be checked by the risk policy
+		{"load",
+			ar.loadBuiltin,
+			RISK_LEVEL_LOW,
+			`run an anko file in the current scope. e.g. load('scripts/helper.ank')`,
+		},
+		// a sleep function. always good to have
+		{"sleep",
+			func(milliSeconds int) {
+				time.Sleep(time.Duration(milliSeconds) * time.Millisecond)
+			},
+			RISK_LEVEL_LOW,
+			"wait for a given time in milliseconds. e.g. sleep(1000)",
+		},
+	}
 }
 
 func (ar *AnkoRunner) Defines(defs []AnkoDefiner) error {
@@ -303,7 +444,8 @@ func (ar *AnkoRunner) RunAnko(script string) (interface{}, error) {
 		return nil, err
 	}
 	var res interface{}
-	res, ar.lastError = vm.ExecuteContext(ar.conTxt, ar.env, &ar.options, script)
+	source, _ := rewriteImports(script)
+	res, ar.lastError = vm.ExecuteContext(ar.conTxt, ar.env, &ar.options, source)
 
 	// no error, but we have exceptions?
 	if ar.lastError == nil {
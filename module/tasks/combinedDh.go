@@ -24,7 +24,10 @@ package tasks
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/swaros/contxt/module/mimiclog"
@@ -57,6 +60,26 @@ func NewCombinedDataHandler() *CombinedDh {
 	return dh
 }
 
+// Copy returns a new data handler with a copy of all the data and placeholders.
+// changes of the copy are not visible in the origin, and the other way around
+func (d *CombinedDh) Copy() *CombinedDh {
+	dh := NewCombinedDataHandler()
+	dh.openBracket = d.openBracket
+	dh.closeBracket = d.closeBracket
+	dh.inBracketSeperator = d.inBracketSeperator
+	dh.logger = d.logger
+	dh.yamcRoot.SetData(copyDataMap(d.yamcRoot.GetData()))
+	for key, ymc := range d.yamcHndl {
+		dh.getYamcByKey(key).SetData(copyDataMap(ymc.GetData()))
+	}
+	return dh
+}
+
+// copyDataMap creates a deep copy of the map
+func copyDataMap(data map[string]interface{}) map[string]interface{} {
+	return normalizeDataValue(data).(map[string]interface{})
+}
+
 func (d *CombinedDh) SetLogger(logger mimiclog.Logger) {
 	d.logger = logger
 }
@@ -434,3 +457,110 @@ func (d *CombinedDh) GetDataKeys() []string {
 	}
 	return keys
 }
+
+// GetDataMapKeys returns the keys of the imported data, like importJson or importYamlFile are using.
+// other than GetDataKeys, they are not the keys of the placeholders
+func (d *CombinedDh) GetDataMapKeys() []string {
+	keys := make([]string, 0, len(d.yamcHndl))
+	for k := range d.yamcHndl {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GetValueByPath returns the value of the path in the data of the key.
+// the path is separated by dots. numeric parts are the index of an array, like services.web.ports.0
+func (d *CombinedDh) GetValueByPath(key, path string) (interface{}, error) {
+	if !d.ifKeyExists(key) {
+		return nil, errors.New("the key [" + key + "] does not exists")
+	}
+	return getDataByPath(d.getYamcByKey(key).GetData(), splitDataPath(path))
+}
+
+// SetValueByPath sets the value of the path in the data of the key.
+// other than SetJSONValueByPath, the type of the value is kept. missing maps are created.
+func (d *CombinedDh) SetValueByPath(key, path string, value interface{}) error {
+	ymc := d.getYamcByKey(key)
+	data, err := setDataByPath(ymc.GetData(), splitDataPath(path), normalizeDataValue(value))
+	if err != nil {
+		return err
+	}
+	return d.setRootData(ymc, data)
+}
+
+// DeleteByPath removes the entry of the path in the data of the key
+func (d *CombinedDh) DeleteByPath(key, path string) error {
+	if !d.ifKeyExists(key) {
+		return errors.New("the key [" + key + "] does not exists")
+	}
+	ymc := d.getYamcByKey(key)
+	data, err := deleteDataByPath(ymc.GetData(), splitDataPath(path))
+	if err != nil {
+		return err
+	}
+	return d.setRootData(ymc, data)
+}
+
+// AppendByPath appends the value to the array of the path. if the path not exists, the array is created.
+func (d *CombinedDh) AppendByPath(key, path string, value interface{}) error {
+	current, err := d.GetValueByPath(key, path)
+	if err != nil {
+		current = nil
+	}
+	var list []interface{}
+	switch v := current.(type) {
+	case nil:
+		list = []interface{}{}
+	case []interface{}:
+		list = v
+	default:
+		return fmt.Errorf("can not append to %s. the value is not an array", path)
+	}
+	return d.SetValueByPath(key, path, append(list, normalizeDataValue(value)))
+}
+
+// MergeData merges the data of the source key into the data of the target key.
+// maps are merged recursive. any other value of the source replaces the value of the target.
+func (d *CombinedDh) MergeData(target, source string) error {
+	if !d.ifKeyExists(source) {
+		return errors.New("the key [" + source + "] does not exists")
+	}
+	ymc := d.getYamcByKey(target)
+	return d.setRootData(ymc, mergeData(ymc.GetData(), normalizeDataValue(d.getYamcByKey(source).GetData())))
+}
+
+// SaveData writes the data of the key to the file. the format is json or yaml.
+// if the format is empty, it is taken from the extension of the file.
+func (d *CombinedDh) SaveData(key, filename, format string) error {
+	if !d.ifKeyExists(key) {
+		return errors.New("the key [" + key + "] does not exists")
+	}
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(filename)), ".")
+	}
+	var reader yamc.DataReader
+	switch format {
+	case "json":
+		reader = yamc.NewJsonReader()
+	case "yaml", "yml":
+		reader = yamc.NewYamlReader()
+	default:
+		return errors.New("unsupported format [" + format + "]. use json or yaml")
+	}
+	content, err := d.getYamcByKey(key).ToString(reader)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, []byte(content), 0644)
+}
+
+// setRootData stores the changed data. the root of the data have to be a map.
+func (d *CombinedDh) setRootData(ymc *yamc.Yamc, data interface{}) error {
+	root, ok := toStringMap(data)
+	if !ok {
+		return fmt.Errorf("the data must be a map, got %T", data)
+	}
+	ymc.SetData(root)
+	return nil
+}
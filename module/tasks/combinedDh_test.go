@@ -367,3 +367,30 @@ func TestAppendTpPhWithAsync(t *testing.T) {
 		t.Error("notexisting should not exist")
 	}
 }
+
+func TestCombinedDhCopy(t *testing.T) {
+	cdh := tasks.NewCombinedDataHandler()
+	cdh.SetPH("name", "origin")
+	if err := cdh.AddJSON("config", `{"service": {"ports": [80, 443]}}`); err != nil {
+		t.Fatal(err)
+	}
+
+	cp := cdh.Copy()
+	cp.SetPH("name", "copy")
+	if err := cp.SetValueByPath("config", "service.ports.0", 8080); err != nil {
+		t.Fatal(err)
+	}
+
+	if val := cdh.GetPH("name"); val != "origin" {
+		t.Error("the placeholder of the origin should not be changed. got", val)
+	}
+	if val := cp.GetPH("name"); val != "copy" {
+		t.Error("the placeholder of the copy should be changed. got", val)
+	}
+	if val, err := cdh.GetValueByPath("config", "service.ports.0"); err != nil || val != float64(80) {
+		t.Errorf("the data of the origin should not be changed. got %v %v", val, err)
+	}
+	if val, err := cp.GetValueByPath("config", "service.ports.0"); err != nil || val != 8080 {
+		t.Errorf("the data of the copy should be changed. got %v %v", val, err)
+	}
+}
@@ -0,0 +1,253 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// splitDataPath splits the path by dots. an empty path is the whole document.
+// numeric parts are used as index for arrays.
+func splitDataPath(path string) []string {
+	path = strings.Trim(path, ".")
+	if path == "" {
+		return []string{}
+	}
+	return strings.Split(path, ".")
+}
+
+// dataIndex returns the index of the array for the part of a path
+func dataIndex(part string, length int, allowAppend bool) (int, error) {
+	index, err := strconv.Atoi(part)
+	if err != nil {
+		return 0, fmt.Errorf("%s is not a valid index of an array", part)
+	}
+	max := length - 1
+	if allowAppend {
+		max = length
+	}
+	if index < 0 || index > max {
+		return 0, fmt.Errorf("index %d is out of range. the array has %d entries", index, length)
+	}
+	return index, nil
+}
+
+// getDataByPath returns the value of the path
+func getDataByPath(data interface{}, keys []string) (interface{}, error) {
+	for i, key := range keys {
+		switch v := data.(type) {
+		case map[string]interface{}:
+			value, ok := v[key]
+			if !ok {
+				return nil, fmt.Errorf("path %s not found", strings.Join(keys[:i+1], "."))
+			}
+			data = value
+		case map[interface{}]interface{}:
+			value, ok := v[key]
+			if !ok {
+				return nil, fmt.Errorf("path %s not found", strings.Join(keys[:i+1], "."))
+			}
+			data = value
+		case []interface{}:
+			index, err := dataIndex(key, len(v), false)
+			if err != nil {
+				return nil, err
+			}
+			data = v[index]
+		default:
+			return nil, fmt.Errorf("path %s not found. %s is not a map or an array", strings.Join(keys[:i+1], "."), strings.Join(keys[:i], "."))
+		}
+	}
+	return data, nil
+}
+
+// setDataByPath sets the value of the path, and returns the changed data.
+// missing maps are created. an array can be extended by the index, they is one above the last one.
+func setDataByPath(data interface{}, keys []string, value interface{}) (interface{}, error) {
+	if len(keys) == 0 {
+		return value, nil
+	}
+	key, rest := keys[0], keys[1:]
+	switch v := data.(type) {
+	case nil:
+		child, err := setDataByPath(nil, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{key: child}, nil
+	case map[string]interface{}:
+		child, err := setDataByPath(v[key], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		v[key] = child
+		return v, nil
+	case map[interface{}]interface{}:
+		child, err := setDataByPath(v[key], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		v[key] = child
+		return v, nil
+	case []interface{}:
+		index, err := dataIndex(key, len(v), true)
+		if err != nil {
+			return nil, err
+		}
+		if index == len(v) {
+			v = append(v, nil)
+		}
+		child, err := setDataByPath(v[index], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		v[index] = child
+		return v, nil
+	default:
+		return nil, fmt.Errorf("can not set %s. the value %v is not a map or an array", key, data)
+	}
+}
+
+// deleteDataByPath removes the entry of the path, and returns the changed data
+func deleteDataByPath(data interface{}, keys []string) (interface{}, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("the path to delete is empty")
+	}
+	key, rest := keys[0], keys[1:]
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if _, ok := v[key]; !ok {
+			return nil, fmt.Errorf("path %s not found", key)
+		}
+		if len(rest) == 0 {
+			delete(v, key)
+			return v, nil
+		}
+		child, err := deleteDataByPath(v[key], rest)
+		if err != nil {
+			return nil, err
+		}
+		v[key] = child
+		return v, nil
+	case map[interface{}]interface{}:
+		if _, ok := v[key]; !ok {
+			return nil, fmt.Errorf("path %s not found", key)
+		}
+		if len(rest) == 0 {
+			delete(v, key)
+			return v, nil
+		}
+		child, err := deleteDataByPath(v[key], rest)
+		if err != nil {
+			return nil, err
+		}
+		v[key] = child
+		return v, nil
+	case []interface{}:
+		index, err := dataIndex(key, len(v), false)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) == 0 {
+			return append(v[:index], v[index+1:]...), nil
+		}
+		child, err := deleteDataByPath(v[index], rest)
+		if err != nil {
+			return nil, err
+		}
+		v[index] = child
+		return v, nil
+	default:
+		return nil, fmt.Errorf("path %s not found", key)
+	}
+}
+
+// mergeData merges the source into the target. maps are merged recursive,
+// any other value of the source replaces the value of the target.
+func mergeData(target, source interface{}) interface{} {
+	sourceMap, sourceIsMap := toStringMap(source)
+	targetMap, targetIsMap := toStringMap(target)
+	if !sourceIsMap || !targetIsMap {
+		return source
+	}
+	for key, value := range sourceMap {
+		if current, ok := targetMap[key]; ok {
+			targetMap[key] = mergeData(current, value)
+		} else {
+			targetMap[key] = value
+		}
+	}
+	return targetMap
+}
+
+func toStringMap(data interface{}) (map[string]interface{}, bool) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		return v, true
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			result[fmt.Sprint(key)] = value
+		}
+		return result, true
+	default:
+		return nil, false
+	}
+}
+
+// normalizeDataValue converts the maps of anko to maps with string keys,
+// so they can be written as json too
+func normalizeDataValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			result[fmt.Sprint(key)] = normalizeDataValue(val)
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			result[key] = normalizeDataValue(val)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, val := range v {
+			result[i] = normalizeDataValue(val)
+		}
+		return result
+	case []string:
+		result := make([]interface{}, len(v))
+		for i, val := range v {
+			result[i] = val
+		}
+		return result
+	default:
+		return value
+	}
+}
@@ -40,16 +40,29 @@ type TaskListExec struct {
 	args                   []interface{}
 	logger                 mimiclog.Logger
 	presetHardExistOnError bool
+	presetHermetic         bool
+	presetOutputMode       string
+	presetLiveErrors       bool
 }
 
 func NewTaskListExec(config configure.RunConfig, adds ...interface{}) *TaskListExec {
 	return &TaskListExec{
 		config: config,
 		watch:  NewGlobalWatchman(),
-		args:   adds,
+		args:   withAnkoModules(adds),
 	}
 }
 
+// withAnkoModules makes sure all targets of the execution share the same anko modules
+func withAnkoModules(adds []interface{}) []interface{} {
+	for _, add := range adds {
+		if _, ok := add.(*AnkoModules); ok {
+			return adds
+		}
+	}
+	return append(adds, NewDefaultAnkoModules(""))
+}
+
 func NewStdTaskListExec(config configure.RunConfig, adds ...interface{}) *TaskListExec {
 	dmc := NewCombinedDataHandler()
 	req := NewDefaultRequires(dmc, mimiclog.NewNullLogger())
@@ -61,7 +74,7 @@ func NewStdTaskListExec(config configure.RunConfig, adds ...interface{}) *TaskLi
 	return &TaskListExec{
 		config: config,
 		watch:  NewGlobalWatchman(),
-		args:   adds,
+		args:   withAnkoModules(adds),
 	}
 }
 
@@ -75,7 +88,30 @@ func (e *TaskListExec) RunTargetWithVars(target string, scopeVars map[string]str
 	if tExec == nil {
 		return systools.ExitByNoTargetExists
 	}
-	return tExec.executeTemplate(async, target, scopeVars)
+	// any run starts with an empty set of outputs
+	tExec.outputs = NewTaskOutputs()
+	code := tExec.executeTemplate(async, tExec.target, scopeVars)
+	// the stop and restart actions of the listeners are running in the background
+	tExec.waitForPendingActions()
+	return code
+}
+
+// ResolveTargetAlias returns the task id, if the target is an alias of a task.
+// if the target is already a task id, or no alias matches, the target is returned as it is.
+func ResolveTargetAlias(config configure.RunConfig, target string) string {
+	for _, task := range config.Task {
+		if task.ID == target {
+			return target
+		}
+	}
+	for _, task := range config.Task {
+		for _, alias := range task.Aliases {
+			if strings.TrimSpace(alias) == target {
+				return task.ID
+			}
+		}
+	}
+	return target
 }
 
 func (e *TaskListExec) SetLogger(logger mimiclog.Logger) {
@@ -97,11 +133,13 @@ func (e *TaskListExec) GetWatch() *Watchman {
 
 // findOrCreateTask returns the taskExecuter for the given target.
 // if the task is not found, it will be created.
+// the target can also be an alias of the task.
 func (e *TaskListExec) findOrCreateTask(target string, scopeVars map[string]string) *targetExecuter {
 	// first create the tasklist if not exists
 	if e.subTasks == nil {
 		e.subTasks = make(map[string]*targetExecuter)
 	}
+	target = ResolveTargetAlias(e.config, target)
 	// check if the task is already created
 	tExec, found := e.subTasks[target]
 	if e.logger != nil {
@@ -114,6 +152,9 @@ func (e *TaskListExec) findOrCreateTask(target string, scopeVars map[string]stri
 				tExec = New(target, scopeVars, e.args...)
 				// take the preset also for any new task
 				tExec.SetHardExitOnError(e.presetHardExistOnError)
+				tExec.SetHermetic(e.presetHermetic)
+				tExec.SetOutputMode(e.presetOutputMode)
+				tExec.SetOutputLiveErrors(e.presetLiveErrors)
 				e.subTasks[target] = tExec // add the task to the tasklist
 				if e.logger != nil {       // if we have a logger, we will set it to the task
 					tExec.SetLogger(e.logger)
@@ -131,6 +172,33 @@ func (e *TaskListExec) SetHardExistToAllTasks(exitOnErr bool) {
 	}
 }
 
+// SetOutputModeToAllTasks sets the output mode (grouped or stream) for all tasks.
+// an empty mode means, the output mode of the task is used.
+func (e *TaskListExec) SetOutputModeToAllTasks(mode string) {
+	e.presetOutputMode = mode
+	for _, task := range e.subTasks {
+		task.SetOutputMode(mode)
+	}
+}
+
+// SetOutputLiveErrorsToAllTasks prints errors at once in grouped mode for all tasks,
+// even if the task itself did not enable it.
+func (e *TaskListExec) SetOutputLiveErrorsToAllTasks(liveErrors bool) {
+	e.presetLiveErrors = liveErrors
+	for _, task := range e.subTasks {
+		task.SetOutputLiveErrors(liveErrors)
+	}
+}
+
+// SetHermeticToAllTasks runs any task in hermetic mode, even if the task
+// itself did not enable it.
+func (e *TaskListExec) SetHermeticToAllTasks(hermetic bool) {
+	e.presetHermetic = hermetic
+	for _, task := range e.subTasks {
+		task.SetHermetic(hermetic)
+	}
+}
+
 func (t *targetExecuter) verifiedKeyname(keyName string) (string, bool) {
 	// just trim spaces
 	keyName = strings.TrimSpace(keyName)
@@ -157,7 +225,8 @@ func (t *targetExecuter) splitTargetsNeedsByWorkingDir(taskList []configure.Task
 	allNeeds := make(map[string]configure.Task, 0)
 
 	for _, task := range taskList {
-		for _, need := range task.Needs {
+		localNeeds, _ := splitProjectNeeds(task.Needs)
+		for _, need := range localNeeds {
 			tasksForNeed := t.getTargetTasks(need)
 			for _, taskForNeed := range *tasksForNeed {
 				allNeeds[taskForNeed.ID] = taskForNeed
@@ -215,6 +284,7 @@ func (t *targetExecuter) runAsyncTargets(target string, displayCmd bool, runTese
 
 func (t *targetExecuter) getTargetTasks(name string) *[]configure.Task {
 	tasks := make([]configure.Task, 0)
+	name = ResolveTargetAlias(t.runCfg, name)
 	for _, task := range t.runCfg.Task {
 		if task.ID == name {
 			tasks = append(tasks, task)
@@ -223,7 +293,7 @@ func (t *targetExecuter) getTargetTasks(name string) *[]configure.Task {
 	return &tasks
 }
 
-func (t *targetExecuter) executeTemplate(runAsync bool, target string, scopeVars map[string]string) int {
+func (t *targetExecuter) executeTemplate(runAsync bool, target string, scopeVars map[string]string) (exitCode int) {
 
 	// check the version of the task
 	if !t.verifyVersion() {
@@ -240,6 +310,8 @@ func (t *targetExecuter) executeTemplate(runAsync bool, target string, scopeVars
 
 	// just trim spaces
 	target = strings.TrimSpace(target)
+	// the target can be an alias. like in needs or runTargets
+	target = ResolveTargetAlias(t.runCfg, target)
 
 	if t == nil {
 		panic("targetExecuter is nil. This should not happen. init it with New()")
@@ -257,6 +329,12 @@ func (t *targetExecuter) executeTemplate(runAsync bool, target string, scopeVars
 		return systools.ExitAlreadyRunning
 	}
 
+	// after an interrupt, no new targets are started
+	if t.isStoppedByInterrupt() {
+		t.getLogger().Info("target is not started because of an interrupt", target)
+		return systools.ExitByInterrupt
+	}
+
 	// increment task counter
 	t.watch.IncTaskCount(target)
 	defer t.watch.IncTaskDoneCount(target) // save done count at then end
@@ -316,6 +394,15 @@ func (t *targetExecuter) executeTemplate(runAsync bool, target string, scopeVars
 				}
 			}*/
 
+		// the lock of the target, if any task asks for it.
+		// it is held until the whole target is done
+		var lock *targetLock
+
+		// in grouped mode the output of the target is printed, if the target is done
+		if group := t.startOutputGroup(target, taskList); group != nil {
+			defer func() { t.finishOutputGroup(group, exitCode) }()
+		}
+
 		// check if we have found the target
 		taskCount := len(taskList)
 		for curTIndex, script := range taskList {
@@ -333,7 +420,7 @@ func (t *targetExecuter) executeTemplate(runAsync bool, target string, scopeVars
 				}*/
 
 			// check requirements
-			canRun, message := t.checkRequirements(script.Requires)
+			canRun, message := t.checkRequirements(script.Requires, t.resolveWorkingDir(&script))
 			if !canRun {
 				logFields := mimiclog.Fields{
 					"target": target,
@@ -358,6 +445,43 @@ func (t *targetExecuter) executeTemplate(runAsync bool, target string, scopeVars
 				}
 			}
 
+			// ask for any variable they is required but not set
+			if err := t.resolvePrompts(script); err != nil {
+				t.getLogger().Error("executeTemplate can not resolve prompts", err)
+				t.out(MsgError(MsgError{Err: err, Reference: "prompts", Target: target}))
+				return systools.ExitByMissingInput
+			}
+
+			// lock the target across processes, before anything is executed
+			if lock == nil {
+				newLock, code := t.acquireTargetLock(target, script.Options)
+				if code != systools.ExitOk {
+					return code
+				}
+				if newLock != nil {
+					lock = newLock
+					defer lock.release()
+				}
+			}
+
+			// the output filters are verified before any command is executed
+			if err := verifyOutputFilters(script); err != nil {
+				t.out(MsgError(MsgError{Err: err, Reference: "outputFilters", Target: target}))
+				return systools.ErrorTemplate
+			}
+
+			// hermetic tasks are running in a controlled environment.
+			// the sandbox is removed if the target is done
+			if t.isHermetic(script) {
+				cleanup, err := t.prepareHermetic(&script)
+				if err != nil {
+					t.getLogger().Error("can not prepare the hermetic environment", err)
+					t.out(MsgError(MsgError{Err: err, Reference: "hermetic", Target: target}))
+					return systools.ErrorBySystem
+				}
+				defer cleanup()
+			}
+
 			// just the abort flag.
 			abort := false
 
@@ -371,6 +495,13 @@ func (t *targetExecuter) executeTemplate(runAsync bool, target string, scopeVars
 					t.out(MsgTarget{Target: target, Context: "needs_required", Info: strings.Join(script.Needs, ",")}, MsgArgs(script.Needs))
 				}
 				t.getLogger().Debug("Needs for the script", script.Needs)
+				localNeeds, projectNeeds := splitProjectNeeds(script.Needs)
+				// needs from other projects are running first. if one of them fails, we stop here
+				if len(projectNeeds) > 0 {
+					if code := t.runProjectNeeds(target, script.Options.Displaycmd, projectNeeds); code != systools.ExitOk {
+						return code
+					}
+				}
 				// check if we have to run the needs in threads or not
 				if runAsync {
 					// first we need to get any target, that is executed in a different path, so we can run them in threads at first
@@ -394,7 +525,7 @@ func (t *targetExecuter) executeTemplate(runAsync bool, target string, scopeVars
 					// so there is no syncronisation needed for this part of needs,
 					// but others can be run in parallel the same needs, so we still need
 					// to use the watchman to check if the task is already running
-					for _, syncTarget := range script.Needs {
+					for _, syncTarget := range localNeeds {
 						if !t.watch.TryCreate(syncTarget) {
 							// task is already registered, so we will not do it
 							t.getLogger().Debug("need already handled " + syncTarget)
@@ -424,7 +555,7 @@ func (t *targetExecuter) executeTemplate(runAsync bool, target string, scopeVars
 			// 'now' listener
 			if len(script.Script) < 1 && len(script.Cmd) < 1 {
 				t.getLogger().Debug("no script lines defined. run listener anyway")
-				t.listenerWatch("", nil, &script)
+				t.listenerWatch(OutputEvent("", nil), &script)
 				// workaround til the async runnig is refactored
 				// now we need to give the subtask time to run and update the waitgroup
 				// UPDATE: set from 1 second to 15 milliseconds
@@ -442,18 +573,36 @@ func (t *targetExecuter) executeTemplate(runAsync bool, target string, scopeVars
 				}
 			}
 
-			// preparing codelines by execute second level commands
-			// that can affect the whole script
-			abort, returnCode, _ = t.TryParse(script.Script, func(codeLine string) (bool, int) {
-				lineAbort, lineExitCode := t.targetTaskExecuter(codeLine, script, t.watch)
-				return lineExitCode, lineAbort
-			})
+			if isScriptFileMode(script) {
+				// the whole script is executed at once
+				abort, returnCode = t.runScriptAsFile(script, t.watch)
+			} else {
+				// preparing codelines by execute second level commands
+				// that can affect the whole script
+				abort, returnCode, _ = t.TryParse(script.Script, func(codeLine string) (bool, int) {
+					lineAbort, lineExitCode := t.targetTaskExecuter(codeLine, script, t.watch)
+					return lineExitCode, lineAbort
+				})
+			}
 			if abort {
 				t.getLogger().Debug("abort reason found, or execution failed")
 				// if we have a return code, we need to return it
 				if returnCode == systools.ErrorCheatMacros {
 					return returnCode
 				}
+			} else if len(script.Outputs) > 0 {
+				// the outputs are provided to the tasks they need this task
+				if err := t.captureOutputs(script); err != nil {
+					t.getLogger().Error("can not capture the outputs", err)
+					t.out(MsgError(MsgError{Err: err, Reference: "outputs", Target: target}))
+					return systools.ExitCmdError
+				}
+			}
+
+			// the task is interrupted. so we run the cleanup target and stop here
+			if t.isStoppedByInterrupt() {
+				t.runOnInterrupt(target, script)
+				return systools.ExitByInterrupt
 			}
 
 			// waitin until the any target that runns also is done
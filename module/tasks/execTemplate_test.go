@@ -1,6 +1,7 @@
 package tasks_test
 
 import (
+	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -2053,3 +2054,135 @@ func TestWorkingDirSplit(t *testing.T) {
 		t.Error("expected no errors but got", len(logger.errors))
 	}
 }
+
+func TestScriptFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("bash specific script")
+	}
+	runConfig := configure.RunConfig{
+		Config: configure.Config{
+			Sequencially: true,
+			Variables: map[string]string{
+				"GREET": "hello",
+			},
+		},
+		Task: []configure.Task{
+			{
+				ID: "filemode",
+				Options: configure.Options{
+					ScriptMode: tasks.ScriptModeFile,
+				},
+				Script: []string{
+					"say() {",
+					"  echo \"${GREET} $1\"",
+					"}",
+					"cd testdata",
+					"#@if-equals ${GREET} hello",
+					"say world",
+					"#@end",
+					"#@if-equals ${GREET} bye",
+					"say nobody",
+					"#@end",
+					"if [ -d ../testdata ]; then",
+					"  echo \"in testdata\"",
+					"fi",
+				},
+			},
+		},
+	}
+	RunTargetHelperWithErrors(
+		t, "filemode", runConfig, false, systools.ExitOk, 2, []string{"hello world", "in testdata"}, true,
+	)
+}
+
+func TestScriptFileModeExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("bash specific script")
+	}
+	runConfig := configure.RunConfig{
+		Config: configure.Config{
+			Sequencially: true,
+		},
+		Task: []configure.Task{
+			{
+				ID: "filemode",
+				Options: configure.Options{
+					ScriptMode: tasks.ScriptModeFile,
+				},
+				Script: []string{
+					"echo first",
+					"exit 3",
+					"echo never",
+				},
+			},
+		},
+	}
+	RunTargetHelperWithErrors(
+		t, "filemode", runConfig, true, systools.ExitCmdError, 0, []string{}, true,
+	)
+}
+
+// the script file is passed to the shell as argument. so it does not need to be executable,
+// and the temp dir can be mounted with noexec
+func TestScriptFileModeNotExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("bash specific script")
+	}
+	runConfig := configure.RunConfig{
+		Config: configure.Config{
+			Sequencially: true,
+		},
+		Task: []configure.Task{
+			{
+				ID: "filemode",
+				Options: configure.Options{
+					ScriptMode: tasks.ScriptModeFile,
+					Maincmd:    "bash",
+				},
+				Script: []string{
+					"if [ -x \"$0\" ]; then",
+					"  echo executable",
+					"else",
+					"  echo not executable",
+					"fi",
+				},
+			},
+		},
+	}
+	RunTargetHelperWithErrors(
+		t, "filemode", runConfig, false, systools.ExitOk, 1, []string{"not executable"}, true,
+	)
+}
+
+func TestTargetAlias(t *testing.T) {
+	runConfig := configure.RunConfig{
+		Config: configure.Config{
+			Sequencially: true,
+		},
+		Task: []configure.Task{
+			{
+				ID:      "build-integration",
+				Aliases: []string{"bi"},
+				Script: []string{
+					"echo build-integration",
+				},
+			},
+			{
+				ID:    "main",
+				Needs: []string{"bi"},
+				Script: []string{
+					"echo main",
+				},
+			},
+		},
+	}
+	RunTargetHelperWithErrors(
+		t, "bi", runConfig, false, systools.ExitOk, 1, []string{"build-integration"}, true,
+	)
+	RunTargetHelperWithErrors(
+		t, "main", runConfig, false, systools.ExitOk, 2, []string{"build-integration", "main"}, true,
+	)
+	assertStrEqual(t, "build-integration", tasks.ResolveTargetAlias(runConfig, "bi"))
+	assertStrEqual(t, "main", tasks.ResolveTargetAlias(runConfig, "main"))
+	assertStrEqual(t, "unknown", tasks.ResolveTargetAlias(runConfig, "unknown"))
+}
@@ -23,6 +23,7 @@ package tasks
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/swaros/contxt/module/configure"
 	"github.com/swaros/contxt/module/mimiclog"
@@ -33,20 +34,32 @@ var (
 )
 
 type targetExecuter struct {
-	target          string
-	arguments       map[string]string
-	runCfg          configure.RunConfig
-	mainCmd         string
-	mainCmdArgs     []string
-	phHandler       PlaceHolder
-	outputHandler   func(msg ...interface{})
-	requireHandler  Requires
-	Logger          mimiclog.Logger
-	dataHandler     DataMapHandler
-	watch           *Watchman
-	commandFallback MainCmdSetter
-	hardExitOnError bool
-	rootPath        string // this is the root path of the executer
+	target           string
+	arguments        map[string]string
+	runCfg           configure.RunConfig
+	mainCmd          string
+	mainCmdArgs      []string
+	phHandler        PlaceHolder
+	outputHandler    func(msg ...interface{})
+	requireHandler   Requires
+	Logger           mimiclog.Logger
+	dataHandler      DataMapHandler
+	watch            *Watchman
+	commandFallback  MainCmdSetter
+	hardExitOnError  bool
+	rootPath         string              // this is the root path of the executer
+	prompter         Prompter            // used to ask for missing variables
+	projectRunner    ProjectTargetRunner // used to run needs from other projects
+	outputs          *TaskOutputs        // the outputs of the tasks for the current execution
+	interruptCleanup bool                // runs an onInterrupt target, so it is not stopped by the interrupt
+	triggerAction    bool                // runs the script of a listener action. the exit code and duration are not checked again
+	forceHermetic    bool                // any task runs hermetic. like the hermetic option is set for all tasks
+	forceOutputMode  string              // the output mode for all tasks. if empty, the mode of the task is used
+	forceLiveErrors  bool                // errors are printed at once in grouped mode, like outputLiveErrors is set for any task
+	outGroups        *outputGroups       // the buffered output of the targets they running in grouped mode
+	ankoModules      *AnkoModules        // the modules they can be imported by the anko commands
+	riskPolicy       *RiskPolicy         // the allowed risk level of the anko functions, depending on the source of the task
+	pendingActions   *sync.WaitGroup     // the stop and restart actions of the listeners, they are running in the background
 }
 
 type emptyCmd struct{}
@@ -96,6 +109,14 @@ func New(target string, arguments map[string]string, any ...interface{}) *target
 			t.watch = any[i].(*Watchman)
 		case MainCmdSetter:
 			t.commandFallback = any[i].(MainCmdSetter)
+		case Prompter:
+			t.prompter = any[i].(Prompter)
+		case ProjectTargetRunner:
+			t.projectRunner = any[i].(ProjectTargetRunner)
+		case *AnkoModules:
+			t.ankoModules = any[i].(*AnkoModules)
+		case *RiskPolicy:
+			t.riskPolicy = any[i].(*RiskPolicy)
 		default:
 			// print out the type of the given argument
 			// so we can see what is wrong
@@ -120,6 +141,25 @@ func (t *targetExecuter) SetHardExitOnError(hardExit bool) *targetExecuter {
 	return t
 }
 
+func (t *targetExecuter) SetHermetic(hermetic bool) *targetExecuter {
+	t.forceHermetic = hermetic
+	return t
+}
+
+// SetOutputMode sets the output mode for all tasks. like grouped or stream.
+// an empty mode means, the output mode of the task is used
+func (t *targetExecuter) SetOutputMode(mode string) *targetExecuter {
+	t.forceOutputMode = mode
+	return t
+}
+
+// SetOutputLiveErrors prints errors at once in grouped mode for all tasks.
+// if false, the outputLiveErrors option of the task is used
+func (t *targetExecuter) SetOutputLiveErrors(liveErrors bool) *targetExecuter {
+	t.forceLiveErrors = liveErrors
+	return t
+}
+
 func (t *targetExecuter) SetMainCmd(mainCmd string, args ...string) *targetExecuter {
 	t.mainCmd = mainCmd
 	t.mainCmdArgs = args
@@ -138,6 +178,23 @@ func (t *targetExecuter) reInitialize() {
 		t.commandFallback = emptyMainCmdSetter
 		t.getLogger().Warn("No MainCmdSetter provided, using empty fallback")
 	}
+	// the outputs are only shared between the tasks of the same execution
+	if t.outputs == nil {
+		t.outputs = NewTaskOutputs()
+	}
+	if t.outGroups == nil {
+		t.outGroups = newOutputGroups()
+	}
+	// the anko modules of the project in the current directory, if nothing else is set
+	if t.ankoModules == nil {
+		t.ankoModules = NewDefaultAnkoModules("")
+	}
+	if t.riskPolicy == nil {
+		t.riskPolicy = NewDefaultRiskPolicy()
+	}
+	if t.pendingActions == nil {
+		t.pendingActions = &sync.WaitGroup{}
+	}
 	// if no task watcher is set, we create a new one
 	if t.watch == nil {
 		t.watch = NewGlobalWatchman()
@@ -159,6 +216,16 @@ func (t *targetExecuter) CopyToTarget(target string) *targetExecuter {
 		t.watch,
 		t.commandFallback,
 	)
+	copy.prompter = t.prompter
+	copy.projectRunner = t.projectRunner
+	copy.outputs = t.outputs
+	copy.forceHermetic = t.forceHermetic
+	copy.forceOutputMode = t.forceOutputMode
+	copy.forceLiveErrors = t.forceLiveErrors
+	copy.outGroups = t.outGroups
+	copy.ankoModules = t.ankoModules
+	copy.riskPolicy = t.riskPolicy
+	copy.pendingActions = t.pendingActions
 
 	return copy
 }
@@ -181,6 +248,16 @@ func (t *targetExecuter) SetPlaceholderHandler(handler PlaceHolder) *targetExecu
 	return t
 }
 
+func (t *targetExecuter) SetPrompter(prompter Prompter) *targetExecuter {
+	t.prompter = prompter
+	return t
+}
+
+func (t *targetExecuter) SetProjectRunner(runner ProjectTargetRunner) *targetExecuter {
+	t.projectRunner = runner
+	return t
+}
+
 func (t *targetExecuter) SetWatchman(watch *Watchman) *targetExecuter {
 	t.watch = watch
 	return t
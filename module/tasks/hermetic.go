@@ -0,0 +1,135 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	cp "github.com/otiai10/copy"
+	"github.com/swaros/contxt/module/configure"
+)
+
+// HermeticEnvAllowed are the environment variables, they are always passed to hermetic tasks
+var HermeticEnvAllowed = []string{"PATH", "HOME"}
+
+// on windows, commands will not work without these variables
+var hermeticWindowsEnv = []string{"SystemRoot", "ComSpec", "PATHEXT", "USERPROFILE"}
+
+// isHermetic returns true if the commands of the task have to run in a controlled environment
+func (t *targetExecuter) isHermetic(task configure.Task) bool {
+	return t.forceHermetic || task.Options.Hermetic
+}
+
+// commandEnv returns the environment for the commands of the task.
+// nil means, the commands are using the environment of contxt.
+// hermetic tasks are getting only the allowed variables and the env of the task.
+func (t *targetExecuter) commandEnv(task configure.Task) []string {
+	hermetic := t.isHermetic(task)
+	if !hermetic && len(task.Env) == 0 {
+		return nil
+	}
+	var env []string
+	if hermetic {
+		allowed := append([]string{}, HermeticEnvAllowed...)
+		if runtime.GOOS == "windows" {
+			allowed = append(allowed, hermeticWindowsEnv...)
+		}
+		allowed = append(allowed, task.Options.EnvAllow...)
+		for _, name := range allowed {
+			if value, ok := os.LookupEnv(name); ok {
+				env = append(env, name+"="+value)
+			}
+		}
+	} else {
+		env = os.Environ()
+	}
+	// the declared variables of the task. they overwrite the allowed variables,
+	// because the last entry wins.
+	for name, value := range task.Env {
+		if t.phHandler != nil {
+			value = t.phHandler.HandlePlaceHolder(value)
+		}
+		env = append(env, name+"="+value)
+	}
+	return env
+}
+
+// prepareHermetic creates the sandbox for a hermetic task.
+// the sandbox contains a private temp dir and optional a copy of the working dir.
+// the task is updated, so the commands are using them.
+// the returned function removes the sandbox.
+func (t *targetExecuter) prepareHermetic(task *configure.Task) (func(), error) {
+	sandbox, err := os.MkdirTemp("", "contxt-hermetic-")
+	if err != nil {
+		return nil, err
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(sandbox); err != nil {
+			t.getLogger().Warn("can not remove the hermetic sandbox", sandbox, err)
+		}
+	}
+
+	tmpDir := filepath.Join(sandbox, "tmp")
+	if err := os.Mkdir(tmpDir, 0o700); err != nil {
+		cleanup()
+		return nil, err
+	}
+	// copy the env, so we do not change the env of the task in the config
+	env := map[string]string{}
+	for name, value := range task.Env {
+		env[name] = value
+	}
+	env["TMPDIR"] = tmpDir
+	if runtime.GOOS == "windows" {
+		env["TMP"] = tmpDir
+		env["TEMP"] = tmpDir
+	}
+	task.Env = env
+
+	if task.Options.HermeticCopy {
+		workingDir, err := t.workingDir(task)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+		if workingDir == "" {
+			if workingDir, err = os.Getwd(); err != nil {
+				cleanup()
+				return nil, err
+			}
+		}
+		copyDir := filepath.Join(sandbox, "work")
+		if err := cp.Copy(workingDir, copyDir); err != nil {
+			cleanup()
+			return nil, err
+		}
+		// the working dir is absolute, so it is used as it is
+		task.Options.WorkingDir = copyDir
+	}
+	t.getLogger().Debug("hermetic sandbox created", sandbox)
+	return cleanup, nil
+}
@@ -0,0 +1,104 @@
+package tasks_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+// runs the target and returns the output of the commands
+func runHermeticTarget(t *testing.T, runCfg configure.RunConfig, target string, forceHermetic bool) []string {
+	t.Helper()
+	var mu sync.Mutex
+	outputs := []string{}
+	outHandler := func(msg ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range msg {
+			if s, ok := m.(tasks.MsgExecOutput); ok {
+				outputs = append(outputs, s.Output)
+			}
+		}
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	dmc.SetPH("PROJECT", "lina")
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, req, tasks.NewWatchman())
+	tsk.SetHardExistToAllTasks(false)
+	tsk.SetHermeticToAllTasks(forceHermetic)
+	assertIntEqual(t, systools.ExitOk, tsk.RunTarget(target, false))
+	return outputs
+}
+
+func TestHermeticEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	t.Setenv("CTX_HERMETIC_SECRET", "secret")
+	t.Setenv("CTX_HERMETIC_ALLOWED", "allowed")
+	script := []string{`echo "[$CTX_HERMETIC_SECRET][$CTX_HERMETIC_ALLOWED][$DECLARED]"`, `echo "$TMPDIR"`}
+	env := map[string]string{"DECLARED": "project ${PROJECT}"}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{ID: "hermetic", Env: env, Options: configure.Options{Hermetic: true, EnvAllow: []string{"CTX_HERMETIC_ALLOWED"}}, Script: script},
+			{ID: "regular", Env: env, Script: script},
+		},
+	}
+
+	outputs := runHermeticTarget(t, runCfg, "hermetic", false)
+	if len(outputs) != 2 {
+		t.Fatalf("expected 2 lines of output. got %v", outputs)
+	}
+	assertStringEqual(t, "[][allowed][project lina]", outputs[0])
+	if !strings.Contains(outputs[1], "contxt-hermetic-") {
+		t.Errorf("expected a private TMPDIR. got %s", outputs[1])
+	}
+	if _, err := os.Stat(outputs[1]); !os.IsNotExist(err) {
+		t.Errorf("expected the private TMPDIR is removed after the run. %s", outputs[1])
+	}
+
+	outputs = runHermeticTarget(t, runCfg, "regular", false)
+	if len(outputs) != 2 {
+		t.Fatalf("expected 2 lines of output. got %v", outputs)
+	}
+	assertStringEqual(t, "[secret][allowed][project lina]", outputs[0])
+
+	// hermetic is enforced for all tasks
+	outputs = runHermeticTarget(t, runCfg, "regular", true)
+	if len(outputs) != 2 {
+		t.Fatalf("expected 2 lines of output. got %v", outputs)
+	}
+	assertStringEqual(t, "[][][project lina]", outputs[0])
+}
+
+func TestHermeticCopy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	dir := createMarkedDir(t, "marker-a")
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID:      "build",
+				Options: configure.Options{Hermetic: true, HermeticCopy: true, WorkingDir: dir},
+				Script:  []string{"cat marker-a", "touch created.txt", "ls"},
+			},
+		},
+	}
+	outputs := runHermeticTarget(t, runCfg, "build", false)
+	all := strings.Join(outputs, "\n")
+	if !strings.Contains(all, "marker-a") || !strings.Contains(all, "created.txt") {
+		t.Errorf("expected the commands running in a copy of the working dir. got %v", outputs)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "created.txt")); !os.IsNotExist(err) {
+		t.Error("the working dir should not be changed by a hermetic copy")
+	}
+}
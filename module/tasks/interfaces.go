@@ -38,6 +38,18 @@ type DataMapHandler interface {
 	GetDataKeys() []string                                   // returns all keys
 }
 
+// DataPathHandler is the interface for data handlers, they can change the data by a path.
+// the path is separated by dots. numeric parts are the index of an array.
+type DataPathHandler interface {
+	GetValueByPath(key, path string) (interface{}, error)     // returns the value of the path
+	SetValueByPath(key, path string, value interface{}) error // sets the value of the path. the type of the value is kept
+	DeleteByPath(key, path string) error                      // removes the entry of the path
+	AppendByPath(key, path string, value interface{}) error   // appends the value to the array of the path
+	MergeData(target, source string) error                    // merges the data of the source into the target
+	SaveData(key, filename, format string) error              // writes the data to a file as json or yaml
+	GetDataMapKeys() []string                                 // returns the keys of the imported data
+}
+
 // PlaceHolder is the interface for the placeholder handler
 // they are used to store and retrieve placeholder
 type PlaceHolder interface {
@@ -59,3 +71,17 @@ type PlaceHolder interface {
 type MainCmdSetter interface {
 	GetMainCmd(cfg configure.Options) (string, []string)
 }
+
+// Prompter is the interface for asking the user for values
+// they are used to ask for missing variables
+type Prompter interface {
+	IsInteractive() bool                                      // returns true if the user can be asked
+	Input(question, defaultValue string) (string, error)      // asks for a value. the default is used if nothing is entered
+	Select(question string, choices []string) (string, error) // asks to select one of the choices
+}
+
+// ProjectTargetRunner is the interface for running targets of other projects
+// in the same workspace. they are used for needs like @api:generate-client
+type ProjectTargetRunner interface {
+	RunProjectTarget(fromProject, project, target string) int // runs the target in the path of the project and returns the exit code
+}
@@ -0,0 +1,129 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/process"
+	"github.com/swaros/contxt/module/systools"
+)
+
+// DefaultInterruptTimeout is the time the onInterrupt target can use,
+// if the task did not define the interruptTimeout option
+var DefaultInterruptTimeout = 5 * time.Second
+
+var (
+	interruptMu     sync.Mutex
+	interruptSignal os.Signal // the signal that interrupts the execution. nil if not interrupted
+	processGroups   sync.Map  // the process groups of all running script lines
+)
+
+// Interrupt marks the execution as interrupted and forwards the signal
+// to the process groups of all running script lines.
+// no new script lines are started after that, but the onInterrupt targets.
+// it returns false, if the execution was already interrupted
+func Interrupt(sig os.Signal) bool {
+	interruptMu.Lock()
+	first := interruptSignal == nil
+	interruptSignal = sig
+	interruptMu.Unlock()
+	ForwardSignal(sig)
+	return first
+}
+
+// IsInterrupted returns true if the execution is interrupted by a signal
+func IsInterrupted() bool {
+	interruptMu.Lock()
+	defer interruptMu.Unlock()
+	return interruptSignal != nil
+}
+
+// ResetInterrupt resets the interrupt state, so targets can be executed again
+func ResetInterrupt() {
+	interruptMu.Lock()
+	defer interruptMu.Unlock()
+	interruptSignal = nil
+}
+
+// ForwardSignal sends the signal to the process groups of all running script lines
+func ForwardSignal(sig os.Signal) {
+	processGroups.Range(func(key, _ interface{}) bool {
+		process.SignalProcessGroup(key.(int), sig)
+		return true
+	})
+}
+
+// trackProcessGroup registers the process group of a started script line
+func trackProcessGroup(pid int) {
+	processGroups.Store(pid, true)
+}
+
+func untrackProcessGroup(pid int) {
+	processGroups.Delete(pid)
+}
+
+// isStoppedByInterrupt returns true, if script lines of this executer have to stop,
+// because of an interrupt. the onInterrupt targets are not affected.
+func (t *targetExecuter) isStoppedByInterrupt() bool {
+	return !t.interruptCleanup && IsInterrupted()
+}
+
+// runOnInterrupt runs the onInterrupt target of the task.
+// we wait for it until the interruptTimeout is reached.
+func (t *targetExecuter) runOnInterrupt(target string, task configure.Task) {
+	if task.OnInterrupt == "" || t.interruptCleanup {
+		return
+	}
+	timeout := time.Duration(task.Options.InterruptTimeout) * time.Millisecond
+	if timeout <= 0 {
+		timeout = DefaultInterruptTimeout
+	}
+	cleanup := t.CopyToTarget(task.OnInterrupt)
+	cleanup.interruptCleanup = true
+	cleanup.SetHardExitOnError(false)
+	cleanup.SetRootPath(t.rootPath)
+	if t.Logger != nil {
+		cleanup.SetLogger(t.Logger)
+	}
+
+	t.out(MsgTarget{Target: target, Context: "interrupt_cleanup", Info: task.OnInterrupt})
+	done := make(chan int, 1)
+	go func() {
+		done <- cleanup.executeTemplate(false, task.OnInterrupt, map[string]string{})
+	}()
+	select {
+	case code := <-done:
+		if code != systools.ExitOk && code != systools.ExitByNothingToDo {
+			t.out(MsgError(MsgError{Err: fmt.Errorf("onInterrupt target %s failed with exit code %d", task.OnInterrupt, code), Reference: "onInterrupt", Target: target}))
+		}
+	case <-time.After(timeout):
+		t.out(MsgError(MsgError{Err: fmt.Errorf("onInterrupt target %s is not done after %v", task.OnInterrupt, timeout), Reference: "onInterrupt", Target: target}))
+	}
+}
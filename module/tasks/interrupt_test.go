@@ -0,0 +1,118 @@
+package tasks_test
+
+import (
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+func TestInterruptWithCleanup(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signals are not supported on windows")
+	}
+	defer tasks.ResetInterrupt()
+
+	var mu sync.Mutex
+	outputs := []string{}
+	outHandler := func(msg ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range msg {
+			if s, ok := m.(tasks.MsgExecOutput); ok {
+				outputs = append(outputs, s.Output)
+			}
+		}
+	}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{ID: "server", OnInterrupt: "cleanup", Script: []string{"echo started", "sleep 30", "echo never"}},
+			{ID: "cleanup", Script: []string{"echo cleanup done"}},
+			{ID: "slow-cleanup", Script: []string{"sleep 30"}},
+			{ID: "stuck", OnInterrupt: "slow-cleanup", Options: configure.Options{InterruptTimeout: 200}, Script: []string{"sleep 30"}},
+		},
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, req, tasks.NewWatchman())
+	tsk.SetHardExistToAllTasks(true) // an interrupt must not be handled as an error
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		if !tasks.Interrupt(os.Interrupt) {
+			t.Error("expected the first interrupt")
+		}
+		if tasks.Interrupt(os.Interrupt) {
+			t.Error("the second interrupt should be reported")
+		}
+	}()
+	start := time.Now()
+	code := tsk.RunTarget("server", false)
+	assertIntEqual(t, systools.ExitByInterrupt, code)
+	if time.Since(start) > 5*time.Second {
+		t.Error("the interrupt was not forwarded to the running command")
+	}
+	mu.Lock()
+	all := strings.Join(outputs, "\n")
+	mu.Unlock()
+	if !strings.Contains(all, "started") || !strings.Contains(all, "cleanup done") {
+		t.Errorf("expected the output of the task and the cleanup. got %v", outputs)
+	}
+	if strings.Contains(all, "never") {
+		t.Errorf("no script line should run after the interrupt. got %v", outputs)
+	}
+
+	// no target is started while interrupted
+	assertIntEqual(t, systools.ExitByInterrupt, tsk.RunTarget("cleanup", false))
+
+	// the cleanup is not waited for longer than the timeout
+	tasks.ResetInterrupt()
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		tasks.Interrupt(os.Interrupt)
+	}()
+	start = time.Now()
+	assertIntEqual(t, systools.ExitByInterrupt, tsk.RunTarget("stuck", false))
+	if time.Since(start) > 5*time.Second {
+		t.Error("the onInterrupt target was waited for too long")
+	}
+	// stop the cleanup, that is still running
+	tasks.ForwardSignal(os.Kill)
+}
+
+// the task runners are started by the process package. they have to get the signal too
+func TestInterruptTaskRunner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signals are not supported on windows")
+	}
+	defer tasks.ResetInterrupt()
+
+	testTask := configure.Task{ID: "runner-interrupt"}
+	task := tasks.New(testTask.ID, nil)
+	defer task.StopAllTaskRunner()
+	runner, err := task.GetRunnerForTask(testTask, func(s string, err error) bool { return true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := runner.GetRunner().Command("sleep 30"); err != nil {
+		t.Fatal(err)
+	}
+	if !task.WaitTilTaskRunnerIsRunning(testTask, 10*time.Millisecond, 100) {
+		t.Fatal("the runner is not started")
+	}
+
+	tasks.Interrupt(os.Interrupt)
+	for tick := 0; tick < 100 && task.TaskRunnerIsActive(testTask); tick++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if task.TaskRunnerIsActive(testTask) {
+		t.Error("the interrupt was not forwarded to the runner")
+	}
+}
@@ -25,14 +25,17 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/swaros/contxt/module/configure"
-	"github.com/swaros/contxt/module/dirhandle"
 	"github.com/swaros/contxt/module/mimiclog"
 	"github.com/swaros/contxt/module/process"
 	"github.com/swaros/contxt/module/systools"
@@ -45,17 +48,18 @@ func (t *targetExecuter) runAnkCmd(task *configure.Task) (int, error) {
 		t.getLogger().Debug("no command to execute in command section")
 		return systools.ExitNoCode, nil
 	}
-	// handle directory change
-	curDir, dirError := t.directoryCheckPrep(task)
-	defer curDir.Popd()
-
+	// the directory the commands are working in
+	workingDir, dirError := t.workingDir(task)
 	if dirError != nil {
 		t.getLogger().Error("can not change directory", dirError)
 		return systools.ExitCmdError, dirError
 	}
 	ankRunner := NewAnkoRunner()
-	// in this context we set the risk level to high, because the targetcommands should be able to do anything
-	ankRunner.SetRiskLevel(RISK_LEVEL_HIGH)
+	ankRunner.SetWorkingDir(workingDir)
+	ankRunner.SetCmdEnv(t.commandEnv(*task))
+	ankRunner.SetModules(t.ankoModules)
+	// the risk level depends on the source of the task. functions above the level are blocked
+	ankRunner.SetRiskLevel(t.riskPolicy.MaxRisk(*task))
 	cancelFn := ankRunner.EnableCancelation()
 	defer ankRunner.ClearBuffer()
 
@@ -69,7 +73,7 @@ func (t *targetExecuter) runAnkCmd(task *configure.Task) (int, error) {
 		ankRunner.SetTimeOut(time.Duration(task.Options.CmdTimeout) * time.Millisecond)
 	}
 
-	cmdFull := t.fullFillVars(strings.Join(task.Cmd, "\n"))
+	cmdFull := t.fullFillTaskVars(strings.Join(task.Cmd, "\n"), *task)
 
 	if task.Options.Displaycmd {
 		t.out(MsgTarget{Target: task.ID, Context: "ankocommand", Info: cmdFull}) // output the command
@@ -83,11 +87,11 @@ func (t *targetExecuter) runAnkCmd(task *configure.Task) (int, error) {
 
 		// listener handling
 		if task.Listener != nil { // do we have listener?
-			t.listenerWatch(msg, nil, task) // listener handler
+			t.listenerWatch(OutputEvent(msg, nil), task) // listener handler
 		}
 
 		// stop reason handling
-		stopReasonFound, message := t.checkReason(task.Stopreasons, msg, nil) // do we found a defined reason to stop execution
+		stopReasonFound, message, _ := t.checkTrigger(task.Stopreasons, OutputEvent(msg, nil)) // do we found a defined reason to stop execution
 		if stopReasonFound {
 			if task.Options.Displaycmd {
 				t.out(MsgProcess{Target: task.ID, StatusChange: "aborted", Comment: message})
@@ -100,7 +104,7 @@ func (t *targetExecuter) runAnkCmd(task *configure.Task) (int, error) {
 
 	t.setPh("CMD."+task.ID+".SOURCE", cmdFull) // set or overwrite the last script output for the target
 	if task.Listener != nil {                  // do we have listener?
-		t.listenerWatch(cmdFull, nil, task) // listener handler
+		t.listenerWatch(OutputEvent(cmdFull, nil), task) // listener handler
 	}
 	startTime := time.Now()
 	_, err := ankRunner.RunAnko(cmdFull)
@@ -135,66 +139,111 @@ func (t *targetExecuter) runAnkCmd(task *configure.Task) (int, error) {
 // it returns the exit code of the executed command
 // and a boolean value if the execution was successful
 func (t *targetExecuter) targetTaskExecuter(codeLine string, currentTask configure.Task, watchman *Watchman) (int, bool) {
-	replacedLine := t.fullFillVars(codeLine) // replace placeholders in the script line
+	if t.isStoppedByInterrupt() { // no new commands after an interrupt
+		return systools.ExitByInterrupt, true
+	}
+	replacedLine := t.fullFillTaskVars(codeLine, currentTask) // replace placeholders in the script line
 	if currentTask.Options.Displaycmd {
 		t.out(MsgTarget{Target: currentTask.ID, Context: "command", Info: replacedLine}) // output the command
 	}
 	t.setPh("RUN."+currentTask.ID+".CMD.LAST", replacedLine) // set or overwrite the last script command for the target
 	t.setPh("RUN.SCRIPT_LINE", replacedLine)                 // set or overwrite the last script command for the target
 
-	runCmd, runArgs := t.commandFallback.GetMainCmd(currentTask.Options) // get the main command and arguments
-	t.SetMainCmd(runCmd, runArgs...)                                     // set the main command and arguments
+	return t.executeResolvedLine(codeLine, replacedLine, currentTask, watchman)
+}
+
+// executeResolvedLine executes a script line, where all placeholders are already replaced.
+// the codeLine is used as reference for error messages.
+// it returns the exit code of the executed command
+// and a boolean value if the execution was successful
+func (t *targetExecuter) executeResolvedLine(codeLine, replacedLine string, currentTask configure.Task, watchman *Watchman) (int, bool) {
+	// the main command and arguments. they are kept local, because needs are running in parallel on the same executer
+	runCmd, runArgs := t.commandFallback.GetMainCmd(currentTask.Options)
+	return t.executeResolvedCommand(codeLine, replacedLine, runCmd, runArgs, currentTask, watchman)
+}
 
-	// keep the current directory
-	curDir, dirError := t.directoryCheckPrep(&currentTask)
+// executeResolvedCommand executes the replacedLine as last argument of the runCmd and runArgs.
+// the return values are the same as for executeResolvedLine
+func (t *targetExecuter) executeResolvedCommand(codeLine, replacedLine, runCmd string, runArgs []string, currentTask configure.Task, watchman *Watchman) (int, bool) {
+	// the directory the command is executed in
+	workingDir, dirError := t.workingDir(&currentTask)
 	if dirError != nil {
 		return systools.ExitCmdError, true
 	}
 
-	// here we execute the current script line
-	execCode, realExitCode, execErr := t.ExecuteScriptLine(
-		runCmd,
-		runArgs,
-		replacedLine,
-		func(logLine string, err error) bool { // callback for any logline
-			t.setPh("RUN."+currentTask.ID+".LOG.LAST", logLine) // set or overwrite the last script output for the target
-			if currentTask.Listener != nil {                    // do we have listener?
-				t.listenerWatch(logLine, err, &currentTask) // listener handler
-			}
+	// triggers they are checking the duration of the command are running in their own timer.
+	// if a stopreason is matching, the command is killed.
+	// the scripts of listener actions are not checked again, so they can not trigger themselves.
+	// the triggerLock makes sure, the listeners are never called at the same time by the timers and the output
+	var triggerLock sync.Mutex
+	var stoppedByDuration atomic.Bool
+	var runningPid atomic.Int64
+	stopTimers := t.startDurationTriggers(&currentTask, &triggerLock, func(message string) {
+		stoppedByDuration.Store(true)
+		if currentTask.Options.Displaycmd {
+			t.out(MsgProcess{Target: currentTask.ID, StatusChange: "aborted", Comment: message})
+		}
+		if pid := runningPid.Load(); pid > 0 {
+			process.KillProcessTree(int(pid))
+		}
+	})
 
-			// The whole output can be ignored by configuration
-			// if this is not enabled then we handle all these here
-			t.outPut(&currentTask, err, logLine)
+	onOutput := func(logLine string, stderr bool, err error) bool { // callback for any logline
+		triggerLock.Lock()
+		defer triggerLock.Unlock()
+		if stoppedByDuration.Load() {
+			return false
+		}
+		event := TriggerEvent{Kind: TriggerOnOutput, Output: logLine, Err: err, Stderr: stderr}
+		t.setPh("RUN."+currentTask.ID+".LOG.LAST", logLine) // set or overwrite the last script output for the target
+		if currentTask.Listener != nil {                    // do we have listener?
+			t.listenerWatch(event, &currentTask) // listener handler
+		}
+		if t.outputs != nil && needsRegexOutput(currentTask) {
+			t.outputs.addLogLine(currentTask.ID, logLine) // keep the output for the outputs they are using a regex
+		}
 
-			stopReasonFound, message := t.checkReason(currentTask.Stopreasons, logLine, err) // do we found a defined reason to stop execution
-			if stopReasonFound {
-				if currentTask.Options.Displaycmd {
-					t.out(MsgProcess{Target: currentTask.ID, StatusChange: "aborted", Comment: message})
-					//t.out(MsgType("stopreason"), MsgReason(message), MsgProcess("aborted"))
-				}
-				return false
-			}
-			return true
-		}, func(process *os.Process) { // callback if the process started and we got the process id
-			pidStr := fmt.Sprintf("%d", process.Pid) // we use them as info for the user only
-			t.setPh("RUN.PID", pidStr)
-			t.setPh("RUN."+t.target+".PID", pidStr)
-			// update watchman with the process infos, if there is an task for this target
-			// this should be the case always by any watchman target update, but we check it anyway
-			if wtask, found := watchman.GetTask(t.target); found {
-				wtask.StartTrackProcess(process)
-				wtask.LogCmd(runCmd, runArgs, replacedLine)
-				if err := watchman.UpdateTask(t.target, wtask); err != nil {
-					t.getLogger().Error("can not update task", err)
-					t.out(MsgError(MsgError{Err: err, Reference: codeLine, Target: currentTask.ID}))
-				}
-			}
+		// The whole output can be ignored by configuration
+		// if this is not enabled then we handle all these here
+		t.outPut(&currentTask, err, logLine)
+
+		stopReasonFound, message, _ := t.checkTrigger(currentTask.Stopreasons, event) // do we found a defined reason to stop execution
+		if stopReasonFound {
 			if currentTask.Options.Displaycmd {
-				t.out(MsgPid{Pid: process.Pid, Target: currentTask.ID}, MsgProcess{Target: currentTask.ID, StatusChange: "started", Comment: replacedLine})
+				t.out(MsgProcess{Target: currentTask.ID, StatusChange: "aborted", Comment: message})
+				//t.out(MsgType("stopreason"), MsgReason(message), MsgProcess("aborted"))
 			}
-		})
+			return false
+		}
+		return true
+	}
+	onStart := func(process *os.Process) { // callback if the process started and we got the process id
+		runningPid.Store(int64(process.Pid))
+		pidStr := fmt.Sprintf("%d", process.Pid) // we use them as info for the user only
+		t.setPh("RUN.PID", pidStr)
+		t.setPh("RUN."+t.target+".PID", pidStr)
+		// update watchman with the process infos, if there is an task for this target
+		// this should be the case always by any watchman target update, but we check it anyway.
+		// needs are running in the same executer, so we use the id of the task, not the target of the executer
+		if wtask, found := watchman.GetTask(currentTask.ID); found {
+			wtask.StartTrackProcess(process)
+			wtask.LogCmd(runCmd, runArgs, replacedLine)
+			if err := watchman.UpdateTask(currentTask.ID, wtask); err != nil {
+				t.getLogger().Error("can not update task", err)
+				t.out(MsgError(MsgError{Err: err, Reference: codeLine, Target: currentTask.ID}))
+			}
+		}
+		if currentTask.Options.Displaycmd {
+			t.out(MsgPid{Pid: process.Pid, Target: currentTask.ID}, MsgProcess{Target: currentTask.ID, StatusChange: "started", Comment: replacedLine})
+		}
+	}
+
+	// here we execute the current script line.
+	// stderr is only read separately, if a trigger needs to know about it
+	opts := ExecOptions{Dir: workingDir, Env: t.commandEnv(currentTask), SeparateStderr: usesStderr(currentTask)}
+	execCode, realExitCode, execErr := t.ExecuteScriptLineWithOptions(opts, runCmd, runArgs, replacedLine, onOutput, onStart)
+	stopTimers()
 
-	curDir.Popd() // restore the current directory
 	if currentTask.Options.Displaycmd {
 		t.out(MsgProcess{
 			Target:       currentTask.ID,
@@ -210,6 +259,26 @@ func (t *targetExecuter) targetTaskExecuter(codeLine string, currentTask configu
 		}
 
 	}
+	// the command is stopped by an interrupt. this is not handled as error of the command
+	if execCode != systools.ExitOk && t.isStoppedByInterrupt() {
+		return systools.ExitByInterrupt, true
+	}
+	if stoppedByDuration.Load() {
+		return systools.ExitByStopReason, true
+	}
+
+	// the exit code of the command is also an event for the triggers
+	if execCode != systools.ExitByStopReason && !t.triggerAction {
+		exitEvent := TriggerEvent{Kind: TriggerOnExit, ExitCode: realExitCode, Err: execErr}
+		t.listenerWatch(exitEvent, &currentTask)
+		if stopReasonFound, message, _ := t.checkTrigger(currentTask.Stopreasons, exitEvent); stopReasonFound {
+			if currentTask.Options.Displaycmd {
+				t.out(MsgProcess{Target: currentTask.ID, StatusChange: "aborted", Comment: message})
+			}
+			return systools.ExitByStopReason, true
+		}
+	}
+
 	// check execution codes
 	switch execCode {
 	case systools.ExitByStopReason:
@@ -261,9 +330,19 @@ func (t *targetExecuter) fullFillVars(codeLine string) string {
 	return replacedLine
 }
 
+// fullFillTaskVars replaces the placeholders like fullFillVars, but also
+// with the outputs of the needs of the task
+func (t *targetExecuter) fullFillTaskVars(codeLine string, task configure.Task) string {
+	replacedLine := codeLine
+	if t.phHandler != nil {
+		replacedLine = t.phHandler.HandlePlaceHolderWithScope(codeLine, t.taskScope(task)) // placeholders
+	}
+	return replacedLine
+}
+
 func (t *targetExecuter) outPut(task *configure.Task, err error, output string) {
-	if !task.Options.Hideout {
-		outStr := output              // hardcoded format for the logoutput iteself
+	outStr, visible := t.filterOutput(task, output) // the filters can also set variables, so they are applied also if the output is hidden
+	if visible && !task.Options.Hideout {
 		if task.Options.Stickcursor { // optional set back the cursor to the beginning
 			t.out(MsgStickCursor(true)) // trigger the stick cursor
 		}
@@ -280,39 +359,85 @@ func (t *targetExecuter) outPut(task *configure.Task, err error, output string)
 
 }
 
-func (t *targetExecuter) directoryCheckPrep(currentTask *configure.Task) (*dirhandle.Popd, error) {
-	curDir := dirhandle.Pushd()
-	if currentTask.Options.WorkingDir != "" {
-		cdErr := os.Chdir(t.phHandler.HandlePlaceHolder(currentTask.Options.WorkingDir)) // change the directory
-		if cdErr != nil {
-			t.getLogger().Error("can not change directory", cdErr)
-			t.out(MsgError(MsgError{Err: cdErr, Reference: currentTask.Options.WorkingDir, Target: currentTask.ID}))
-			return nil, cdErr
-		}
-	} else {
-		// if the rootpath exists, we change to this path
-		if t.rootPath != "" {
-			if er := os.Chdir(t.rootPath); er != nil {
-				t.getLogger().Error("can not change directory", er)
-				t.out(MsgError(MsgError{Err: er, Reference: t.rootPath, Target: currentTask.ID}))
-				return nil, er
-			}
-		} else {
-			// if the rootpath does not exists, we look for tht BASEPATH placeholder
-			// and change to this path
-			if t.phHandler != nil {
-				if basePath := t.phHandler.GetPH("BASEPATH"); basePath != "" {
-					if er := os.Chdir(basePath); er != nil {
-						t.getLogger().Error("can not change directory", er)
-						t.out(MsgError(MsgError{Err: er, Reference: basePath, Target: currentTask.ID}))
-						return nil, er
-					}
-				}
-			}
+// resolveWorkingDir returns the directory the task is executed in.
+// this is the working dir of the task, or the root path, or the BASEPATH variable.
+// a relative working dir is resolved against the root path or the BASEPATH.
+// an empty string means the current directory of the process.
+// the current directory of the process is never changed, so tasks with different
+// working dirs can run at the same time.
+func (t *targetExecuter) resolveWorkingDir(currentTask *configure.Task) string {
+	baseDir := t.rootPath
+	if baseDir == "" && t.phHandler != nil {
+		// if the rootpath does not exists, we look for tht BASEPATH placeholder
+		if basePath, exists := t.phHandler.GetPHExists("BASEPATH"); exists {
+			baseDir = basePath
 		}
+	}
+	if currentTask == nil {
+		return baseDir
+	}
+	return ResolveWorkingDir(*currentTask, baseDir, t.phHandler)
+}
+
+// ResolveWorkingDir returns the directory the task is executed in.
+// a relative working dir of the task is resolved against the baseDir. without a working dir, the baseDir is used.
+// the placeholders of the working dir are replaced, if the placeholder handler is not nil.
+func ResolveWorkingDir(task configure.Task, baseDir string, ph PlaceHolder) string {
+	if task.Options.WorkingDir == "" {
+		return baseDir
+	}
+	workingDir := task.Options.WorkingDir
+	if ph != nil {
+		workingDir = ph.HandlePlaceHolder(workingDir)
+	}
+	if filepath.IsAbs(workingDir) {
+		return workingDir
+	}
+	if baseDir != "" {
+		return filepath.Join(baseDir, workingDir)
+	}
+	if absDir, err := filepath.Abs(workingDir); err == nil {
+		return absDir
+	}
+	return workingDir
+}
+
+// workingDir returns the resolved working dir of the task and
+// checks if the directory exists.
+func (t *targetExecuter) workingDir(currentTask *configure.Task) (string, error) {
+	dir := t.resolveWorkingDir(currentTask)
+	if dir == "" {
+		return "", nil
+	}
+	stat, err := os.Stat(dir)
+	if err == nil && !stat.IsDir() {
+		err = errors.New(dir + " is not a directory")
+	}
+	if err != nil {
+		t.getLogger().Error("can not use directory", err)
+		t.out(MsgError(MsgError{Err: err, Reference: dir, Target: currentTask.ID}))
+		return "", err
+	}
+	return dir, nil
+}
 
+// resolvePath returns the path relative to the directory.
+// absolute paths, and paths without a directory are returned as they are.
+func resolvePath(dir, path string) string {
+	if dir == "" || path == "" || filepath.IsAbs(path) {
+		return path
 	}
-	return curDir, nil
+	return filepath.Join(dir, path)
+}
+
+// ExecOptions are the options for executing a command
+type ExecOptions struct {
+	Dir string   // the directory the command is executed in. if empty, the current directory is used
+	Env []string // the environment as "key=value" entries. if nil, the environment of contxt is used
+	// SeparateStderr reads stdout and stderr separately, so the output callback knows if the line is written to stderr.
+	// otherwise stderr is combined with stdout.
+	// the order of lines written to stdout and stderr at the same time is not guaranteed.
+	SeparateStderr bool
 }
 
 // ExecuteScriptLine executes a script line and returns the exit code
@@ -322,60 +447,110 @@ func (t *targetExecuter) ExecuteScriptLine(dCmd string, dCmdArgs []string, comma
 	return Execute(dCmd, dCmdArgs, command, callback, startInfo)
 }
 
+// ExecuteScriptLineWithOptions executes a script line with the given options. see ExecuteWithOptions
+func (t *targetExecuter) ExecuteScriptLineWithOptions(opts ExecOptions, dCmd string, dCmdArgs []string, command string, callback func(line string, stderr bool, err error) bool, startInfo func(*os.Process)) (int, int, error) {
+	return ExecuteWithOptions(opts, dCmd, dCmdArgs, command, callback, startInfo)
+}
+
 // Execute executes a command and returns the internal exit code, the command exit code and an error
 // the callback function is called for each line of the output
 // the startInfo function is called if the process started and the process id is available
 func Execute(dCmd string, dCmdArgs []string, command string, callback func(string, error) bool, startInfo func(*os.Process)) (int, int, error) {
-	cmdArg := append(dCmdArgs, command)
+	return ExecuteWithOptions(ExecOptions{}, dCmd, dCmdArgs, command, func(line string, _ bool, err error) bool {
+		return callback(line, err)
+	}, startInfo)
+}
+
+// ExecuteWithOptions executes a command and returns the internal exit code, the command exit code and an error.
+// the callback function is called for each line of the output. stderr is only reported as such, if SeparateStderr is set.
+// the startInfo function is called if the process started and the process id is available.
+func ExecuteWithOptions(opts ExecOptions, dCmd string, dCmdArgs []string, command string, callback func(line string, stderr bool, err error) bool, startInfo func(*os.Process)) (int, int, error) {
+	cmdArg := append(append([]string{}, dCmdArgs...), command)
 	cmd := exec.Command(dCmd, cmdArg...)
+	cmd.Dir = opts.Dir
+	cmd.Env = opts.Env
 
 	stdoutPipe, _ := cmd.StdoutPipe()
-	cmd.Stderr = cmd.Stdout
+	var stderrPipe io.Reader
+	if opts.SeparateStderr {
+		stderrPipe, _ = cmd.StderrPipe()
+	} else {
+		cmd.Stderr = cmd.Stdout
+	}
 
+	// the command gets his own process group, so we can stop the command with all child processes.
+	// this also means signals like Ctrl-C are no longer send to the command by the terminal. they have to be forwarded.
+	process.TryPid2Pgid(cmd)
 	err := cmd.Start()
 	if err != nil {
 		return systools.ExitCmdError, 0, err
 	}
-	process.TryPid2Pgid(cmd)
+	trackProcessGroup(cmd.Process.Pid)
+	defer untrackProcessGroup(cmd.Process.Pid)
 
 	startInfo(cmd.Process)
-	scanner := bufio.NewScanner(stdoutPipe)
-
-	scanner.Split(bufio.ScanLines)
-	for scanner.Scan() {
-		m := scanner.Text()
-		keepRunning := callback(m, nil)
-		if !keepRunning {
-			cmd.Process.Kill()
-			process.KillProcessTree(cmd.Process.Pid)
-			return systools.ExitByStopReason, 0, err
-		}
 
+	// the callback is never called at the same time from both pipes
+	var mu sync.Mutex
+	stopped := false
+	readLines := func(pipe io.Reader, stderr bool) {
+		scanner := bufio.NewScanner(pipe)
+		scanner.Split(bufio.ScanLines)
+		for scanner.Scan() {
+			mu.Lock()
+			if !stopped && !callback(scanner.Text(), stderr, nil) {
+				stopped = true
+				cmd.Process.Kill()
+				process.KillProcessTree(cmd.Process.Pid)
+			}
+			mu.Unlock()
+		}
+	}
+	var wg sync.WaitGroup
+	if stderrPipe != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			readLines(stderrPipe, true)
+		}()
 	}
+	readLines(stdoutPipe, false)
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
 	err = cmd.Wait()
+	if stopped {
+		return systools.ExitByStopReason, 0, nil
+	}
 	if err != nil {
-		callback(err.Error(), err)
-		errRealCode := 0
-		if exiterr, ok := err.(*exec.ExitError); ok {
-			if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
-				errRealCode = status.ExitStatus()
-			}
+		callback(err.Error(), false, err)
+		return systools.ExitCmdError, exitCodeOf(err), err
+	}
+	return systools.ExitOk, 0, nil
+}
 
+// exitCodeOf returns the exit code of the command, if the error is an exit error
+func exitCodeOf(err error) int {
+	if exiterr, ok := err.(*exec.ExitError); ok {
+		if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
+			return status.ExitStatus()
 		}
-		return systools.ExitCmdError, errRealCode, err
 	}
-
-	return systools.ExitOk, 0, err
+	return 0
 }
 
 // listenerWatch checks if a trigger is hit and executes the action
-func (t *targetExecuter) listenerWatch(logLine string, e error, currentTask *configure.Task) {
+func (t *targetExecuter) listenerWatch(event TriggerEvent, currentTask *configure.Task) {
 	if currentTask.Listener != nil {
-
+		logLine := event.Output
 		for _, listener := range currentTask.Listener {
-			triggerFound, triggerMessage := t.checkReason(listener.Trigger, logLine, e) // check if a trigger have a match
+			triggerFound, triggerMessage, captures := t.checkTrigger(listener.Trigger, event) // check if a trigger have a match
 			if triggerFound {
 				t.setPh("RUN."+t.target+".LOG.HIT", logLine)
+				for name, value := range captures { // the groups of the regex are provided to the action
+					t.setPh(triggerCaptureKey(currentTask.ID, name), value)
+				}
 				if currentTask.Options.Displaycmd {
 					t.out(MsgType("run-trigger-sricpt-line"), MsgCommand(logLine))
 				}
@@ -390,6 +565,11 @@ func (t *targetExecuter) listenerWatch(logLine string, e error, currentTask *con
 					actionDef.Target = keyname
 				}
 
+				// declarative actions like setVar or message are executed first, so the script and target can use the results
+				if t.runDeclarativeActions(actionDef, currentTask, captures) {
+					someReactionTriggered = true
+				}
+
 				if len(actionDef.Script) > 0 { // script are directs executes without any async or other executes out of scope
 					someReactionTriggered = true
 					var dummyArgs map[string]string = make(map[string]string) // create empty arguments as scoped values
@@ -397,6 +577,7 @@ func (t *targetExecuter) listenerWatch(logLine string, e error, currentTask *con
 						t.getLogger().Debug("TRIGGER SCRIPT ACTION", triggerScript)
 						subRun := t.CopyToTarget(t.target)
 						subRun.SetArgs(dummyArgs)
+						subRun.triggerAction = true
 						subRun.targetTaskExecuter(triggerScript, *currentTask, t.watch)
 					}
 
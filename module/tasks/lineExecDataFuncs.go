@@ -0,0 +1,131 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errNoDataPaths is returned, if the data handler can not change data by a path
+var errNoDataPaths = errors.New("the data handler does not support paths")
+
+// getDataFnAsDefaults returns the functions to change the data of a key by a path.
+// the data is the same as for importJson, importYamlFile and the ${key:path} placeholders.
+func (t *targetExecuter) getDataFnAsDefaults(anko *AnkoRunner) []AnkoDefiner {
+	return []AnkoDefiner{
+		{"dataGet",
+			func(key, path string) (interface{}, error) {
+				dh, ok := t.dataHandler.(DataPathHandler)
+				if !ok {
+					return nil, errNoDataPaths
+				}
+				return dh.GetValueByPath(key, path)
+			},
+			RISK_LEVEL_LOW,
+			`get the value of a path. e.g. image,err = dataGet('compose','services.web.image')
+numeric parts of the path are the index of an array. e.g. dataGet('compose','services.web.ports.0')`,
+		},
+		{"dataSet",
+			func(key, path string, value interface{}) error {
+				dh, ok := t.dataHandler.(DataPathHandler)
+				if !ok {
+					return errNoDataPaths
+				}
+				if str, isStr := value.(string); isStr {
+					value = t.phHandler.HandlePlaceHolder(str)
+				}
+				return t.dataError(anko, "dataSet", dh.SetValueByPath(key, path, value), key, path)
+			},
+			RISK_LEVEL_LOW,
+			`set the value of a path. the type of the value is kept. e.g. dataSet('compose','services.web.replicas', 3)
+missing maps are created. maps and arrays can be set too. e.g. dataSet('compose','services.web.environment', {"DEBUG": "1"})`,
+		},
+		{"dataDelete",
+			func(key, path string) error {
+				dh, ok := t.dataHandler.(DataPathHandler)
+				if !ok {
+					return errNoDataPaths
+				}
+				return t.dataError(anko, "dataDelete", dh.DeleteByPath(key, path), key, path)
+			},
+			RISK_LEVEL_LOW,
+			`remove the entry of a path. e.g. dataDelete('compose','services.debug')`,
+		},
+		{"dataAppend",
+			func(key, path string, value interface{}) error {
+				dh, ok := t.dataHandler.(DataPathHandler)
+				if !ok {
+					return errNoDataPaths
+				}
+				if str, isStr := value.(string); isStr {
+					value = t.phHandler.HandlePlaceHolder(str)
+				}
+				return t.dataError(anko, "dataAppend", dh.AppendByPath(key, path, value), key, path)
+			},
+			RISK_LEVEL_LOW,
+			`append a value to the array of a path. e.g. dataAppend('compose','services.web.ports','8080:80')
+the array is created, if the path not exists`,
+		},
+		{"dataMerge",
+			func(target, source string) error {
+				dh, ok := t.dataHandler.(DataPathHandler)
+				if !ok {
+					return errNoDataPaths
+				}
+				return t.dataError(anko, "dataMerge", dh.MergeData(target, source), target, source)
+			},
+			RISK_LEVEL_LOW,
+			`merge the data of the second key into the first key. e.g. dataMerge('config','override')
+maps are merged, any other value of the second key replaces the value of the first key`,
+		},
+		{"dataSave",
+			func(key, fileName string, format ...string) error {
+				dh, ok := t.dataHandler.(DataPathHandler)
+				if !ok {
+					return errNoDataPaths
+				}
+				useFormat := ""
+				if len(format) > 0 {
+					useFormat = format[0]
+				}
+				return t.dataError(anko, "dataSave", dh.SaveData(key, t.fsPath(anko, fileName), useFormat), key, fileName)
+			},
+			RISK_LEVEL_HIGH,
+			`write the data of a key to a file. e.g. dataSave('compose','docker-compose.yml')
+the format is taken from the extension, or set as json or yaml. e.g. dataSave('compose','compose.out','yaml')`,
+		},
+	}
+}
+
+// dataError reports the error of a data function
+func (t *targetExecuter) dataError(anko *AnkoRunner, fn string, err error, args ...string) error {
+	if err != nil {
+		anko.ThrowException(err, fmt.Sprintf("%s('%s')", fn, strings.Join(args, "','")))
+		t.out(MsgError(MsgError{Err: err, Reference: fn, Target: t.target}))
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package tasks_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/yamc"
+)
+
+func TestDataGetAndSet(t *testing.T) {
+	cmd := `
+	importYamlFile("compose", "testdata/data/compose.yml")
+	image, err = dataGet("compose", "services.web.image")
+	println(image)
+	port, err = dataGet("compose", "services.web.ports.0")
+	println(port)
+	dataSet("compose", "services.web.replicas", 3)
+	replicas, err = dataGet("compose", "services.web.replicas")
+	println(replicas + 1)
+	dataSet("compose", "services.web.environment", {"DEBUG": "1"})
+	println(dataGet("compose", "services.web.environment.DEBUG")[0])
+	_, err = dataGet("compose", "services.web.nope")
+	println(err)`
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 5, []string{
+		"nginx:1.24",
+		"80:80",
+		"4",
+		"1",
+		"path services.web.nope not found",
+	})
+}
+
+func TestDataDeleteAppendAndMerge(t *testing.T) {
+	cmd := `
+	importYamlFile("compose", "testdata/data/compose.yml")
+	dataDelete("compose", "services.debug")
+	_, err = dataGet("compose", "services.debug")
+	println(err != nil)
+	dataAppend("compose", "services.web.ports", "443:443")
+	dataAppend("compose", "services.web.volumes", "./html:/usr/share/nginx/html")
+	println(len(dataGet("compose", "services.web.ports")[0]))
+	println(dataGet("compose", "services.web.volumes.0")[0])
+	importJson("override", "{\"services\": {\"web\": {\"image\": \"nginx:1.25\"}}}")
+	dataMerge("compose", "override")
+	println(dataGet("compose", "services.web.image")[0])
+	println(dataGet("compose", "services.web.replicas")[0])`
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 5, []string{
+		"true",
+		"2",
+		"./html:/usr/share/nginx/html",
+		"nginx:1.25",
+		"2",
+	})
+}
+
+func TestDataSave(t *testing.T) {
+	os.MkdirAll("temp/datasave", os.ModePerm)
+	cmd := `
+	varSet("VERSION", "1.26")
+	importYamlFile("compose", "testdata/data/compose.yml")
+	dataSet("compose", "services.web.image", "nginx:${VERSION}")
+	err = dataSave("compose", "temp/datasave/docker-compose.yml")
+	if err != nil {
+		println(err)
+	}
+	err = dataSave("compose", "temp/datasave/compose.out", "json")
+	if err != nil {
+		println(err)
+	}`
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 0, []string{""})
+	saved, err := yamc.NewByYaml("temp/datasave/docker-compose.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image, err := saved.FindValue("services.web.image"); err != nil || image != "nginx:1.26" {
+		t.Error("unexpected image", image, err)
+	}
+	if replicas, err := saved.FindValue("services.web.replicas"); err != nil || replicas != 2 {
+		t.Errorf("the type of replicas should be kept. got %v (%T) %v", replicas, replicas, err)
+	}
+	asJson, err := yamc.NewByJson("temp/datasave/compose.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version, _ := asJson.FindValue("version"); version != "3" {
+		t.Error("unexpected version", version)
+	}
+}
@@ -0,0 +1,515 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	cp "github.com/otiai10/copy"
+)
+
+// getFsFnAsDefaults returns the functions to find, inspect and pack files.
+// any relative path is resolved against the working dir of the runner.
+func (t *targetExecuter) getFsFnAsDefaults(anko *AnkoRunner) []AnkoDefiner {
+	return []AnkoDefiner{
+		{"exists",
+			func(path string) bool {
+				_, err := os.Stat(t.fsPath(anko, path))
+				return err == nil
+			},
+			RISK_LEVEL_LOW,
+			`check if a file or directory exists. e.g. if exists('output') { ... }`,
+		},
+		{"stat",
+			func(path string) (map[string]interface{}, error) {
+				info, err := os.Stat(t.fsPath(anko, path))
+				if err != nil {
+					return nil, err
+				}
+				return fileInfoMap(info), nil
+			},
+			RISK_LEVEL_MEDIUM,
+			`get the infos of a file or directory. e.g. info,err = stat('output.txt')
+the map contains name, size, mode, modTime (unix seconds) and isDir`,
+		},
+		{"glob",
+			func(pattern string) ([]string, error) {
+				pattern = filepath.FromSlash(t.phHandler.HandlePlaceHolder(pattern))
+				return globFiles(anko.GetWorkingDir(), pattern)
+			},
+			RISK_LEVEL_MEDIUM,
+			`find files by a pattern. e.g. files,err = glob('src/**/*.go')
+** matches any number of directories. the files are sorted by name`,
+		},
+		{"walk",
+			func(root string, fn func(string, map[string]interface{}) interface{}) error {
+				root = filepath.FromSlash(t.phHandler.HandlePlaceHolder(root))
+				resolved := anko.ResolvePath(root)
+				return filepath.Walk(resolved, func(path string, info os.FileInfo, err error) error {
+					if err != nil {
+						return err
+					}
+					// the value of the last statement is returned by anko, so only errors are stopping the walk
+					if err, ok := fn(filepath.Join(root, strings.TrimPrefix(path, resolved)), fileInfoMap(info)).(error); ok {
+						return err
+					}
+					return nil
+				})
+			},
+			RISK_LEVEL_MEDIUM,
+			`walk through a directory, and call the function for any file and directory. e.g.
+walk('src', func(path, info) {
+  println(path, info.size)
+})
+the walk stops if the function returns an error. this error is returned by walk`,
+		},
+		{"sha256File",
+			func(path string) (string, error) {
+				f, err := os.Open(t.fsPath(anko, path))
+				if err != nil {
+					return "", err
+				}
+				defer f.Close()
+				hash := sha256.New()
+				if _, err := io.Copy(hash, f); err != nil {
+					return "", err
+				}
+				return hex.EncodeToString(hash.Sum(nil)), nil
+			},
+			RISK_LEVEL_MEDIUM,
+			`get the sha256 checksum of a file as hex string. e.g. sum,err = sha256File('dist/app.tar.gz')`,
+		},
+		{"move",
+			func(src, dst string) error {
+				src, dst = t.fsPath(anko, src), t.fsPath(anko, dst)
+				err := os.Rename(src, dst)
+				if errors.Is(err, syscall.EXDEV) {
+					// rename is not working across devices
+					if err := cp.Copy(src, dst); err != nil {
+						return err
+					}
+					return os.RemoveAll(src)
+				}
+				return err
+			},
+			RISK_LEVEL_HIGH,
+			`move or rename a file or directory. e.g. move('build/app','dist/app')`,
+		},
+		{"chmod",
+			func(path, mode string) error {
+				perm, err := strconv.ParseUint(mode, 8, 32)
+				if err != nil {
+					return fmt.Errorf("invalid mode %s. use an octal mode like 0755", mode)
+				}
+				return os.Chmod(t.fsPath(anko, path), os.FileMode(perm))
+			},
+			RISK_LEVEL_HIGH,
+			`change the mode of a file. e.g. chmod('dist/app','0755')`,
+		},
+		{"tarCreate",
+			func(archive, src string) error {
+				return tarCreate(t.fsPath(anko, archive), t.fsPath(anko, src))
+			},
+			RISK_LEVEL_HIGH,
+			`create a tar archive of a file or the content of a directory. e.g. tarCreate('dist/app.tar.gz','build')
+the archive is gzip compressed, if the name ends with .gz or .tgz`,
+		},
+		{"tarExtract",
+			func(archive, dst string) error {
+				return tarExtract(t.fsPath(anko, archive), t.fsPath(anko, dst))
+			},
+			RISK_LEVEL_HIGH,
+			`extract a tar archive to a directory. e.g. tarExtract('dist/app.tar.gz','out')`,
+		},
+		{"zipCreate",
+			func(archive, src string) error {
+				return zipCreate(t.fsPath(anko, archive), t.fsPath(anko, src))
+			},
+			RISK_LEVEL_HIGH,
+			`create a zip archive of a file or the content of a directory. e.g. zipCreate('dist/app.zip','build')`,
+		},
+		{"zipExtract",
+			func(archive, dst string) error {
+				return zipExtract(t.fsPath(anko, archive), t.fsPath(anko, dst))
+			},
+			RISK_LEVEL_HIGH,
+			`extract a zip archive to a directory. e.g. zipExtract('dist/app.zip','out')`,
+		},
+	}
+}
+
+// fsPath handles the placeholders of the path, and resolves it against the working dir
+func (t *targetExecuter) fsPath(anko *AnkoRunner, path string) string {
+	path = t.phHandler.HandlePlaceHolder(path)
+	return anko.ResolvePath(filepath.FromSlash(path))
+}
+
+// fileInfoMap returns the file info as map, so it can be used in anko
+func fileInfoMap(info os.FileInfo) map[string]interface{} {
+	return map[string]interface{}{
+		"name":    info.Name(),
+		"size":    info.Size(),
+		"mode":    fmt.Sprintf("%04o", info.Mode().Perm()),
+		"modTime": info.ModTime().Unix(),
+		"isDir":   info.IsDir(),
+	}
+}
+
+// globFiles returns the files they match the pattern.
+// the pattern is relative to the working dir, if it is not absolute, and so are the results.
+// other than filepath.Glob, ** matches any number of directories.
+func globFiles(workingDir, pattern string) ([]string, error) {
+	segments := strings.Split(pattern, string(filepath.Separator))
+	// the base is the part of the pattern without any meta char
+	base := 0
+	for base < len(segments) && !strings.ContainsAny(segments[base], `*?[\`) {
+		base++
+	}
+	if base == len(segments) {
+		if _, err := os.Stat(resolvePath(workingDir, pattern)); err != nil {
+			return []string{}, nil
+		}
+		return []string{pattern}, nil
+	}
+	root := strings.Join(segments[:base], string(filepath.Separator))
+	if root == "" && filepath.IsAbs(pattern) {
+		root = string(filepath.Separator)
+	}
+	for _, segment := range segments[base:] {
+		if _, err := filepath.Match(segment, ""); err != nil {
+			return nil, err
+		}
+	}
+	resolved := resolvePath(workingDir, root)
+	if root == "" {
+		resolved = resolvePath(workingDir, ".")
+	}
+	matches := []string{}
+	err := filepath.Walk(resolved, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == resolved {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		rel, err := filepath.Rel(resolved, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if globMatch(segments[base:], strings.Split(rel, string(filepath.Separator))) {
+			matches = append(matches, filepath.Join(root, rel))
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// globMatch matches the path segments against the pattern segments.
+// the segment ** matches any number of path segments.
+func globMatch(pattern, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				if globMatch(pattern[1:], path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pattern[0], path[0]); !ok {
+			return false
+		}
+		pattern, path = pattern[1:], path[1:]
+	}
+	return len(path) == 0
+}
+
+// archiveEntries calls the function for any file and directory of the source.
+// the name is the slash separated path relative to the source directory,
+// or the name of the file, if the source is a file.
+func archiveEntries(src string, fn func(path, name string, info os.FileInfo) error) error {
+	stat, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !stat.IsDir() {
+		return fn(src, stat.Name(), stat)
+	}
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		return fn(path, filepath.ToSlash(rel), info)
+	})
+}
+
+// extractPath returns the path of an archive entry in the destination.
+// entries they would be written outside of the destination are rejected.
+// so are entries they would be written through a symlink, that already exists in the destination.
+func extractPath(dst, name string) (string, error) {
+	target := filepath.Join(dst, filepath.FromSlash(name))
+	if !isInsideDir(dst, target) {
+		return "", fmt.Errorf("invalid path in archive: %s", name)
+	}
+	rel, err := filepath.Rel(filepath.Clean(dst), target)
+	if err != nil || rel == "." {
+		return target, err
+	}
+	check := filepath.Clean(dst)
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		check = filepath.Join(check, part)
+		if info, err := os.Lstat(check); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("invalid path in archive: %s is written through the symlink %s", name, check)
+		}
+	}
+	return target, nil
+}
+
+// extractLink checks the target of a symlink in the archive.
+// absolute links and links they are pointing outside of the destination are rejected.
+func extractLink(dst, target, name, link string) error {
+	if filepath.IsAbs(link) || filepath.VolumeName(link) != "" {
+		return fmt.Errorf("invalid symlink in archive: %s points to the absolute path %s", name, link)
+	}
+	if !isInsideDir(dst, filepath.Join(filepath.Dir(target), filepath.FromSlash(link))) {
+		return fmt.Errorf("invalid symlink in archive: %s points outside of the destination", name)
+	}
+	return nil
+}
+
+// isInsideDir checks if the path is the dir itself, or any path below
+func isInsideDir(dir, path string) bool {
+	dir = filepath.Clean(dir)
+	path = filepath.Clean(path)
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+func isGzipArchive(archive string) bool {
+	return strings.HasSuffix(archive, ".gz") || strings.HasSuffix(archive, ".tgz")
+}
+
+// closeWriters closes the writers in the given order and returns the first error.
+// the error of the writing itself is returned first, if any.
+// closing writes the end of the archives, so the errors of closing can not be ignored
+func closeWriters(err error, writers ...io.Closer) error {
+	for _, w := range writers {
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func tarCreate(archive, src string) error {
+	f, err := os.Create(archive)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = f
+	writers := []io.Closer{f}
+	if isGzipArchive(archive) {
+		gz := gzip.NewWriter(f)
+		writers = append([]io.Closer{gz}, writers...)
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	writers = append([]io.Closer{tw}, writers...)
+	err = archiveEntries(src, func(path, name string, info os.FileInfo) error {
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return copyFileTo(tw, path)
+	})
+	return closeWriters(err, writers...)
+}
+
+func tarExtract(archive, dst string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if isGzipArchive(archive) {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err := extractPath(dst, header.Name)
+		if err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(header.Mode)|0700); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeFileFrom(target, tr, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := extractLink(dst, target, header.Name, header.Linkname); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+				return err
+			}
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func zipCreate(archive, src string) error {
+	f, err := os.Create(archive)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(f)
+	err = archiveEntries(src, func(path, name string, info os.FileInfo) error {
+		// zip has no links, so only files and directories are added
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			return nil
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		if info.IsDir() {
+			header.Name += "/"
+		} else {
+			header.Method = zip.Deflate
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil || info.IsDir() {
+			return err
+		}
+		return copyFileTo(w, path)
+	})
+	return closeWriters(err, zw, f)
+}
+
+func zipExtract(archive, dst string) error {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, file := range zr.File {
+		target, err := extractPath(dst, file.Name)
+		if err != nil {
+			return err
+		}
+		if file.FileInfo().IsDir() {
+			if err := os.MkdirAll(target, file.Mode().Perm()|0700); err != nil {
+				return err
+			}
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			return err
+		}
+		err = writeFileFrom(target, r, file.Mode().Perm())
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFileTo(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func writeFileFrom(target string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	return closeWriters(err, f)
+}
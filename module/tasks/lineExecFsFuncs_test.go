@@ -0,0 +1,245 @@
+package tasks_test
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/swaros/contxt/module/systools"
+)
+
+func TestFsGlob(t *testing.T) {
+	cmd := `
+	files, err = glob("testdata/case01/*.sh")
+	if err != nil {
+		println(err)
+	}
+	println(files)
+	files, err = glob("testdata/**/case01/test.sh")
+	println(files)
+	none, err = glob("testdata/case01/*.none")
+	println(len(none))`
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 3, []string{"[testdata/case01/test.sh]", "[testdata/case01/test.sh]", "0"})
+}
+
+func TestFsStatAndExists(t *testing.T) {
+	cmd := `
+	info, err = stat("testdata/case01")
+	if err != nil {
+		println(err)
+	}
+	println(info.isDir, info.name)
+	println(exists("testdata/case01/test.sh"), exists("testdata/case01/nope.sh"))
+	_, err = stat("testdata/case01/nope.sh")
+	println(err != nil)`
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 3, []string{"true case01", "true false", "true"})
+}
+
+func TestFsWalk(t *testing.T) {
+	cmd := `
+	found = 0
+	err = walk("testdata/case01", func(path, info) {
+		if !info.isDir && path == "testdata/case01/test.sh" {
+			found = found + 1
+		}
+	})
+	if err != nil {
+		println(err)
+	}
+	println(found)
+	errors = import("errors")
+	count = 0
+	err = walk("testdata/case01", func(path, info) {
+		count = count + 1
+		return errors.New("stop walking")
+	})
+	println(count, err)`
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 2, []string{"1", "1 stop walking"})
+}
+
+func TestFsSha256(t *testing.T) {
+	os.MkdirAll("temp/fshash", os.ModePerm)
+	if err := os.WriteFile("temp/fshash/hello.txt", []byte("hello world"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := `
+	sum, err = sha256File("temp/fshash/hello.txt")
+	if err != nil {
+		println(err)
+	}
+	println(sum)`
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 1, []string{"b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"})
+}
+
+func TestFsArchives(t *testing.T) {
+	os.RemoveAll("temp/fsarchive")
+	cmd := `
+	mkdir("temp/fsarchive")
+	for name in ["app.tar.gz", "app.tar", "app.zip"] {
+		err = nil
+		if stringContains(name, ".tar") {
+			err = tarCreate("temp/fsarchive/" + name, "testdata/case01")
+		} else {
+			err = zipCreate("temp/fsarchive/" + name, "testdata/case01")
+		}
+		if err != nil {
+			println(err)
+		}
+		if stringContains(name, ".tar") {
+			err = tarExtract("temp/fsarchive/" + name, "temp/fsarchive/out-" + name)
+		} else {
+			err = zipExtract("temp/fsarchive/" + name, "temp/fsarchive/out-" + name)
+		}
+		if err != nil {
+			println(err)
+		}
+	}`
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 0, []string{""})
+	for _, name := range []string{"app.tar.gz", "app.tar", "app.zip"} {
+		assertFileMatch(t, "testdata/case01/test.sh", "temp/fsarchive/out-"+name+"/test.sh")
+		assertFileMatch(t, "testdata/case01/.contxt.yml", "temp/fsarchive/out-"+name+"/.contxt.yml")
+	}
+}
+
+// the end of the archives is written by closing them. errors of closing have to be reported.
+// /dev/full fails any write, and the gzip writer keeps the small content until it is closed
+func TestFsArchivesReportCloseErrors(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full is not available")
+	}
+	os.RemoveAll("temp/fsarchive-full")
+	if err := os.MkdirAll("temp/fsarchive-full", os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"app.tar.gz", "app.zip"} {
+		if err := os.Symlink("/dev/full", filepath.Join("temp/fsarchive-full", name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := `
+	println(tarCreate("temp/fsarchive-full/app.tar.gz", "testdata/case01") != nil)
+	println(zipCreate("temp/fsarchive-full/app.zip", "testdata/case01") != nil)
+	`
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 2, []string{"true", "true"})
+}
+
+// writeTestTar writes a tar archive with the given entries. the content of files is the link name
+func writeTestTar(t *testing.T, archive string, entries []tar.Header) {
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	for _, header := range entries {
+		header := header
+		content := header.Linkname
+		if header.Typeflag == tar.TypeReg {
+			header.Linkname = ""
+			header.Size = int64(len(content))
+		}
+		header.Mode = 0644
+		if err := tw.WriteHeader(&header); err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFsTarExtractSymlinks(t *testing.T) {
+	os.RemoveAll("temp/fstarlinks")
+	outside, _ := filepath.Abs("temp/fstarlinks/outside")
+	if err := os.MkdirAll(outside, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	// the destination contains already a symlink to the outside
+	if err := os.MkdirAll("temp/fstarlinks/out-existing", os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, "temp/fstarlinks/out-existing/link"); err != nil {
+		t.Fatal(err)
+	}
+	writeTestTar(t, "temp/fstarlinks/absolute.tar", []tar.Header{
+		{Name: "a", Typeflag: tar.TypeSymlink, Linkname: outside},
+		{Name: "a/x", Typeflag: tar.TypeReg, Linkname: "evil"},
+	})
+	writeTestTar(t, "temp/fstarlinks/relative.tar", []tar.Header{
+		{Name: "sub/a", Typeflag: tar.TypeSymlink, Linkname: "../../outside"},
+		{Name: "sub/a/x", Typeflag: tar.TypeReg, Linkname: "evil"},
+	})
+	writeTestTar(t, "temp/fstarlinks/through.tar", []tar.Header{
+		{Name: "sub/", Typeflag: tar.TypeDir},
+		{Name: "a", Typeflag: tar.TypeSymlink, Linkname: "sub"},
+		{Name: "a/x", Typeflag: tar.TypeReg, Linkname: "evil"},
+	})
+	writeTestTar(t, "temp/fstarlinks/existing.tar", []tar.Header{
+		{Name: "link/x", Typeflag: tar.TypeReg, Linkname: "evil"},
+	})
+	writeTestTar(t, "temp/fstarlinks/valid.tar", []tar.Header{
+		{Name: "sub/file.txt", Typeflag: tar.TypeReg, Linkname: "hello"},
+		{Name: "ln", Typeflag: tar.TypeSymlink, Linkname: "sub/file.txt"},
+	})
+
+	cmd := `
+	for name in ["absolute", "relative", "through", "existing", "valid"] {
+		err = tarExtract("temp/fstarlinks/" + name + ".tar", "temp/fstarlinks/out-" + name)
+		println(name, err)
+	}`
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 5, []string{
+		"absolute invalid symlink in archive: a points to the absolute path " + outside,
+		"relative invalid symlink in archive: sub/a points outside of the destination",
+		"through invalid path in archive: a/x is written through the symlink temp/fstarlinks/out-through/a",
+		"existing invalid path in archive: link/x is written through the symlink temp/fstarlinks/out-existing/link",
+		"valid <nil>",
+	})
+	assertFileNotExists(t, filepath.Join(outside, "x"))
+	if content, err := os.ReadFile("temp/fstarlinks/out-valid/ln"); err != nil || string(content) != "hello" {
+		t.Error("expected the valid link to be extracted", string(content), err)
+	}
+}
+
+func TestFsMoveAndChmod(t *testing.T) {
+	os.RemoveAll("temp/fsmove")
+	os.MkdirAll("temp/fsmove", os.ModePerm)
+	if err := os.WriteFile("temp/fsmove/app", []byte("#!/bin/sh"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := `
+	err = move("temp/fsmove/app", "temp/fsmove/bin/app")
+	println(err)
+	mkdir("temp/fsmove/bin")
+	err = move("temp/fsmove/app", "temp/fsmove/bin/app")
+	if err != nil {
+		println(err)
+	}
+	err = chmod("temp/fsmove/bin/app", "0750")
+	if err != nil {
+		println(err)
+	}
+	info, _ = stat("temp/fsmove/bin/app")
+	println(info.mode)
+	err = chmod("temp/fsmove/bin/app", "rwx")
+	println(err)`
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 3, []string{
+		"rename temp/fsmove/app temp/fsmove/bin/app: no such file or directory",
+		"0750",
+		"invalid mode rwx. use an octal mode like 0755",
+	})
+	assertFileNotExists(t, "temp/fsmove/app")
+	assertFileExists(t, "temp/fsmove/bin/app")
+}
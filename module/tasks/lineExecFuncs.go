@@ -107,7 +107,7 @@ func (t *targetExecuter) GetFnAsDefaults(anko *AnkoRunner) []AnkoDefiner {
 		},
 		{"importJsonFile",
 			func(key, path string) error {
-				path = t.phHandler.HandlePlaceHolder(path)
+				path = anko.ResolvePath(t.phHandler.HandlePlaceHolder(path))
 				json, err := systools.ReadFileAsString(path)
 				if err != nil {
 					anko.ThrowException(err, fmt.Sprintf("importJsonFile('%s','%s')", key, path))
@@ -126,7 +126,7 @@ func (t *targetExecuter) GetFnAsDefaults(anko *AnkoRunner) []AnkoDefiner {
 		},
 		{"importYamlFile",
 			func(key, path string) error {
-				path = t.phHandler.HandlePlaceHolder(path)
+				path = anko.ResolvePath(t.phHandler.HandlePlaceHolder(path))
 				yaml, err := systools.ReadFileAsString(path)
 				if err != nil {
 					anko.ThrowException(err, fmt.Sprintf("importYamlFile('%s','%s')", key, path))
@@ -179,7 +179,8 @@ func (t *targetExecuter) GetFnAsDefaults(anko *AnkoRunner) []AnkoDefiner {
 				returnValue := ""
 				add := ""
 				runCmd, runArgs := t.commandFallback.GetMainCmd(configure.Options{})
-				_, cmdExit, execErr := t.ExecuteScriptLine(runCmd, runArgs, cmd, func(output string, e error) bool {
+				opts := ExecOptions{Dir: anko.GetWorkingDir(), Env: anko.GetCmdEnv()}
+				_, cmdExit, execErr := t.ExecuteScriptLineWithOptions(opts, runCmd, runArgs, cmd, func(output string, _ bool, e error) bool {
 					returnValue = returnValue + add + output
 					add = "\n"
 					if e != nil {
@@ -280,14 +281,14 @@ err = result[2]`,
 		},
 		{"varWrite",
 			func(varName, fileName string) error {
-				return t.phHandler.ExportVarToFile(varName, fileName)
+				return t.phHandler.ExportVarToFile(varName, anko.ResolvePath(fileName))
 			},
 			RISK_LEVEL_HIGH,
 			`write a variable to a file. e.g. varWrite('key','output.txt')`,
 		},
 		{"writeFile",
 			func(fileName, content string) error {
-				f, err := os.Create(fileName)
+				f, err := os.Create(anko.ResolvePath(fileName))
 				if err != nil {
 					anko.ThrowException(err, fmt.Sprintf("writeFile.create('%s','%s')", fileName, content))
 					return err
@@ -304,7 +305,7 @@ err = result[2]`,
 		},
 		{"readFile",
 			func(fileName string) (string, error) {
-				return systools.ReadFileAsString(fileName)
+				return systools.ReadFileAsString(anko.ResolvePath(fileName))
 			},
 			RISK_LEVEL_HIGH,
 			`read a file. e.g. content,err = readFile('input.txt')`,
@@ -313,8 +314,8 @@ err = result[2]`,
 			func(src, dst string) error {
 				src = t.phHandler.HandlePlaceHolder(src)
 				dst = t.phHandler.HandlePlaceHolder(dst)
-				src = filepath.FromSlash(src)
-				dst = filepath.FromSlash(dst)
+				src = anko.ResolvePath(filepath.FromSlash(src))
+				dst = anko.ResolvePath(filepath.FromSlash(dst))
 				return systools.CopyFile(src, dst)
 			},
 			RISK_LEVEL_HIGH,
@@ -335,7 +336,7 @@ err = result[2]`,
 						return true, nil
 					},
 				}
-				return cp.Copy(src, dst, opt)
+				return cp.Copy(anko.ResolvePath(src), anko.ResolvePath(dst), opt)
 			},
 			RISK_LEVEL_HIGH,
 			`copy a file. e.g. copy('input.txt','output.txt') or copy a directory copy('input','output')
@@ -357,7 +358,7 @@ this will only copy files with the given suffixes`,
 						return false, nil
 					},
 				}
-				return cp.Copy(src, dst, opt)
+				return cp.Copy(anko.ResolvePath(src), anko.ResolvePath(dst), opt)
 			},
 			RISK_LEVEL_HIGH,
 			`copy a file but skip some files depending suffix. e.g. copyButSkip('input','output','.git','.tmp')
@@ -366,7 +367,7 @@ this will copy any files except the ones with the given suffixes`,
 		{"remove",
 			func(path string) error {
 				path = t.phHandler.HandlePlaceHolder(path)
-				path = filepath.FromSlash(path)
+				path = anko.ResolvePath(filepath.FromSlash(path))
 				return os.RemoveAll(path)
 			},
 			RISK_LEVEL_HIGH,
@@ -375,7 +376,7 @@ this will copy any files except the ones with the given suffixes`,
 		{"mkdir",
 			func(path string) error {
 				path = t.phHandler.HandlePlaceHolder(path)
-				path = filepath.FromSlash(path)
+				path = anko.ResolvePath(filepath.FromSlash(path))
 				return os.MkdirAll(path, os.ModePerm)
 			},
 			RISK_LEVEL_HIGH,
@@ -410,9 +411,55 @@ this will copy any files except the ones with the given suffixes`,
 			`wait for a given time in miliseconds. e.g. waitMilis(1000)`,
 		},
 	}
+	cmdList = append(cmdList, t.getFsFnAsDefaults(anko)...)
+	cmdList = append(cmdList, t.getHttpFnAsDefaults(anko)...)
+	cmdList = append(cmdList, t.getProcFnAsDefaults(anko)...)
+	cmdList = append(cmdList, t.getDataFnAsDefaults(anko)...)
+	cmdList = append(cmdList, t.getTplFnAsDefaults(anko)...)
+	cmdList = append(cmdList, t.getPromptFnAsDefaults(anko)...)
 	return cmdList
 }
 
+// ListAnkoFunctions returns the functions, they can be used in the cmd section of a task
+func ListAnkoFunctions() []AnkoDefiner {
+	anko := NewAnkoRunner()
+	t := New("", nil)
+	return append(anko.BuiltinDefines(), t.GetFnAsDefaults(anko)...)
+}
+
+// NewAnkoRunner creates an anko runner with the functions of the cmd section.
+// the functions are using the same placeholders and data as the targets of the execution.
+// the risk level is the level of the local template.
+func (e *TaskListExec) NewAnkoRunner() *AnkoRunner {
+	return e.newAnkoRunner(e.args)
+}
+
+// NewAnkoRunnerWithData creates an anko runner like NewAnkoRunner, but the functions
+// are using the given data handler for the placeholders and data.
+func (e *TaskListExec) NewAnkoRunnerWithData(data *CombinedDh) *AnkoRunner {
+	args := []interface{}{data}
+	for _, arg := range e.args {
+		switch arg.(type) {
+		case PlaceHolder, DataMapHandler:
+			continue
+		}
+		args = append(args, arg)
+	}
+	return e.newAnkoRunner(args)
+}
+
+func (e *TaskListExec) newAnkoRunner(args []interface{}) *AnkoRunner {
+	t := New("", map[string]string{}, append(append([]interface{}{}, args...), e.config)...)
+	e.applyLogger(t)
+	anko := NewAnkoRunner()
+	anko.SetLogger(t.getLogger())
+	anko.SetModules(t.ankoModules)
+	anko.SetRiskLevel(t.riskPolicy.MaxRisk(configure.Task{}))
+	anko.EnableCancelation()
+	t.SetFunctions(anko)
+	return anko
+}
+
 func (t *targetExecuter) SetFunctions(anko *AnkoRunner) {
 	if err := anko.AddDefaultDefines(t.GetFnAsDefaults(anko)); err != nil {
 		t.out(MsgError(MsgError{Err: err, Reference: "SetFunctions()", Target: t.target}))
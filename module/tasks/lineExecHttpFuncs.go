@@ -0,0 +1,206 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// httpResponse is the response of a request, as it is returned to anko
+type httpResponse map[string]interface{}
+
+// getHttpFnAsDefaults returns the functions to call http endpoints.
+// the requests are using the context of the runner, so they are canceled together with the script.
+func (t *targetExecuter) getHttpFnAsDefaults(anko *AnkoRunner) []AnkoDefiner {
+	return []AnkoDefiner{
+		{"httpGet",
+			func(url string) (httpResponse, error) {
+				return t.httpDo(anko, http.MethodGet, url, nil, "", 0)
+			},
+			RISK_LEVEL_HIGH,
+			`send a GET request. e.g. resp,err = httpGet('https://example.com/version.json')
+the response is a map with status, headers and body. e.g. if resp.status == 200 { println(resp.body) }`,
+		},
+		{"httpPost",
+			func(url, contentType, body string) (httpResponse, error) {
+				return t.httpDo(anko, http.MethodPost, url, map[string]string{"Content-Type": contentType}, body, 0)
+			},
+			RISK_LEVEL_HIGH,
+			`send a POST request. e.g. resp,err = httpPost('https://example.com/deploy','application/json','{"env":"prod"}')
+the response is a map with status, headers and body`,
+		},
+		{"httpRequest",
+			func(options interface{}) (httpResponse, error) {
+				opts, err := ankoStringMap(options)
+				if err != nil {
+					return nil, fmt.Errorf("httpRequest needs a map of options: %w", err)
+				}
+				method, _ := opts["method"].(string)
+				if method == "" {
+					method = http.MethodGet
+				}
+				url, _ := opts["url"].(string)
+				body, _ := opts["body"].(string)
+				var headers map[string]string
+				if h, ok := opts["headers"]; ok {
+					if headers, err = ankoHeaders(h); err != nil {
+						return nil, err
+					}
+				}
+				timeout := time.Duration(0)
+				if to, ok := ankoNumber(opts["timeout"]); ok {
+					timeout = time.Duration(to) * time.Millisecond
+				}
+				return t.httpDo(anko, strings.ToUpper(method), url, headers, body, timeout)
+			},
+			RISK_LEVEL_HIGH,
+			`send a request with the given options. e.g.
+resp,err = httpRequest({"method": "PUT", "url": "https://example.com/api", "headers": {"Authorization": "Bearer ${TOKEN}"}, "body": "{}", "timeout": 5000})
+the timeout is in milliseconds. the response is a map with status, headers and body`,
+		},
+		{"httpDownload",
+			func(url, path string) error {
+				return t.httpDownload(anko, url, path)
+			},
+			RISK_LEVEL_HIGH,
+			`download a file. e.g. err = httpDownload('https://example.com/tool.tar.gz','bin/tool.tar.gz')
+any status, that is not 2xx, is an error`,
+		},
+	}
+}
+
+// httpRequest creates the request with the context of the runner.
+// placeholders are handled for the url, the headers and the body.
+func (t *targetExecuter) httpRequest(ctx context.Context, method, url string, headers map[string]string, body string) (*http.Request, error) {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(t.phHandler.HandlePlaceHolder(body))
+	}
+	req, err := http.NewRequestWithContext(ctx, method, t.phHandler.HandlePlaceHolder(url), reader)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range headers {
+		if value != "" {
+			req.Header.Set(key, t.phHandler.HandlePlaceHolder(value))
+		}
+	}
+	return req, nil
+}
+
+// httpDo sends the request and returns status, headers and body of the response.
+// a status, that is not 2xx, is not an error. the script has to check the status.
+func (t *targetExecuter) httpDo(anko *AnkoRunner, method, url string, headers map[string]string, body string, timeout time.Duration) (httpResponse, error) {
+	ctx := anko.GetContext()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	req, err := t.httpRequest(ctx, method, url, headers, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	respHeaders := map[string]interface{}{}
+	for key, values := range resp.Header {
+		respHeaders[key] = strings.Join(values, ", ")
+	}
+	return httpResponse{
+		"status":  int64(resp.StatusCode),
+		"headers": respHeaders,
+		"body":    string(content),
+	}, nil
+}
+
+// httpDownload writes the body of the response to the file
+func (t *targetExecuter) httpDownload(anko *AnkoRunner, url, path string) error {
+	req, err := t.httpRequest(anko.GetContext(), http.MethodGet, url, nil, "")
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("download of %s failed: %s", req.URL, resp.Status)
+	}
+	path = t.fsPath(anko, path)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, resp.Body)
+	return closeWriters(err, f)
+}
+
+// ankoStringMap returns a map of anko, with string keys
+func ankoStringMap(value interface{}) (map[string]interface{}, error) {
+	switch m := value.(type) {
+	case map[string]interface{}:
+		return m, nil
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(m))
+		for key, val := range m {
+			result[fmt.Sprint(key)] = val
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("expected a map, got %T", value)
+	}
+}
+
+// ankoHeaders returns the headers of an anko map
+func ankoHeaders(value interface{}) (map[string]string, error) {
+	m, err := ankoStringMap(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid headers: %w", err)
+	}
+	headers := make(map[string]string, len(m))
+	for key, val := range m {
+		headers[key] = fmt.Sprint(val)
+	}
+	return headers, nil
+}
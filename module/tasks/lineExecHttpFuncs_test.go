@@ -0,0 +1,117 @@
+package tasks_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+func newHttpTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Version", "1.2.3")
+		fmt.Fprint(w, `{"version":"1.2.3"}`)
+	})
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s %s %s %s", r.Method, r.Header.Get("Content-Type"), r.Header.Get("Authorization"), body)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	})
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHttpGetAndPost(t *testing.T) {
+	server := newHttpTestServer(t)
+	cmd := fmt.Sprintf(`
+	resp, err = httpGet("%[1]s/version")
+	if err != nil {
+		println(err)
+	}
+	println(resp.status)
+	println(resp.headers["X-Version"])
+	println(resp.body)
+	resp, err = httpPost("%[1]s/echo", "application/json", "{}")
+	println(resp.body)
+	resp, err = httpGet("%[1]s/missing")
+	println(resp.status)`, server.URL)
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 5, []string{
+		"200",
+		"1.2.3",
+		`{"version":"1.2.3"}`,
+		"POST application/json  {}",
+		"404",
+	})
+}
+
+func TestHttpRequest(t *testing.T) {
+	server := newHttpTestServer(t)
+	cmd := fmt.Sprintf(`
+	varSet("TOKEN", "secret")
+	resp, err = httpRequest({"method": "put", "url": "%[1]s/echo", "headers": {"Authorization": "Bearer ${TOKEN}"}, "body": "data"})
+	if err != nil {
+		println(err)
+	}
+	println(resp.body)
+	resp, err = httpRequest({"url": "%[1]s/slow", "timeout": 50})
+	println(err != nil)`, server.URL)
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 2, []string{"PUT  Bearer secret data", "true"})
+}
+
+func TestHttpDownload(t *testing.T) {
+	server := newHttpTestServer(t)
+	os.RemoveAll("temp/httpdownload")
+	cmd := fmt.Sprintf(`
+	err = httpDownload("%[1]s/version", "temp/httpdownload/version.json")
+	if err != nil {
+		println(err)
+	}
+	err = httpDownload("%[1]s/missing", "temp/httpdownload/missing.json")
+	println(err)`, server.URL)
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 1, []string{
+		fmt.Sprintf("download of %s/missing failed: 404 Not Found", server.URL),
+	})
+	content, err := os.ReadFile("temp/httpdownload/version.json")
+	if err != nil || string(content) != `{"version":"1.2.3"}` {
+		t.Error("unexpected download", string(content), err)
+	}
+	assertFileNotExists(t, "temp/httpdownload/missing.json")
+}
+
+func TestHttpCanceledWithScript(t *testing.T) {
+	server := newHttpTestServer(t)
+	anko := tasks.NewStdTaskListExec(configure.RunConfig{}).NewAnkoRunner()
+	anko.SetOutputSupression(true)
+	anko.SetTimeOut(100 * time.Millisecond)
+	start := time.Now()
+	_, err := anko.RunAnko(fmt.Sprintf(`resp, err = httpGet("%s/slow")
+	println(err)`, server.URL))
+	if time.Since(start) > time.Second {
+		t.Error("the request should be canceled by the timeout of the script")
+	}
+	if err == nil && !strings.Contains(strings.Join(anko.GetBuffer(), ""), "context deadline exceeded") {
+		t.Error("expected the request to fail", anko.GetBuffer())
+	}
+}
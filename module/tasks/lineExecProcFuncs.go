@@ -0,0 +1,254 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/process"
+)
+
+// ErrSpawnTimeout is returned by wait, if the process is still running after the timeout
+var ErrSpawnTimeout = errors.New("timeout while waiting for the process")
+
+// SpawnHandle is the handle of a process, they is started in background by spawn.
+type SpawnHandle struct {
+	mu       sync.Mutex
+	command  string
+	proc     *process.Process
+	osProc   *os.Process
+	started  chan struct{}
+	done     chan struct{}
+	output   []string
+	exitCode int
+	err      error
+}
+
+// Pid returns the process id, or 0 if the process could not be started
+func (h *SpawnHandle) Pid() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.osProc == nil {
+		return 0
+	}
+	return h.osProc.Pid
+}
+
+// IsRunning returns true, while the process is running
+func (h *SpawnHandle) IsRunning() bool {
+	select {
+	case <-h.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Output returns the output of the process, they is written so far
+func (h *SpawnHandle) Output() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return strings.Join(h.output, "\n")
+}
+
+// Wait waits until the process is done, and returns the exit code.
+// a timeout of 0 waits without limit.
+func (h *SpawnHandle) Wait(timeout time.Duration, cancel <-chan struct{}) (int, error) {
+	var timer <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+	select {
+	case <-h.done:
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return h.exitCode, h.err
+	case <-timer:
+		return 0, ErrSpawnTimeout
+	case <-cancel:
+		return 0, errors.New("wait canceled")
+	}
+}
+
+// Kill kills the process with all child processes, and waits until the process is done
+func (h *SpawnHandle) Kill() error {
+	if !h.IsRunning() {
+		return nil
+	}
+	// the process is started in his own process group, so the whole group is killed
+	if err := process.KillProcessTree(h.Pid()); err != nil {
+		if _, _, err := h.proc.Kill(); err != nil {
+			return err
+		}
+	}
+	select {
+	case <-h.done:
+		return nil
+	case <-time.After(5 * time.Second):
+		return fmt.Errorf("process %d is still running after kill", h.Pid())
+	}
+}
+
+// spawn starts the command in background, with the same shell they is used by exec.
+// the process is registered in the watchman of the target, so it is stopped if contxt is exiting.
+func (t *targetExecuter) spawn(anko *AnkoRunner, cmd string) (*SpawnHandle, error) {
+	cmd = t.phHandler.HandlePlaceHolder(cmd)
+	runCmd, runArgs := t.commandFallback.GetMainCmd(configure.Options{})
+	h := &SpawnHandle{
+		command: cmd,
+		proc:    process.NewProcess(runCmd, append(append([]string{}, runArgs...), cmd)...),
+		started: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	var startOnce sync.Once
+	h.proc.SetLogger(t.getLogger())
+	h.proc.SetCombinePipes(true)
+	h.proc.SetWorkingDir(anko.GetWorkingDir())
+	h.proc.SetEnv(anko.GetCmdEnv())
+	h.proc.SetOnOutput(func(msg string, err error) bool {
+		// the error of the exit code is reported as output too
+		if err == nil {
+			h.mu.Lock()
+			h.output = append(h.output, msg)
+			h.mu.Unlock()
+		}
+		return true
+	})
+	h.proc.SetOnInit(func(proc *os.Process) {
+		h.mu.Lock()
+		h.osProc = proc
+		h.mu.Unlock()
+		trackProcessGroup(proc.Pid)
+		t.trackSpawned(runCmd, runArgs, cmd, proc)
+		startOnce.Do(func() { close(h.started) })
+	})
+	go func() {
+		_, exitCode, err := h.proc.Exec()
+		if pid := h.Pid(); pid > 0 {
+			untrackProcessGroup(pid)
+		}
+		h.mu.Lock()
+		h.exitCode, h.err = exitCode, err
+		h.mu.Unlock()
+		startOnce.Do(func() { close(h.started) })
+		close(h.done)
+	}()
+	<-h.started
+	if h.Pid() == 0 {
+		<-h.done
+		return nil, h.err
+	}
+	return h, nil
+}
+
+// trackSpawned registers the process in the watchman.
+// any process gets his own entry, so they are stopped together with the processes of the tasks.
+func (t *targetExecuter) trackSpawned(runCmd string, runArgs []string, cmd string, proc *os.Process) {
+	if t.watch == nil {
+		return
+	}
+	key := fmt.Sprintf("%s:spawn:%d", t.target, proc.Pid)
+	wtask, _ := t.watch.getTaskOrCreate(key)
+	wtask.StartTrackProcess(proc)
+	wtask.LogCmd(runCmd, runArgs, cmd)
+	if err := t.watch.UpdateTask(key, wtask); err != nil {
+		t.getLogger().Error("failed to register the spawned process", key, err)
+	}
+}
+
+// getProcFnAsDefaults returns the functions to control processes, they are running in background
+func (t *targetExecuter) getProcFnAsDefaults(anko *AnkoRunner) []AnkoDefiner {
+	return []AnkoDefiner{
+		{"spawn",
+			func(cmd string) (*SpawnHandle, error) {
+				h, err := t.spawn(anko, cmd)
+				if err != nil {
+					anko.ThrowException(err, fmt.Sprintf("spawn('%s')", cmd))
+					t.out(MsgError(MsgError{Err: err, Reference: "spawn(cmd)", Target: t.target}))
+				}
+				return h, err
+			},
+			RISK_LEVEL_HIGH,
+			`start a command in background, and return the handle of the process. e.g. server,err = spawn('php -S localhost:8080')
+the process is stopped if contxt is exiting`,
+		},
+		{"wait",
+			func(h *SpawnHandle, timeout int) (int, error) {
+				if h == nil {
+					return 0, errors.New("wait needs the handle of a spawned process")
+				}
+				return h.Wait(time.Duration(timeout)*time.Millisecond, anko.GetContext().Done())
+			},
+			RISK_LEVEL_LOW,
+			`wait until the spawned process is done. e.g. exitCode,err = wait(server, 5000)
+the timeout is in milliseconds. 0 waits without limit`,
+		},
+		{"kill",
+			func(h *SpawnHandle) error {
+				if h == nil {
+					return errors.New("kill needs the handle of a spawned process")
+				}
+				return h.Kill()
+			},
+			RISK_LEVEL_HIGH,
+			`kill the spawned process and all child processes. e.g. kill(server)`,
+		},
+		{"isRunning",
+			func(h *SpawnHandle) bool {
+				return h != nil && h.IsRunning()
+			},
+			RISK_LEVEL_LOW,
+			`check if the spawned process is still running. e.g. if isRunning(server) { ... }`,
+		},
+		{"output",
+			func(h *SpawnHandle) string {
+				if h == nil {
+					return ""
+				}
+				return h.Output()
+			},
+			RISK_LEVEL_LOW,
+			`get the output of the spawned process, they is written so far. e.g. println(output(server))`,
+		},
+		{"pid",
+			func(h *SpawnHandle) int {
+				if h == nil {
+					return 0
+				}
+				return h.Pid()
+			},
+			RISK_LEVEL_LOW,
+			`get the process id of the spawned process. e.g. println(pid(server))`,
+		},
+	}
+}
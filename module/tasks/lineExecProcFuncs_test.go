@@ -0,0 +1,90 @@
+package tasks_test
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+func TestSpawnAndWait(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test uses sh commands")
+	}
+	cmd := `
+	h, err = spawn("echo started; sleep 0.1; echo done; exit 3")
+	if err != nil {
+		println(err)
+	}
+	println(pid(h) > 0)
+	code, err = wait(h, 5000)
+	println(code)
+	println(isRunning(h))
+	println(output(h))`
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 5, []string{"true", "3", "false", "started", "done"})
+}
+
+func TestSpawnKill(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test uses sh commands")
+	}
+	cmd := `
+	h, err = spawn("sleep 10")
+	println(isRunning(h))
+	_, err = wait(h, 50)
+	println(err)
+	err = kill(h)
+	if err != nil {
+		println(err)
+	}
+	println(isRunning(h))`
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 3, []string{"true", "timeout while waiting for the process", "false"})
+}
+
+func TestSpawnStoppedByWatchman(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test uses sh commands")
+	}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{ID: "server", Cmd: []string{`h, _ = spawn("sleep 10")`, `println(pid(h))`}},
+		},
+	}
+	watch := tasks.NewWatchman()
+	pids := []string{}
+	outHandler := func(msg ...interface{}) {
+		for _, m := range msg {
+			if s, ok := m.(tasks.MsgExecOutput); ok {
+				pids = append(pids, s.Output)
+			}
+		}
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, req, watch)
+	if code := tsk.RunTarget("server", false); code != systools.ExitOk {
+		t.Fatal("unexpected exit code", code)
+	}
+	running := []string{}
+	for _, key := range watch.ListTasks() {
+		if strings.HasPrefix(key, "server:spawn:") {
+			running = append(running, key)
+		}
+	}
+	if len(running) != 1 || len(pids) != 1 || running[0] != "server:spawn:"+pids[0] {
+		t.Fatal("expected the spawned process in the watchman", running, pids)
+	}
+	if task, _ := watch.GetTask(running[0]); !task.IsProcessRunning() {
+		t.Error("the spawned process should be running")
+	}
+	watch.StopAllTasks(nil)
+	if task, _ := watch.GetTask(running[0]); task.IsProcessRunning() {
+		t.Error("the spawned process should be stopped by the watchman")
+	}
+}
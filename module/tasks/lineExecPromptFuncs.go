@@ -0,0 +1,123 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrNotInteractive is returned by the prompt functions, if the user can not be asked
+var ErrNotInteractive = errors.New("can not ask the user in non-interactive mode")
+
+// getPromptFnAsDefaults returns the functions to ask the user.
+// they are using the vm function format of anko, so in non-interactive runs the script stops
+// at the first question, instead of going on with an empty answer.
+func (t *targetExecuter) getPromptFnAsDefaults(anko *AnkoRunner) []AnkoDefiner {
+	return []AnkoDefiner{
+		{"ask",
+			func(ctx context.Context, question, defaultValue reflect.Value) (reflect.Value, reflect.Value) {
+				q := fmt.Sprint(ankoValue(question))
+				if err := t.canAsk(q); err != nil {
+					return promptResult(nil, err)
+				}
+				value, err := t.prompter.Input(q, fmt.Sprint(ankoValue(defaultValue)))
+				return promptResult(value, err)
+			},
+			RISK_LEVEL_LOW,
+			`ask the user for a value. e.g. name = ask('name of the service', 'web')
+in non-interactive runs, like in a CI, the script stops with an error`,
+		},
+		{"confirm",
+			func(ctx context.Context, question reflect.Value) (reflect.Value, reflect.Value) {
+				q := fmt.Sprint(ankoValue(question))
+				if err := t.canAsk(q); err != nil {
+					return promptResult(nil, err)
+				}
+				value, err := t.prompter.Select(q, []string{"yes", "no"})
+				return promptResult(value == "yes", err)
+			},
+			RISK_LEVEL_LOW,
+			`ask the user for yes or no. e.g. if confirm('deploy to production?') { ... }
+in non-interactive runs, like in a CI, the script stops with an error`,
+		},
+		{"choose",
+			func(ctx context.Context, question, options reflect.Value) (reflect.Value, reflect.Value) {
+				q := fmt.Sprint(ankoValue(question))
+				choices, err := promptChoices(ankoValue(options))
+				if err != nil {
+					return promptResult(nil, fmt.Errorf("choose('%s'): %w", q, err))
+				}
+				if err := t.canAsk(q); err != nil {
+					return promptResult(nil, err)
+				}
+				value, err := t.prompter.Select(q, choices)
+				return promptResult(value, err)
+			},
+			RISK_LEVEL_LOW,
+			`ask the user to select one of the options. e.g. env = choose('environment', ['dev','stage','prod'])
+in non-interactive runs, like in a CI, the script stops with an error`,
+		},
+	}
+}
+
+// canAsk returns an error, if there is no prompter, or the prompter is not interactive
+func (t *targetExecuter) canAsk(question string) error {
+	if t.prompter == nil || !t.prompter.IsInteractive() {
+		return fmt.Errorf("%w: %s", ErrNotInteractive, question)
+	}
+	return nil
+}
+
+// promptChoices returns the options of an anko array as strings
+func promptChoices(options interface{}) ([]string, error) {
+	var choices []string
+	switch opts := options.(type) {
+	case []string:
+		choices = opts
+	case []interface{}:
+		for _, opt := range opts {
+			choices = append(choices, fmt.Sprint(opt))
+		}
+	default:
+		return nil, fmt.Errorf("expected an array of options, got %T", options)
+	}
+	if len(choices) < 1 {
+		return nil, errors.New("there are no options to choose from")
+	}
+	return choices, nil
+}
+
+// promptResult returns the value in the vm function format
+func promptResult(value interface{}, err error) (reflect.Value, reflect.Value) {
+	errValue := reflect.New(errorType).Elem()
+	if err != nil {
+		errValue.Set(reflect.ValueOf(err))
+		return reflect.Value{}, errValue
+	}
+	return reflect.ValueOf(value), errValue
+}
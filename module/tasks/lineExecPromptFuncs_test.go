@@ -0,0 +1,95 @@
+package tasks_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+func runAskScript(t *testing.T, cmd string, prompter *testPrompter) (int, []string, []string) {
+	t.Helper()
+	messages := []string{}
+	errors := []string{}
+	outHandler := func(msg ...interface{}) {
+		for _, m := range msg {
+			switch s := m.(type) {
+			case tasks.MsgExecOutput:
+				messages = append(messages, strings.TrimSpace(s.Output))
+			case tasks.MsgError:
+				errors = append(errors, s.Err.Error())
+			case tasks.MsgErrDebug:
+				errors = append(errors, s.Err.Error())
+			}
+		}
+	}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID:  "ask",
+				Cmd: []string{cmd},
+			},
+		},
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	args := []interface{}{dmc, outHandler, tasks.ShellCmd, req}
+	if prompter != nil {
+		args = append(args, prompter)
+	}
+	tsk := tasks.NewTaskListExec(runCfg, args...)
+	tsk.SetHardExistToAllTasks(false)
+	code := tsk.RunTarget("ask", false)
+	return code, messages, errors
+}
+
+func TestAnkoPromptFunctions(t *testing.T) {
+	cmd := `
+	name = ask("name of the service", "web")
+	println(name)
+	if confirm("deploy?") {
+		println("deploy")
+	}
+	env = choose("environment", ["dev", "stage", "prod"])
+	println(env)`
+
+	prompter := &testPrompter{interactive: true, answers: []string{"api", "yes", "stage"}}
+	code, messages, errors := runAskScript(t, cmd, prompter)
+	assertIntEqual(t, systools.ExitOk, code)
+	assertIntEqual(t, 0, len(errors))
+	assertIntEqual(t, 3, len(messages))
+	assertSliceContains(t, messages, "api")
+	assertSliceContains(t, messages, "deploy")
+	assertSliceContains(t, messages, "stage")
+	assertIntEqual(t, 3, len(prompter.asked))
+}
+
+func TestAnkoPromptNonInteractiveStops(t *testing.T) {
+	cmd := `
+	println("before")
+	name = ask("name of the service", "web")
+	println("after")`
+
+	for _, prompter := range []*testPrompter{{interactive: false}, nil} {
+		code, messages, errors := runAskScript(t, cmd, prompter)
+		assertIntEqual(t, systools.ExitCmdError, code)
+		assertSliceContains(t, messages, "before")
+		assertSliceNotContains(t, messages, "after")
+		if len(errors) == 0 {
+			t.Error("expected an error for the question in non-interactive mode")
+		}
+	}
+}
+
+func TestAnkoChooseWithoutOptions(t *testing.T) {
+	prompter := &testPrompter{interactive: true, answers: []string{"dev"}}
+	code, _, errors := runAskScript(t, `env = choose("environment", [])`, prompter)
+	assertIntEqual(t, systools.ExitCmdError, code)
+	assertIntEqual(t, 0, len(prompter.asked))
+	if len(errors) == 0 {
+		t.Error("expected an error for choose without options")
+	}
+}
@@ -0,0 +1,110 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/swaros/contxt/module/ctemplate"
+)
+
+// getTplFnAsDefaults returns the functions to render go/templates.
+// the data of the templates are the placeholders and the imported maps.
+func (t *targetExecuter) getTplFnAsDefaults(anko *AnkoRunner) []AnkoDefiner {
+	return []AnkoDefiner{
+		{"render",
+			func(template string) (string, error) {
+				rendered, err := t.renderTemplate(template)
+				return rendered, t.renderError(anko, "render", err, template)
+			},
+			RISK_LEVEL_LOW,
+			`render a go/template with the placeholders and the imported data. e.g. out,err = render('image: {{ .compose.services.web.image }}')
+placeholders are on the root level. e.g. render('{{ .version | upper }}'). the sprig functions can be used too`,
+		},
+		{"renderFile",
+			func(src, dst string) error {
+				return t.renderError(anko, "renderFile", t.renderFile(t.fsPath(anko, src), t.fsPath(anko, dst)), src, dst)
+			},
+			RISK_LEVEL_HIGH,
+			`render a go/template file and write the result to a file. e.g. renderFile('templates/config.tpl','config.yml')
+the file mode of the template is kept`,
+		},
+	}
+}
+
+// templateData returns the data for rendering templates.
+// imported maps are using the key, and overwrite placeholders with the same name.
+func (t *targetExecuter) templateData() map[string]interface{} {
+	data := make(map[string]interface{})
+	if t.phHandler != nil {
+		t.phHandler.GetPlaceHoldersFnc(func(phKey, phValue string) {
+			data[phKey] = phValue
+		})
+	}
+	if dh, ok := t.dataHandler.(DataPathHandler); ok {
+		for _, key := range dh.GetDataMapKeys() {
+			if value, err := dh.GetValueByPath(key, ""); err == nil {
+				data[key] = normalizeDataValue(value)
+			}
+		}
+	}
+	return data
+}
+
+// renderTemplate renders the template with the data of the task
+func (t *targetExecuter) renderTemplate(template string) (string, error) {
+	tpl := ctemplate.NewCtxTemplate()
+	tpl.SetLogger(t.getLogger())
+	tpl.SetData(t.templateData())
+	return tpl.ParseTemplateString(template)
+}
+
+// renderFile renders the template file src and writes the result to dst
+func (t *targetExecuter) renderFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	rendered, err := t.renderTemplate(string(content))
+	if err != nil {
+		return fmt.Errorf("failed to render %s: %w", src, err)
+	}
+	return os.WriteFile(dst, []byte(rendered), info.Mode().Perm())
+}
+
+// renderError reports the error of a render function
+func (t *targetExecuter) renderError(anko *AnkoRunner, fn string, err error, args ...string) error {
+	if err != nil {
+		anko.ThrowException(err, fmt.Sprintf("%s('%s')", fn, strings.Join(args, "','")))
+		t.out(MsgError(MsgError{Err: err, Reference: fn, Target: t.target}))
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package tasks_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/swaros/contxt/module/systools"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	cmd := `
+	varSet("VERSION", "1.2.3")
+	importYamlFile("compose", "testdata/data/compose.yml")
+	out, err = render("version {{ .VERSION }}")
+	println(out)
+	out, err = render("{{ .compose.services.web.image | upper }}")
+	println(out)
+	out, err = render("{{ .compose.services.web.replicas }} replicas")
+	println(out)`
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 3, []string{
+		"version 1.2.3",
+		"NGINX:1.24",
+		"2 replicas",
+	})
+}
+
+func TestRenderTemplateError(t *testing.T) {
+	cmd := `
+	_, err = render("{{ .VERSION ")
+	println(err != nil)`
+
+	AnkoTestRunHelperWithErrors(t, cmd, true, systools.ExitCmdError, 2, []string{
+		"true",
+		"Error in script: template: contxt-functions:1: unclosed action errType: *errors.errorString",
+	})
+}
+
+func TestRenderFile(t *testing.T) {
+	os.MkdirAll("temp/render", os.ModePerm)
+	cmd := `
+	varSet("SERVICE", "web")
+	importYamlFile("compose", "testdata/data/compose.yml")
+	err = renderFile("testdata/templates/config.tpl", "temp/render/config.yml")
+	if err != nil {
+		println(err)
+	}`
+
+	AnkoTestRunHelper(t, cmd, systools.ExitOk, 0, []string{""})
+	rendered, err := os.ReadFile("temp/render/config.yml")
+	assertNoError(t, err)
+	assertStringEqual(t, "service: web\nimage: nginx:1.24\nreplicas: 2\n", string(rendered))
+}
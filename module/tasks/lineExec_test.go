@@ -0,0 +1,74 @@
+package tasks_test
+
+import (
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+func TestExecuteWithOptions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands are written for bash")
+	}
+	dir := t.TempDir()
+	noStart := func(*os.Process) {}
+
+	// dir and env
+	lines := []string{}
+	code, _, err := tasks.ExecuteWithOptions(
+		tasks.ExecOptions{Dir: dir, Env: []string{"CTX_EXEC_TEST=hello"}},
+		"bash", []string{"-c"}, "pwd; echo $CTX_EXEC_TEST; echo err >&2",
+		func(line string, stderr bool, err error) bool {
+			if stderr {
+				t.Errorf("expected stderr is combined with stdout. got %s", line)
+			}
+			lines = append(lines, line)
+			return true
+		}, noStart)
+	assertNoError(t, err)
+	assertIntEqual(t, systools.ExitOk, code)
+	assertSliceContains(t, lines, dir)
+	assertSliceContains(t, lines, "hello")
+	assertSliceContains(t, lines, "err") // stderr is combined with stdout
+
+	// stderr is read separately, if it is enabled
+	stderrLines := []string{}
+	stdoutLines := []string{}
+	_, _, err = tasks.ExecuteWithOptions(
+		tasks.ExecOptions{SeparateStderr: true},
+		"bash", []string{"-c"}, "echo out; echo err >&2",
+		func(line string, stderr bool, err error) bool {
+			if stderr {
+				stderrLines = append(stderrLines, line)
+			} else {
+				stdoutLines = append(stdoutLines, line)
+			}
+			return true
+		}, noStart)
+	assertNoError(t, err)
+	assertSliceContains(t, stdoutLines, "out")
+	assertSliceContains(t, stderrLines, "err")
+	assertSliceNotContains(t, stdoutLines, "err")
+
+	// the command is stopped, if the callback returns false
+	lines = []string{}
+	code, _, _ = tasks.ExecuteWithOptions(tasks.ExecOptions{}, "bash", []string{"-c"}, "echo stop; sleep 10; echo never",
+		func(line string, _ bool, err error) bool {
+			lines = append(lines, line)
+			return line != "stop"
+		}, noStart)
+	assertIntEqual(t, systools.ExitByStopReason, code)
+	assertSliceNotContains(t, lines, "never")
+
+	// the exit code of the command
+	code, realCode, err := tasks.ExecuteWithOptions(tasks.ExecOptions{}, "bash", []string{"-c"}, "exit 3",
+		func(line string, _ bool, err error) bool { return true }, noStart)
+	assertIntEqual(t, systools.ExitCmdError, code)
+	assertIntEqual(t, 3, realCode)
+	if err == nil {
+		t.Error("expected an error for the exit code")
+	}
+}
@@ -0,0 +1,183 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/systools"
+)
+
+const (
+	// RestartWaitTicks is how often we check, if a stopped target is done, before it is started again
+	RestartWaitTicks = 100
+	// RestartTick is the time between the checks, if a stopped target is done
+	RestartTick = 100 * time.Millisecond
+)
+
+// triggerCaptureKey returns the name of the variable, that contains a group of the trigger match.
+// the same name is used for the declarative actions, the script and the target of the action
+func triggerCaptureKey(taskID, group string) string {
+	return "RUN." + taskID + ".TRIGGER." + group
+}
+
+// runDeclarativeActions executes the actions they do not need a target or a script.
+// the captures of the trigger can be used in the values as ${RUN.<task-id>.TRIGGER.<group>}.
+// returns true if at least one action is defined
+func (t *targetExecuter) runDeclarativeActions(action configure.Action, currentTask *configure.Task, captures map[string]string) bool {
+	scope := make(map[string]string)
+	for key, value := range t.taskScope(*currentTask) {
+		scope[key] = value
+	}
+	for name, value := range captures {
+		scope[triggerCaptureKey(currentTask.ID, name)] = value
+	}
+	resolve := func(value string) string {
+		if t.phHandler == nil {
+			return value
+		}
+		return t.phHandler.HandlePlaceHolderWithScope(value, scope)
+	}
+
+	triggered := false
+	for name, value := range action.SetVar {
+		triggered = true
+		t.setPh(name, resolve(value))
+	}
+
+	if action.Message != "" {
+		triggered = true
+		t.out(MsgTarget{Target: currentTask.ID, Context: "trigger_message", Info: resolve(action.Message)})
+	}
+
+	if action.AppendToFile.File != "" {
+		triggered = true
+		fileName := resolvePath(t.resolveWorkingDir(currentTask), resolve(action.AppendToFile.File))
+		if err := appendLine(fileName, resolve(action.AppendToFile.Line)); err != nil {
+			t.getLogger().Error("can not append to file", fileName, err)
+			t.out(MsgError(MsgError{Err: err, Reference: fileName, Target: currentTask.ID}))
+		}
+	}
+
+	if action.Stop != "" {
+		triggered = true
+		if target, ok := t.verifiedKeyname(resolve(action.Stop)); ok {
+			t.stopTarget(target)
+		} else {
+			t.out(MsgError(MsgError{Err: fmt.Errorf("invalid target to stop: %s", action.Stop), Target: currentTask.ID}))
+		}
+	}
+
+	if action.Restart != "" {
+		triggered = true
+		if target, ok := t.verifiedKeyname(resolve(action.Restart)); ok {
+			t.restartTarget(target)
+		} else {
+			t.out(MsgError(MsgError{Err: fmt.Errorf("invalid target to restart: %s", action.Restart), Target: currentTask.ID}))
+		}
+	}
+	return triggered
+}
+
+// appendLine appends the line to the file. the file is created if it not exists
+func appendLine(fileName, line string) error {
+	file, err := os.OpenFile(fileName, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.WriteString(line + "\n")
+	return err
+}
+
+// stopTarget stops the task runners and the running command of the target.
+// the listener is called from the output of a command, so we do not wait here
+// for the command to stop. this happens in the background, and errors are reported as output
+func (t *targetExecuter) stopTarget(target string) {
+	target = ResolveTargetAlias(t.runCfg, target)
+	t.out(MsgTarget{Target: target, Context: "trigger_stop", Info: "stop triggered by listener"})
+	t.runPendingAction(func() {
+		if err := t.stopAndWait(target); err != nil {
+			t.out(MsgError(MsgError{Err: err, Target: target}))
+		}
+	})
+}
+
+// restartTarget stops the target and starts it again, if it is done.
+// this happens in the background, so a task can also restart itself
+func (t *targetExecuter) restartTarget(target string) {
+	target = ResolveTargetAlias(t.runCfg, target)
+	t.out(MsgTarget{Target: target, Context: "trigger_stop", Info: "stop triggered by listener"})
+	t.runPendingAction(func() {
+		if err := t.stopAndWait(target); err != nil {
+			t.out(MsgError(MsgError{Err: fmt.Errorf("%w. restart canceled", err), Target: target}))
+			return
+		}
+		for tick := 0; t.watch.TaskRunning(target); tick++ {
+			if tick >= RestartWaitTicks {
+				t.out(MsgError(MsgError{Err: fmt.Errorf("target %s is not stopped. restart canceled", target), Target: target}))
+				return
+			}
+			time.Sleep(RestartTick)
+		}
+		if t.isStoppedByInterrupt() {
+			return
+		}
+		t.out(MsgTarget{Target: target, Context: "trigger_restart", Info: "restart triggered by listener"})
+		if code := t.executeTemplate(true, target, make(map[string]string)); code != systools.ExitOk {
+			t.out(MsgError(MsgError{Err: fmt.Errorf("restarted target %s exits with code %d", target, code), Target: target}))
+		}
+	})
+}
+
+// stopAndWait stops the task runners and the running command of the target,
+// and waits until the command is stopped
+func (t *targetExecuter) stopAndWait(target string) error {
+	for _, task := range *t.getTargetTasks(target) {
+		t.StopAndRemoveTaskRunner(task)
+	}
+	if done, _ := t.watch.WaitForStopProcess(target, RestartTick, 10); !done {
+		return fmt.Errorf("target %s is still running after stop", target)
+	}
+	return nil
+}
+
+// runPendingAction runs the action in the background.
+// the execution of the target waits for these actions, before it returns
+func (t *targetExecuter) runPendingAction(action func()) {
+	t.pendingActions.Add(1)
+	go func() {
+		defer t.pendingActions.Done()
+		action()
+	}()
+}
+
+// waitForPendingActions waits until all stop and restart actions are done
+func (t *targetExecuter) waitForPendingActions() {
+	t.pendingActions.Wait()
+}
@@ -0,0 +1,171 @@
+package tasks_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+func TestDeclarativeListenerActions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	dir := t.TempDir()
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID:      "deploy",
+				Options: configure.Options{WorkingDir: dir},
+				Script:  []string{"echo 'deployed version 1.4.2'", "echo 'result ${DEPLOYED}'"},
+				Listener: []configure.Listener{
+					{
+						Trigger: configure.Trigger{OnoutRegex: []string{`version (\S+)`}},
+						Action: configure.Action{
+							SetVar:       map[string]string{"DEPLOYED": "v${RUN.deploy.TRIGGER.1}"},
+							Message:      "found version ${RUN.deploy.TRIGGER.1}",
+							AppendToFile: configure.FileAppend{File: "deploy.log", Line: "${RUN.deploy.TRIGGER.0}"},
+							Script:       []string{"echo 'script ${RUN.deploy.TRIGGER.1} ${DEPLOYED}'"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var mu sync.Mutex
+	outputs := []string{}
+	messages := []string{}
+	outHandler := func(msg ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range msg {
+			switch tm := m.(type) {
+			case tasks.MsgExecOutput:
+				outputs = append(outputs, tm.Output)
+			case tasks.MsgTarget:
+				if tm.Context == "trigger_message" {
+					messages = append(messages, tm.Info)
+				}
+			}
+		}
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, req, tasks.NewWatchman())
+	tsk.SetHardExistToAllTasks(false)
+	assertIntEqual(t, systools.ExitOk, tsk.RunTarget("deploy", false))
+
+	assertSliceContains(t, outputs, "result v1.4.2")
+	// the script of the same action uses the same names, and the variables of the declarative actions
+	assertSliceContains(t, outputs, "script 1.4.2 v1.4.2")
+	assertSliceContains(t, messages, "found version 1.4.2")
+	content, err := os.ReadFile(filepath.Join(dir, "deploy.log"))
+	assertNoError(t, err)
+	assertStringEqual(t, "version 1.4.2\n", string(content))
+}
+
+func TestListenerActionStop(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{ID: "main", Needs: []string{"server", "watcher"}, Script: []string{"echo main done"}},
+			{ID: "server", Options: configure.Options{IgnoreCmdError: true}, Script: []string{"sleep 20"}},
+			{
+				ID:     "watcher",
+				Script: []string{"sleep 0.5", "echo 'config changed'"},
+				Listener: []configure.Listener{
+					{Trigger: configure.Trigger{OnoutContains: []string{"config changed"}}, Action: configure.Action{Stop: "server"}},
+				},
+			},
+		},
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, func(msg ...interface{}) {}, tasks.ShellCmd, req, tasks.NewWatchman())
+	tsk.SetHardExistToAllTasks(false)
+
+	start := time.Now()
+	tsk.RunTarget("main", true)
+	if time.Since(start) > 10*time.Second {
+		t.Errorf("expected the server is stopped by the listener. the run took %v", time.Since(start))
+	}
+}
+
+func TestListenerActionRestart(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	dir := t.TempDir()
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				// fails on the first run, and is restarted by itself
+				ID:      "flaky",
+				Options: configure.Options{WorkingDir: dir},
+				Script:  []string{"echo run >> runs.log; test $(wc -l < runs.log) -ge 2"},
+				Listener: []configure.Listener{
+					{Trigger: configure.Trigger{OnExitCode: []string{"1"}}, Action: configure.Action{Restart: "flaky"}},
+				},
+			},
+		},
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, func(msg ...interface{}) {}, tasks.ShellCmd, req, tasks.NewWatchman())
+	tsk.SetHardExistToAllTasks(false)
+	assertIntEqual(t, systools.ExitCmdError, tsk.RunTarget("flaky", false))
+
+	// the restart is running in the background, but the run waits for it
+	content, err := os.ReadFile(filepath.Join(dir, "runs.log"))
+	assertNoError(t, err)
+	assertStringEqual(t, "run\nrun\n", string(content))
+}
+
+func TestListenerActionRestartReportsErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID:     "deploy",
+				Script: []string{"exit 1"},
+				Listener: []configure.Listener{
+					{Trigger: configure.Trigger{OnExitCode: []string{"1"}}, Action: configure.Action{Restart: "broken"}},
+				},
+			},
+			{ID: "broken", Script: []string{"exit 2"}},
+		},
+	}
+	var mu sync.Mutex
+	errs := []string{}
+	outHandler := func(msg ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range msg {
+			if e, ok := m.(tasks.MsgError); ok && e.Err != nil {
+				errs = append(errs, e.Err.Error())
+			}
+		}
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, req, tasks.NewWatchman())
+	tsk.SetHardExistToAllTasks(false)
+	assertIntEqual(t, systools.ExitCmdError, tsk.RunTarget("deploy", false))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assertSliceContains(t, errs, fmt.Sprintf("restarted target broken exits with code %d", systools.ExitCmdError))
+}
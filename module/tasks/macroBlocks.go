@@ -0,0 +1,246 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/linehack"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/tidwall/gjson"
+)
+
+// macroBlock is a block of a script, opened by one of the #@if macros or #@foreach,
+// and closed by #@end
+type macroBlock struct {
+	mark         string       // the macro they opened the block
+	line         int          // the line of the script, where the block is opened
+	active       bool         // the lines of the current branch are executed
+	parentActive bool         // the block itself is in a branch, they is executed
+	matched      bool         // one of the branches was already active, so any #@else is ignored
+	hasElse      bool         // the #@else branch is reached
+	iteration    gjson.Result // the data of #@foreach
+	lines        []string     // the lines of #@foreach, added by #@-
+}
+
+// macroBlocks is the stack of the open blocks. the last one is the innermost block
+type macroBlocks []*macroBlock
+
+func (b *macroBlocks) push(block *macroBlock) {
+	*b = append(*b, block)
+}
+
+func (b *macroBlocks) pop() *macroBlock {
+	block := b.top()
+	if block != nil {
+		*b = (*b)[:len(*b)-1]
+	}
+	return block
+}
+
+func (b macroBlocks) top() *macroBlock {
+	if len(b) == 0 {
+		return nil
+	}
+	return b[len(b)-1]
+}
+
+// active returns true, if the lines in the current branch are executed
+func (b macroBlocks) active() bool {
+	if block := b.top(); block != nil {
+		return block.active
+	}
+	return true
+}
+
+// iteration returns the innermost #@foreach block
+func (b macroBlocks) iteration() *macroBlock {
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i].mark == iterateMark {
+			return b[i]
+		}
+	}
+	return nil
+}
+
+// conditionBlock returns the innermost block, if it is an #@if block, they accept an #@else branch
+func (b macroBlocks) conditionBlock(mark string, line int) (*macroBlock, error) {
+	block := b.top()
+	if block == nil || block.mark == iterateMark {
+		return nil, fmt.Errorf("invalid usage %s in line %d. there is no #@if to continue", mark, line)
+	}
+	if block.hasElse {
+		return nil, fmt.Errorf("invalid usage %s in line %d. the #@if in line %d has already an #@else", mark, line, block.line)
+	}
+	return block, nil
+}
+
+// checkMacroBlocks checks if the #@if, #@else, #@foreach and #@end macros of the script are balanced.
+// this is done before any line of the script is executed, so a broken script has no side effects.
+// the lines of a #@foreach, added by #@-, are checked as their own script.
+// it returns the line they is invalid, and the error
+func checkMacroBlocks(script []string) (string, error) {
+	var blocks macroBlocks
+	for index, line := range script {
+		lineNr := index + 1
+		if len(line) <= len(startMark) || line[0:len(startMark)] != startMark {
+			continue
+		}
+		parts := systools.SplitQuoted(line, inlineCmdSep)
+		if len(parts) < 1 {
+			continue
+		}
+		switch parts[0] {
+		case ifMark, equalsMark, notEqualsMark, osCheck:
+			if err := staticConditionUsage(parts[0], parts, line); err != nil {
+				return line, err
+			}
+			blocks.push(&macroBlock{mark: parts[0], line: lineNr})
+
+		case iterateMark:
+			blocks.push(&macroBlock{mark: parts[0], line: lineNr})
+
+		case elseIfMark, elseIfEquals, elseIfNotEquals, elseIfOs:
+			if _, err := blocks.conditionBlock(parts[0], lineNr); err != nil {
+				return line, err
+			}
+			if err := staticConditionUsage(strings.Replace(parts[0], elseIfMark, ifMark, 1), parts, line); err != nil {
+				return line, err
+			}
+
+		case elseMark:
+			block, err := blocks.conditionBlock(parts[0], lineNr)
+			if err != nil {
+				return line, err
+			}
+			block.hasElse = true
+
+		case inlineMark:
+			if iteration := blocks.iteration(); iteration != nil {
+				iteration.lines = append(iteration.lines, strings.Replace(line, inlineMark+" ", "", 4))
+			}
+
+		case endMark:
+			block := blocks.pop()
+			if block == nil {
+				return line, fmt.Errorf("invalid usage %s in line %d. there is no #@if or #@foreach to close", endMark, lineNr)
+			}
+			if block.mark == iterateMark {
+				if subLine, err := checkMacroBlocks(block.lines); err != nil {
+					return subLine, fmt.Errorf("%s in line %d: %w", iterateMark, block.line, err)
+				}
+			}
+		}
+	}
+	if block := blocks.top(); block != nil {
+		return script[block.line-1], fmt.Errorf("invalid usage %s in line %d. the block is not closed by %s", block.mark, block.line, endMark)
+	}
+	return "", nil
+}
+
+// staticConditionUsage checks the arguments of a condition before the script runs.
+// lines with placeholders are skipped, because the arguments are known after they are replaced.
+func staticConditionUsage(mark string, parts []string, line string) error {
+	if strings.Contains(line, "${") {
+		return nil
+	}
+	return macroConditionUsage(mark, parts, line)
+}
+
+// isBlockMacro returns true for the macros, they are changing the blocks.
+// they have to be handled also in branches, they are not executed
+func isBlockMacro(mark string) bool {
+	switch mark {
+	case ifMark, equalsMark, notEqualsMark, osCheck,
+		elseMark, elseIfMark, elseIfEquals, elseIfNotEquals, elseIfOs,
+		iterateMark, inlineMark, endMark:
+		return true
+	}
+	return false
+}
+
+// macroCondition checks the condition of an #@if or #@elseif macro.
+// the mark is the #@if macro, also for #@elseif, so the usage is the same.
+func (t *targetExecuter) macroCondition(mark string, parts []string, line string) (bool, error) {
+	if err := macroConditionUsage(mark, parts, line); err != nil {
+		return false, err
+	}
+	switch mark {
+	case osCheck:
+		return parts[1] == configure.GetOs(), nil
+	case equalsMark, notEqualsMark:
+		if mark == notEqualsMark {
+			return parts[1] != parts[2], nil
+		}
+		return parts[1] == parts[2], nil
+	case ifMark:
+		return t.macroExpression(strings.TrimSpace(line[len(parts[0]):]))
+	}
+	return false, errors.New("unknown condition " + parts[0])
+}
+
+// macroConditionUsage checks the arguments of an #@if or #@elseif macro.
+// the mark is the #@if macro, also for #@elseif, so the usage is the same.
+func macroConditionUsage(mark string, parts []string, line string) error {
+	switch mark {
+	case osCheck:
+		if len(parts) != 2 {
+			return errors.New("invalid usage " + parts[0] + " need: os")
+		}
+	case equalsMark, notEqualsMark:
+		if len(parts) != 3 {
+			return errors.New("invalid usage " + parts[0] + " need: str1 str2")
+		}
+	case ifMark:
+		if strings.TrimSpace(line[len(parts[0]):]) == "" {
+			return errors.New("invalid usage " + parts[0] + " need: expression")
+		}
+	}
+	return nil
+}
+
+// macroExpression checks an expression like $VERSION == "1.0" && $OS != "windows".
+// variables with $ are the placeholders.
+func (t *targetExecuter) macroExpression(expression string) (bool, error) {
+	var varErr error
+	parser := linehack.NewParser()
+	parser.SetVariableRequester(func(name string) (interface{}, error) {
+		if value, exists := t.phHandler.GetPHExists(name); exists {
+			return value, nil
+		}
+		if varErr == nil {
+			varErr = fmt.Errorf("variable %s is not defined in %s", name, expression)
+		}
+		return "", varErr
+	})
+	result, err := parser.Condition(expression)
+	if err != nil {
+		return false, fmt.Errorf("invalid expression %s: %w", expression, err)
+	}
+	return result, varErr
+}
@@ -28,6 +28,16 @@ import (
 
 func (t *targetExecuter) out(msg ...interface{}) {
 	if t.outputHandler != nil {
+		// the output of grouped targets is printed later
+		buffered, grouping := t.bufferOutput(msg...)
+		if buffered {
+			return
+		}
+		// only while targets are grouped, a group can be printed at the same time
+		if grouping {
+			t.outGroups.flushMu.Lock()
+			defer t.outGroups.flushMu.Unlock()
+		}
 		t.outputHandler(msg...)
 	}
 }
@@ -54,15 +64,28 @@ func (t *targetExecuter) setPh(name, value string) {
 	}
 }
 
-func (t *targetExecuter) checkReason(reason configure.Trigger, output string, e error) (bool, string) {
-	if t.requireHandler != nil {
-		return t.requireHandler.CheckReason(reason, output, e)
+// checkTrigger checks if the trigger matches the event.
+// requirement handlers they are not implementing the EventChecker are only
+// checking the output events
+func (t *targetExecuter) checkTrigger(trigger configure.Trigger, event TriggerEvent) (bool, string, map[string]string) {
+	if t.requireHandler == nil {
+		return false, "", nil
+	}
+	if checker, ok := t.requireHandler.(EventChecker); ok {
+		return checker.CheckTriggerEvent(trigger, event)
+	}
+	if event.Kind != TriggerOnOutput {
+		return false, "", nil
 	}
-	return false, ""
+	found, message := t.requireHandler.CheckReason(trigger, event.Output, event.Err)
+	return found, message, nil
 }
 
-func (t *targetExecuter) checkRequirements(require configure.Require) (bool, string) {
+func (t *targetExecuter) checkRequirements(require configure.Require, dir string) (bool, string) {
 	if t.requireHandler != nil {
+		if dirHandler, ok := t.requireHandler.(RequiresInDir); ok {
+			return dirHandler.CheckRequirementsInDir(require, dir)
+		}
 		return t.requireHandler.CheckRequirements(require)
 	}
 	return false, "no requirement check handler set"
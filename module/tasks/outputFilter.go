@@ -0,0 +1,109 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/ctxout"
+)
+
+// the compiled regular expressions of the output filters and triggers.
+// they are checked for any line of output, so we compile them only once
+var regexCache sync.Map
+
+// compileCached returns the compiled regex for the pattern
+func compileCached(pattern string) (*regexp.Regexp, error) {
+	if regex, found := regexCache.Load(pattern); found {
+		return regex.(*regexp.Regexp), nil
+	}
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Store(pattern, regex)
+	return regex, nil
+}
+
+// filterExpression returns the compiled regex of the filter
+func filterExpression(filter configure.OutputFilter) (*regexp.Regexp, error) {
+	regex, err := compileCached(filter.Match)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output filter %s: %w", filter.Match, err)
+	}
+	return regex, nil
+}
+
+// verifyOutputFilters checks if all filters of the task can be used
+func verifyOutputFilters(task configure.Task) error {
+	for _, filter := range task.OutputFilters {
+		if filter.Match == "" {
+			return fmt.Errorf("output filter without match expression in task %s", task.ID)
+		}
+		if _, err := filterExpression(filter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterOutput applies the output filters of the task to the line.
+// the filters are applied in the order they are defined. so a filter works on the
+// result of the filters before.
+// returns the line they should be printed, and false if the line is hidden
+func (t *targetExecuter) filterOutput(task *configure.Task, line string) (string, bool) {
+	for _, filter := range task.OutputFilters {
+		regex, err := filterExpression(filter)
+		if err != nil {
+			// the filters are verified before the task starts. so this should not happen
+			t.getLogger().Error("ignore output filter", err)
+			continue
+		}
+		match := regex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		if filter.SetVar != "" {
+			value := match[0]
+			if len(match) > 1 {
+				value = match[1]
+			}
+			t.setPh(filter.SetVar, value)
+		}
+		if filter.Hide {
+			return "", false
+		}
+		if filter.Replace != "" {
+			line = regex.ReplaceAllString(line, filter.Replace)
+		}
+		if filter.Color != "" {
+			line = filter.Color + line + ctxout.CleanTag
+		}
+	}
+	return line, true
+}
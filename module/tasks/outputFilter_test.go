@@ -0,0 +1,73 @@
+package tasks_test
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+func TestOutputFilters(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID: "filtered",
+				OutputFilters: []configure.OutputFilter{
+					{Match: `^\[DEBUG\]`, Hide: true},
+					{Match: `Version: (\S+)`, SetVar: "BUILD_VERSION", Hide: true},
+					{Match: `password=\S+`, Replace: "password=***"},
+					{Match: `ERROR`, Color: "<f:red>"},
+				},
+				Script: []string{
+					"echo '[DEBUG] loading plugins'",
+					"echo 'Version: 1.2.3'",
+					"echo 'connect with password=secret'",
+					"echo 'ERROR something failed'",
+					"echo 'done'",
+				},
+			},
+			{ID: "invalid", OutputFilters: []configure.OutputFilter{{Match: `(`}}, Script: []string{"echo never"}},
+			{ID: "hidden-fail", OutputFilters: []configure.OutputFilter{{Match: `.*`, Hide: true}}, Script: []string{"echo fail", "exit 3"}},
+		},
+	}
+
+	var mu sync.Mutex
+	outputs := []string{}
+	outHandler := func(msg ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range msg {
+			if s, ok := m.(tasks.MsgExecOutput); ok {
+				outputs = append(outputs, s.Output)
+			}
+		}
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, req, tasks.NewWatchman())
+	tsk.SetHardExistToAllTasks(false)
+
+	assertIntEqual(t, systools.ExitOk, tsk.RunTarget("filtered", false))
+	assertStringEqual(t, "connect with password=***|<f:red>ERROR something failed</>|done", strings.Join(outputs, "|"))
+	assertStringEqual(t, "1.2.3", dmc.GetPH("BUILD_VERSION"))
+
+	outputs = []string{}
+	assertIntEqual(t, systools.ErrorTemplate, tsk.RunTarget("invalid", false))
+	if len(outputs) != 0 {
+		t.Errorf("expected no command is executed. got %v", outputs)
+	}
+
+	// the exit code is not changed by hiding the output
+	assertIntEqual(t, systools.ExitCmdError, tsk.RunTarget("hidden-fail", false))
+	if len(outputs) != 0 {
+		t.Errorf("expected the output is hidden. got %v", outputs)
+	}
+}
@@ -0,0 +1,177 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/swaros/contxt/module/configure"
+)
+
+const (
+	OutputModeStream  = "stream"  // the output is printed as it comes. this is the default
+	OutputModeGrouped = "grouped" // the output of a target is printed as one block, if the target is done
+)
+
+// outputGroup is the buffered output of one target
+type outputGroup struct {
+	target     string
+	start      time.Time
+	liveErrors bool
+	messages   [][]interface{}
+}
+
+// outputGroups contains the groups of all targets they are running in grouped mode
+type outputGroups struct {
+	mu      sync.Mutex
+	flushMu sync.Mutex // makes sure a group is printed without other output in between
+	groups  map[string]*outputGroup
+}
+
+func newOutputGroups() *outputGroups {
+	return &outputGroups{groups: make(map[string]*outputGroup)}
+}
+
+// outputMode returns the output mode for the target, and if errors are printed at once.
+// the mode of the first task, that defines one, is used.
+// a forced output mode is used for any task, but the live errors of the tasks are still respected.
+func (t *targetExecuter) outputMode(taskList []configure.Task) (string, bool) {
+	liveErrors := t.forceLiveErrors
+	for _, task := range taskList {
+		liveErrors = liveErrors || task.Options.OutputLiveErrors
+	}
+	if t.forceOutputMode != "" {
+		return t.forceOutputMode, liveErrors
+	}
+	for _, task := range taskList {
+		if task.Options.Output != "" {
+			return strings.ToLower(strings.TrimSpace(task.Options.Output)), liveErrors
+		}
+	}
+	return OutputModeStream, false
+}
+
+// startOutputGroup starts buffering the output of the target, if the target runs in grouped mode.
+// returns nil if the output is not grouped
+func (t *targetExecuter) startOutputGroup(target string, taskList []configure.Task) *outputGroup {
+	mode, liveErrors := t.outputMode(taskList)
+	switch mode {
+	case OutputModeStream, "":
+		return nil
+	case OutputModeGrouped:
+	default:
+		t.getLogger().Warn("unknown output mode. using stream", mode)
+		return nil
+	}
+	group := &outputGroup{target: target, start: time.Now(), liveErrors: liveErrors}
+	t.outGroups.mu.Lock()
+	defer t.outGroups.mu.Unlock()
+	if _, exists := t.outGroups.groups[target]; exists {
+		// the target is already grouped by an other run of the same target
+		return nil
+	}
+	t.outGroups.groups[target] = group
+	return group
+}
+
+// finishOutputGroup prints the buffered output of the target as one block
+func (t *targetExecuter) finishOutputGroup(group *outputGroup, exitCode int) {
+	t.outGroups.mu.Lock()
+	delete(t.outGroups.groups, group.target)
+	messages := group.messages
+	t.outGroups.mu.Unlock()
+
+	if t.outputHandler == nil {
+		return
+	}
+	t.outGroups.flushMu.Lock()
+	defer t.outGroups.flushMu.Unlock()
+	t.outputHandler(MsgTarget{Target: group.target, Context: "output_group_start", Info: fmt.Sprintf("%d lines", len(messages))})
+	for _, msg := range messages {
+		t.outputHandler(msg...)
+	}
+	t.outputHandler(MsgTarget{
+		Target:  group.target,
+		Context: "output_group_end",
+		Info:    fmt.Sprintf("done in %v with exit code %d", time.Since(group.start).Round(time.Millisecond), exitCode),
+	}, MsgNumber(exitCode))
+}
+
+// bufferOutput keeps the message in the group of the target, if the target runs in grouped mode.
+// the first return value is false if the message have to be printed now.
+// the second one is true, if any target runs in grouped mode.
+func (t *targetExecuter) bufferOutput(msg ...interface{}) (bool, bool) {
+	if t.outGroups == nil {
+		return false, false
+	}
+	t.outGroups.mu.Lock()
+	defer t.outGroups.mu.Unlock()
+	if len(t.outGroups.groups) == 0 {
+		return false, false
+	}
+	target, isError := messageTarget(msg...)
+	if target == "" {
+		return false, true
+	}
+	group, found := t.outGroups.groups[target]
+	if !found || (isError && group.liveErrors) {
+		return false, true
+	}
+	group.messages = append(group.messages, msg)
+	return true, true
+}
+
+// messageTarget returns the target of the first message they have one.
+// and if one of the messages is an error
+func messageTarget(msg ...interface{}) (string, bool) {
+	target := ""
+	isError := false
+	for _, m := range msg {
+		current := ""
+		switch tm := m.(type) {
+		case MsgTarget:
+			current = tm.Target
+		case MsgExecOutput:
+			current = tm.Target
+		case MsgProcess:
+			current = tm.Target
+		case MsgPid:
+			current = tm.Target
+		case MsgError:
+			current = tm.Target
+			isError = true
+		case MsgErrDebug:
+			current = tm.Target
+			isError = true
+		}
+		if target == "" {
+			target = current
+		}
+	}
+	return target, isError
+}
@@ -0,0 +1,158 @@
+package tasks_test
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+func TestGroupedOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	grouped := configure.Options{Output: "grouped"}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{ID: "main", Needs: []string{"slow", "fast"}, Script: []string{"echo main"}},
+			{ID: "slow", Options: grouped, Script: []string{"echo slow-1", "sleep 0.3", "echo slow-2"}},
+			{ID: "fast", Options: grouped, Script: []string{"echo fast-1", "sleep 0.1", "echo fast-2"}},
+		},
+	}
+
+	var mu sync.Mutex
+	lines := []string{}
+	outHandler := func(msg ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range msg {
+			switch tm := m.(type) {
+			case tasks.MsgExecOutput:
+				lines = append(lines, tm.Output)
+			case tasks.MsgTarget:
+				if strings.HasPrefix(tm.Context, "output_group_") {
+					lines = append(lines, tm.Context+":"+tm.Target)
+					if tm.Context == "output_group_end" && !strings.Contains(tm.Info, "exit code 0") {
+						t.Errorf("expected the exit code in the footer. got %s", tm.Info)
+					}
+				}
+			}
+		}
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, req, tasks.NewWatchman())
+	tsk.SetHardExistToAllTasks(false)
+	assertIntEqual(t, systools.ExitOk, tsk.RunTarget("main", true))
+
+	expected := []string{
+		"output_group_start:fast", "fast-1", "fast-2", "output_group_end:fast",
+		"output_group_start:slow", "slow-1", "slow-2", "output_group_end:slow",
+		"main",
+	}
+	assertStringEqual(t, strings.Join(expected, "|"), strings.Join(lines, "|"))
+}
+
+func TestGroupedOutputLiveErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{ID: "failing", Options: configure.Options{Output: "grouped", OutputLiveErrors: true}, Script: []string{"echo before", "exit 3"}},
+		},
+	}
+
+	var mu sync.Mutex
+	order := []string{}
+	outHandler := func(msg ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range msg {
+			switch tm := m.(type) {
+			case tasks.MsgExecOutput:
+				order = append(order, "output")
+			case tasks.MsgError:
+				order = append(order, "error")
+			case tasks.MsgTarget:
+				if strings.HasPrefix(tm.Context, "output_group_") {
+					order = append(order, tm.Context)
+				}
+			}
+		}
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, req, tasks.NewWatchman())
+	tsk.SetHardExistToAllTasks(false)
+	tsk.RunTarget("failing", false)
+
+	if len(order) == 0 || order[0] != "error" {
+		t.Fatalf("expected the error is printed before the group. got %v", order)
+	}
+	groupStart := strings.Index(strings.Join(order, "|"), "output_group_start|output")
+	if groupStart < 0 || order[len(order)-1] != "output_group_end" {
+		t.Errorf("expected the output is still grouped. got %v", order)
+	}
+	if strings.Contains(strings.Join(order, "|")[groupStart:], "error") {
+		t.Errorf("expected no error inside the group. got %v", order)
+	}
+}
+
+func TestForcedGroupedOutputLiveErrors(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	tests := []struct {
+		name       string
+		options    configure.Options
+		liveErrors bool
+	}{
+		{name: "task option", options: configure.Options{OutputLiveErrors: true}},
+		{name: "preset for all tasks", liveErrors: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runCfg := configure.RunConfig{
+				Task: []configure.Task{
+					{ID: "failing", Options: tt.options, Script: []string{"echo before", "exit 3"}},
+				},
+			}
+			var mu sync.Mutex
+			order := []string{}
+			outHandler := func(msg ...interface{}) {
+				mu.Lock()
+				defer mu.Unlock()
+				for _, m := range msg {
+					switch tm := m.(type) {
+					case tasks.MsgError:
+						order = append(order, "error")
+					case tasks.MsgTarget:
+						if strings.HasPrefix(tm.Context, "output_group_") {
+							order = append(order, tm.Context)
+						}
+					}
+				}
+			}
+			dmc := tasks.NewCombinedDataHandler()
+			req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+			tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, req, tasks.NewWatchman())
+			tsk.SetHardExistToAllTasks(false)
+			tsk.SetOutputModeToAllTasks(tasks.OutputModeGrouped)
+			tsk.SetOutputLiveErrorsToAllTasks(tt.liveErrors)
+			tsk.RunTarget("failing", false)
+
+			if len(order) == 0 || order[0] != "error" {
+				t.Fatalf("expected the error is printed before the group. got %v", order)
+			}
+			if order[len(order)-1] != "output_group_end" {
+				t.Errorf("expected the output is still grouped. got %v", order)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/systools"
+)
+
+const NeedsOutputPrefix = "needs." // outputs are used as ${needs.<task>.<name>}
+
+// TaskOutputs keeps the outputs of the tasks for one execution.
+// different to the placeholders, these values are not global.
+// they are only visible for the tasks they need the task.
+type TaskOutputs struct {
+	mu       sync.Mutex
+	values   map[string]map[string]string // task id -> output name -> value
+	logLines map[string][]string          // the output of the tasks they need them for regex outputs
+}
+
+func NewTaskOutputs() *TaskOutputs {
+	return &TaskOutputs{
+		values:   make(map[string]map[string]string),
+		logLines: make(map[string][]string),
+	}
+}
+
+// Set stores the value of an output
+func (o *TaskOutputs) Set(taskID, name, value string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if _, ok := o.values[taskID]; !ok {
+		o.values[taskID] = make(map[string]string)
+	}
+	o.values[taskID][name] = value
+}
+
+// Get returns the value of an output
+func (o *TaskOutputs) Get(taskID, name string) (string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	value, ok := o.values[taskID][name]
+	return value, ok
+}
+
+// GetAll returns a copy of all outputs of the task
+func (o *TaskOutputs) GetAll(taskID string) map[string]string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	values := make(map[string]string)
+	for name, value := range o.values[taskID] {
+		values[name] = value
+	}
+	return values
+}
+
+func (o *TaskOutputs) addLogLine(taskID, line string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.logLines[taskID] = append(o.logLines[taskID], line)
+}
+
+func (o *TaskOutputs) takeLogLines(taskID string) []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	lines := o.logLines[taskID]
+	delete(o.logLines, taskID)
+	return lines
+}
+
+// needsRegexOutput returns true if one of the outputs is using a regex.
+// only then we need to keep the output of the task.
+func needsRegexOutput(task configure.Task) bool {
+	for _, output := range task.Outputs {
+		if output.Regex != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// taskScope returns the scoped variables for the task.
+// these are the arguments of the executer and the outputs of the needs,
+// they are named like needs.<task>.<name>
+func (t *targetExecuter) taskScope(task configure.Task) map[string]string {
+	if t.outputs == nil || len(task.Needs) == 0 {
+		return t.arguments
+	}
+	scope := make(map[string]string)
+	for key, value := range t.arguments {
+		scope[key] = value
+	}
+	for _, need := range task.Needs {
+		needID, _ := systools.StringSplitArgs(need, "arg")
+		needID = ResolveTargetAlias(t.runCfg, strings.TrimSpace(needID))
+		for name, value := range t.outputs.GetAll(needID) {
+			scope[NeedsOutputPrefix+needID+"."+name] = value
+		}
+	}
+	return scope
+}
+
+// captureOutputs reads the outputs of the task after the script is executed
+func (t *targetExecuter) captureOutputs(task configure.Task) error {
+	if t.outputs == nil {
+		return nil
+	}
+	logLines := t.outputs.takeLogLines(task.ID)
+	for _, output := range task.Outputs {
+		if output.Name == "" {
+			return errors.New("output without name defined in task " + task.ID)
+		}
+		value, err := t.captureOutput(task, output, logLines)
+		if err != nil {
+			return fmt.Errorf("output %s of task %s: %w", output.Name, task.ID, err)
+		}
+		t.outputs.Set(task.ID, output.Name, value)
+	}
+	return nil
+}
+
+func (t *targetExecuter) captureOutput(task configure.Task, output configure.Output, logLines []string) (string, error) {
+	switch {
+	case output.Cmd != "":
+		return t.captureCmdOutput(task, t.fullFillTaskVars(output.Cmd, task))
+	case output.Regex != "":
+		regex, err := regexp.Compile(output.Regex)
+		if err != nil {
+			return "", err
+		}
+		for _, line := range logLines {
+			if match := regex.FindStringSubmatch(line); match != nil {
+				if len(match) > 1 {
+					return match[1], nil
+				}
+				return match[0], nil
+			}
+		}
+		return "", errors.New("nothing in the output is matching " + output.Regex)
+	case output.File != "":
+		workingDir, err := t.workingDir(&task)
+		if err != nil {
+			return "", err
+		}
+		content, err := os.ReadFile(resolvePath(workingDir, t.fullFillTaskVars(output.File, task)))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(content)), nil
+	}
+	return "", errors.New("no source defined. use cmd, regex or file")
+}
+
+// captureCmdOutput executes the command in the same way as the script lines,
+// and returns the output
+func (t *targetExecuter) captureCmdOutput(task configure.Task, command string) (string, error) {
+	workingDir, err := t.workingDir(&task)
+	if err != nil {
+		return "", err
+	}
+
+	runCmd, runArgs := t.commandFallback.GetMainCmd(task.Options)
+	var lines []string
+	opts := ExecOptions{Dir: workingDir, Env: t.commandEnv(task)}
+	internalCode, _, execErr := t.ExecuteScriptLineWithOptions(opts, runCmd, runArgs, command, func(line string, _ bool, err error) bool {
+		lines = append(lines, line)
+		return true
+	}, func(process *os.Process) {})
+	if execErr != nil {
+		return "", execErr
+	}
+	if internalCode != systools.ExitOk {
+		return "", fmt.Errorf("command %s failed", command)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
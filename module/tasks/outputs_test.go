@@ -0,0 +1,108 @@
+package tasks_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+func runOutputTasks(t *testing.T, runCfg configure.RunConfig, target string, async bool) (int, *tasks.CombinedDh, []string) {
+	t.Helper()
+	var mu sync.Mutex
+	messages := []string{}
+	outHandler := func(msg ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range msg {
+			switch s := m.(type) {
+			case tasks.MsgExecOutput:
+				messages = append(messages, s.Output)
+			}
+		}
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, req, tasks.NewWatchman())
+	tsk.SetHardExistToAllTasks(false)
+	code := tsk.RunTarget(target, async)
+	return code, dmc, messages
+}
+
+func TestTaskOutputs(t *testing.T) {
+	versionFile := filepath.Join(t.TempDir(), "version.txt")
+	if err := os.WriteFile(versionFile, []byte("4.5.6\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID:      "build",
+				Aliases: []string{"b"},
+				Script:  []string{"echo commit: abc123"},
+				Outputs: []configure.Output{
+					{Name: "VERSION", Cmd: "echo 1.2.3"},
+					{Name: "COMMIT", Regex: `commit: (\w+)`},
+					{Name: "FILE_VERSION", File: versionFile},
+				},
+			},
+			{
+				ID:      "lint",
+				Script:  []string{"echo lint"},
+				Outputs: []configure.Output{{Name: "RESULT", Cmd: "echo clean"}},
+			},
+			{
+				ID:     "deploy",
+				Needs:  []string{"b", "lint"},
+				Script: []string{"echo deploy ${needs.build.VERSION} ${needs.build.COMMIT} ${needs.build.FILE_VERSION} ${needs.lint.RESULT}"},
+			},
+			{
+				ID:     "unrelated",
+				Needs:  []string{"lint"},
+				Script: []string{"echo 'unrelated ${needs.build.VERSION}'"},
+			},
+		},
+	}
+	for _, async := range []bool{false, true} {
+		code, dmc, messages := runOutputTasks(t, runCfg, "deploy", async)
+		assertIntEqual(t, systools.ExitOk, code)
+		assertSliceContains(t, messages, "deploy 1.2.3 abc123 4.5.6 clean")
+		// the outputs are not stored as global variables
+		if _, exists := dmc.GetPHExists("needs.build.VERSION"); exists {
+			t.Error("the output should not be stored as global variable")
+		}
+	}
+
+	// only the tasks they need build, are getting the outputs
+	code, _, messages := runOutputTasks(t, runCfg, "unrelated", false)
+	assertIntEqual(t, systools.ExitOk, code)
+	assertSliceContains(t, messages, "unrelated ${needs.build.VERSION}")
+}
+
+func TestTaskOutputsFailure(t *testing.T) {
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID:      "build",
+				Script:  []string{"echo no version here"},
+				Outputs: []configure.Output{{Name: "VERSION", Regex: `version: (\d+)`}},
+			},
+			{
+				ID:     "deploy",
+				Needs:  []string{"build"},
+				Script: []string{"echo deploy ${needs.build.VERSION}"},
+			},
+		},
+	}
+	code, _, _ := runOutputTasks(t, runCfg, "build", false)
+	assertIntEqual(t, systools.ExitCmdError, code)
+
+	runCfg.Task[0].Outputs = []configure.Output{{Name: "VERSION"}}
+	code, _, _ = runOutputTasks(t, runCfg, "build", false)
+	assertIntEqual(t, systools.ExitCmdError, code)
+}
@@ -0,0 +1,120 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/swaros/contxt/module/systools"
+)
+
+const ProjectNeedPrefix = "@" // needs starting with this prefix are targets of other projects
+
+// SplitProjectNeed splits a need like @api:generate-client into the project
+// reference and the target. ok is false if the need is not a project need,
+// or if the project or the target is missing.
+func SplitProjectNeed(need string) (project string, target string, ok bool) {
+	need = strings.TrimSpace(need)
+	if !strings.HasPrefix(need, ProjectNeedPrefix) {
+		return "", "", false
+	}
+	project, target, found := strings.Cut(strings.TrimPrefix(need, ProjectNeedPrefix), ":")
+	project = strings.TrimSpace(project)
+	target = strings.TrimSpace(target)
+	if !found || project == "" || target == "" {
+		return "", "", false
+	}
+	return project, target, true
+}
+
+// splitProjectNeeds separates the needs of other projects from the local needs
+func splitProjectNeeds(needs []string) (local []string, external []string) {
+	for _, need := range needs {
+		if strings.HasPrefix(strings.TrimSpace(need), ProjectNeedPrefix) {
+			external = append(external, need)
+		} else {
+			local = append(local, need)
+		}
+	}
+	return local, external
+}
+
+// runProjectNeeds runs the needs they are defined in other projects.
+// they are executed one after another, because any of them runs in a different path.
+// if a need is already started by an other target, we wait until it is done and use his exit code.
+// the first failing need stops the execution, and the exit code is returned.
+// ExitByNothingToDo is not a failure. it means the requirements of the target are not matching,
+// and this is also not an error, if the target runs by his own.
+func (t *targetExecuter) runProjectNeeds(target string, displayCmd bool, needs []string) int {
+	for _, need := range needs {
+		project, projectTarget, ok := SplitProjectNeed(need)
+		if !ok {
+			err := errors.New("invalid need " + need + ". expected @project:target")
+			t.out(MsgError(MsgError{Err: err, Reference: need, Target: target}))
+			return systools.ErrorInvalidTargetName
+		}
+		if t.projectRunner == nil {
+			err := errors.New("need " + need + " can not be resolved. running targets of other projects is not supported here")
+			t.out(MsgError(MsgError{Err: err, Reference: need, Target: target}))
+			return systools.ExitByNoTargetExists
+		}
+		var code int
+		if !t.watch.TryCreate(need) {
+			t.getLogger().Debug("need already handled. wait until it is done " + need)
+			if displayCmd {
+				t.out(MsgTarget{Target: target, Context: "needs_ignored_runs_already", Info: need})
+			}
+			done := false
+			if code, done = t.watch.WaitForTaskDone(need, 50*time.Millisecond, t.isStoppedByInterrupt); !done {
+				if t.isStoppedByInterrupt() {
+					return systools.ExitByInterrupt
+				}
+				err := errors.New("need " + need + " is no longer tracked. can not wait for the result")
+				t.out(MsgError(MsgError{Err: err, Reference: need, Target: target}))
+				return systools.ExitCmdError
+			}
+		} else {
+			if displayCmd {
+				t.out(MsgTarget{Target: target, Context: "needs_execute", Info: need})
+			}
+			t.watch.IncTaskCount(need)
+			code = t.projectRunner.RunProjectTarget(t.runCfg.Workspace.Project, project, projectTarget)
+			// the exit code is set before the need is done, so the waiting targets are getting them
+			if err := t.watch.SetTaskExitCode(need, code); err != nil {
+				t.getLogger().Error("can not set the exit code of the need", need, err)
+			}
+			t.watch.IncTaskDoneCount(need)
+		}
+		if code != systools.ExitOk && code != systools.ExitByNothingToDo {
+			err := fmt.Errorf("need %s failed with exit code %d", need, code)
+			t.out(MsgError(MsgError{Err: err, Reference: need, Target: target}))
+			return code
+		}
+	}
+	return systools.ExitOk
+}
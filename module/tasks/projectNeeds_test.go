@@ -0,0 +1,166 @@
+package tasks_test
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+type testProjectRunner struct {
+	mu    sync.Mutex
+	codes map[string]int
+	calls []string
+	delay time.Duration // the time any target is running
+}
+
+func (r *testProjectRunner) RunProjectTarget(fromProject, project, target string) int {
+	r.mu.Lock()
+	r.calls = append(r.calls, fromProject+">"+project+":"+target)
+	r.mu.Unlock()
+	time.Sleep(r.delay)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if code, ok := r.codes[project+":"+target]; ok {
+		return code
+	}
+	return systools.ExitOk
+}
+
+func runProjectNeedsTask(t *testing.T, needs []string, runner *testProjectRunner, async bool) (int, []string) {
+	t.Helper()
+	var mu sync.Mutex
+	messages := []string{}
+	outHandler := func(msg ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range msg {
+			switch s := m.(type) {
+			case tasks.MsgExecOutput:
+				messages = append(messages, s.Output)
+			case tasks.MsgError:
+				messages = append(messages, s.Err.Error())
+			}
+		}
+	}
+	runCfg := configure.RunConfig{
+		Workspace: configure.WorkspaceInfo{Project: "shop", Role: "frontend"},
+		Task: []configure.Task{
+			{
+				ID:     "build",
+				Needs:  needs,
+				Script: []string{"echo building"},
+			},
+			{
+				ID:     "prepare",
+				Script: []string{"echo preparing"},
+			},
+		},
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	args := []interface{}{dmc, outHandler, tasks.ShellCmd, req, tasks.NewWatchman()}
+	if runner != nil {
+		args = append(args, runner)
+	}
+	tsk := tasks.NewTaskListExec(runCfg, args...)
+	tsk.SetHardExistToAllTasks(false)
+	code := tsk.RunTarget("build", async)
+	return code, messages
+}
+
+func TestSplitProjectNeed(t *testing.T) {
+	project, target, ok := tasks.SplitProjectNeed("@api:generate-client")
+	if !ok || project != "api" || target != "generate-client" {
+		t.Errorf("unexpected result %s %s %v", project, target, ok)
+	}
+	for _, invalid := range []string{"api:generate-client", "@api", "@:build", "@api:", "build"} {
+		if _, _, ok := tasks.SplitProjectNeed(invalid); ok {
+			t.Errorf("expected %s is not a valid project need", invalid)
+		}
+	}
+}
+
+func TestProjectNeeds(t *testing.T) {
+	for _, async := range []bool{false, true} {
+		runner := &testProjectRunner{}
+		code, messages := runProjectNeedsTask(t, []string{"@api:generate-client", "prepare"}, runner, async)
+		assertIntEqual(t, systools.ExitOk, code)
+		if strings.Join(runner.calls, ",") != "shop>api:generate-client" {
+			t.Errorf("unexpected calls %v", runner.calls)
+		}
+		assertSliceContains(t, messages, "preparing")
+		assertSliceContains(t, messages, "building")
+	}
+}
+
+func TestProjectNeedsFailure(t *testing.T) {
+	runner := &testProjectRunner{codes: map[string]int{"api:generate-client": systools.ExitCmdError}}
+	code, messages := runProjectNeedsTask(t, []string{"@api:generate-client", "@blog/api:lint"}, runner, false)
+	assertIntEqual(t, systools.ExitCmdError, code)
+	// the second need should not be executed
+	assertIntEqual(t, 1, len(runner.calls))
+	assertSliceContains(t, messages, "need @api:generate-client failed with exit code 103")
+	for _, msg := range messages {
+		if msg == "building" {
+			t.Error("the target should not be executed, if a need fails")
+		}
+	}
+}
+
+func TestProjectNeedsWithoutRunner(t *testing.T) {
+	code, _ := runProjectNeedsTask(t, []string{"@api:generate-client"}, nil, false)
+	assertIntEqual(t, systools.ExitByNoTargetExists, code)
+
+	code, _ = runProjectNeedsTask(t, []string{"@api"}, &testProjectRunner{}, false)
+	assertIntEqual(t, systools.ErrorInvalidTargetName, code)
+}
+
+// the same need of two parallel targets runs once. the second target waits for the result
+func TestProjectNeedsWaitForRunningNeed(t *testing.T) {
+	runner := &testProjectRunner{codes: map[string]int{"api:generate-client": systools.ExitCmdError}, delay: 300 * time.Millisecond}
+	var mu sync.Mutex
+	messages := []string{}
+	outHandler := func(msg ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range msg {
+			switch s := m.(type) {
+			case tasks.MsgExecOutput:
+				messages = append(messages, s.Output)
+			case tasks.MsgError:
+				messages = append(messages, s.Err.Error())
+			}
+		}
+	}
+	runCfg := configure.RunConfig{
+		Workspace: configure.WorkspaceInfo{Project: "shop", Role: "frontend"},
+		Task: []configure.Task{
+			{ID: "build", Needs: []string{"frontend", "docs"}, Script: []string{"echo building"}},
+			{ID: "frontend", Needs: []string{"@api:generate-client"}, Script: []string{"echo frontend"}},
+			{ID: "docs", Needs: []string{"@api:generate-client"}, Script: []string{"echo docs"}},
+		},
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, req, tasks.NewWatchman(), runner)
+	tsk.SetHardExistToAllTasks(false)
+	tsk.RunTarget("build", true)
+
+	assertIntEqual(t, 1, len(runner.calls))
+	failures := 0
+	for _, msg := range messages {
+		if msg == "frontend" || msg == "docs" {
+			t.Errorf("the target should not be executed, if the need fails. got %v", messages)
+		}
+		if msg == "need @api:generate-client failed with exit code 103" {
+			failures++
+		}
+	}
+	assertIntEqual(t, 2, failures)
+}
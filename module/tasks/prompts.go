@@ -0,0 +1,153 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+)
+
+const (
+	PromptTypeString  = "string"  // default. free text input
+	PromptTypeSelect  = "select"  // select one of the choices
+	PromptTypeConfirm = "confirm" // yes or no. the value is true or false
+
+	maxPromptAttempts = 3 // how often we ask again, if the value is not valid
+)
+
+// resolvePrompts checks any prompt of the task. if the variable is not set,
+// the user will be asked for the value.
+// if we are not able to ask the user, the default value is used.
+// if no default value exists, an error is returned.
+func (t *targetExecuter) resolvePrompts(task configure.Task) error {
+	for _, prompt := range task.Prompts {
+		if prompt.Name == "" {
+			return errors.New("prompt without name defined in task " + task.ID)
+		}
+		if t.phHandler != nil {
+			if _, exists := t.phHandler.GetPHExists(prompt.Name); exists {
+				continue
+			}
+		}
+		value, err := t.askPrompt(prompt)
+		if err != nil {
+			return err
+		}
+		t.getLogger().Debug("prompt resolved", mimiclog.Fields{"name": prompt.Name, "value": value, "target": task.ID})
+		t.setPh(prompt.Name, value)
+	}
+	return nil
+}
+
+// askPrompt returns the value for the prompt.
+func (t *targetExecuter) askPrompt(prompt configure.Prompt) (string, error) {
+	promptType := strings.ToLower(strings.TrimSpace(prompt.Type))
+	if promptType == "" {
+		promptType = PromptTypeString
+	}
+	choices := prompt.Choices
+	switch promptType {
+	case PromptTypeString:
+	case PromptTypeConfirm:
+		choices = []string{"true", "false"}
+	case PromptTypeSelect:
+		if len(choices) < 1 {
+			return "", errors.New("prompt " + prompt.Name + " is defined as select, but there are no choices")
+		}
+	default:
+		return "", errors.New("prompt " + prompt.Name + " have an unsupported type: " + prompt.Type)
+	}
+
+	// without a terminal we can not ask. so the default is the only option
+	if t.prompter == nil || !t.prompter.IsInteractive() {
+		if prompt.Default == "" {
+			return "", fmt.Errorf("variable %s is not set and can not be asked in non-interactive mode. set it by -v %s=<value>", prompt.Name, prompt.Name)
+		}
+		if err := verifyPromptValue(prompt, choices, prompt.Default); err != nil {
+			return "", err
+		}
+		return prompt.Default, nil
+	}
+
+	question := prompt.Question
+	if question == "" {
+		question = prompt.Name
+	}
+	var lastErr error
+	for attempt := 0; attempt < maxPromptAttempts; attempt++ {
+		var value string
+		var err error
+		if promptType == PromptTypeString {
+			value, err = t.prompter.Input(question, prompt.Default)
+		} else {
+			value, err = t.prompter.Select(question, choices)
+		}
+		if err != nil {
+			return "", err
+		}
+		if value == "" {
+			value = prompt.Default
+		}
+		if lastErr = verifyPromptValue(prompt, choices, value); lastErr == nil {
+			return value, nil
+		}
+		t.out(MsgError(MsgError{Err: lastErr, Reference: prompt.Name, Target: t.target}))
+	}
+	return "", lastErr
+}
+
+// verifyPromptValue checks the value against the choices and the validation regex
+func verifyPromptValue(prompt configure.Prompt, choices []string, value string) error {
+	if value == "" {
+		return fmt.Errorf("no value given for %s", prompt.Name)
+	}
+	if len(choices) > 0 {
+		found := false
+		for _, choice := range choices {
+			if choice == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("value '%s' for %s is not one of %s", value, prompt.Name, strings.Join(choices, ", "))
+		}
+	}
+	if prompt.Validate != "" {
+		regex, err := regexp.Compile(prompt.Validate)
+		if err != nil {
+			return fmt.Errorf("invalid validation expression for %s: %w", prompt.Name, err)
+		}
+		if !regex.MatchString(value) {
+			return fmt.Errorf("value '%s' for %s is not matching %s", value, prompt.Name, prompt.Validate)
+		}
+	}
+	return nil
+}
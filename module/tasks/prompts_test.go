@@ -0,0 +1,134 @@
+package tasks_test
+
+import (
+	"testing"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+type testPrompter struct {
+	interactive bool
+	answers     []string
+	asked       []string
+}
+
+func (p *testPrompter) IsInteractive() bool {
+	return p.interactive
+}
+
+func (p *testPrompter) next(question string) string {
+	p.asked = append(p.asked, question)
+	if len(p.answers) == 0 {
+		return ""
+	}
+	answer := p.answers[0]
+	p.answers = p.answers[1:]
+	return answer
+}
+
+func (p *testPrompter) Input(question, defaultValue string) (string, error) {
+	return p.next(question), nil
+}
+
+func (p *testPrompter) Select(question string, choices []string) (string, error) {
+	return p.next(question), nil
+}
+
+func runPromptTask(t *testing.T, prompts []configure.Prompt, prompter *testPrompter, preset map[string]string) (int, *tasks.CombinedDh, []string) {
+	t.Helper()
+	messages := []string{}
+	outHandler := func(msg ...interface{}) {
+		for _, m := range msg {
+			switch s := m.(type) {
+			case tasks.MsgExecOutput:
+				messages = append(messages, s.Output)
+			}
+		}
+	}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID:      "release",
+				Prompts: prompts,
+				Script: []string{
+					"echo version ${VERSION}",
+				},
+			},
+		},
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	for k, v := range preset {
+		dmc.SetPH(k, v)
+	}
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	args := []interface{}{dmc, outHandler, tasks.ShellCmd, req}
+	if prompter != nil {
+		args = append(args, prompter)
+	}
+	tsk := tasks.NewTaskListExec(runCfg, args...)
+	tsk.SetHardExistToAllTasks(false)
+	tsk.GetWatch().ResetAllTaskInfos()
+	code := tsk.RunTarget("release", false)
+	return code, dmc, messages
+}
+
+func TestPromptNonInteractiveFails(t *testing.T) {
+	prompts := []configure.Prompt{{Name: "VERSION", Question: "version to release?"}}
+	code, _, _ := runPromptTask(t, prompts, &testPrompter{interactive: false}, nil)
+	assertIntEqual(t, systools.ExitByMissingInput, code)
+
+	code, _, _ = runPromptTask(t, prompts, nil, nil)
+	assertIntEqual(t, systools.ExitByMissingInput, code)
+}
+
+func TestPromptNonInteractiveUsesDefault(t *testing.T) {
+	prompts := []configure.Prompt{{Name: "VERSION", Question: "version to release?", Default: "1.0.0"}}
+	code, dmc, messages := runPromptTask(t, prompts, nil, nil)
+	assertIntEqual(t, systools.ExitOk, code)
+	assertStrEqual(t, "1.0.0", dmc.GetPH("VERSION"))
+	assertSliceContains(t, messages, "version 1.0.0")
+}
+
+func TestPromptNotAskedIfVariableExists(t *testing.T) {
+	prompter := &testPrompter{interactive: true, answers: []string{"2.0.0"}}
+	prompts := []configure.Prompt{{Name: "VERSION", Question: "version to release?"}}
+	code, _, messages := runPromptTask(t, prompts, prompter, map[string]string{"VERSION": "0.1.0"})
+	assertIntEqual(t, systools.ExitOk, code)
+	assertIntEqual(t, 0, len(prompter.asked))
+	assertSliceContains(t, messages, "version 0.1.0")
+}
+
+func TestPromptInteractiveWithValidation(t *testing.T) {
+	prompter := &testPrompter{interactive: true, answers: []string{"latest", "2.1.0"}}
+	prompts := []configure.Prompt{{Name: "VERSION", Question: "version to release?", Validate: `^\d+\.\d+\.\d+$`}}
+	code, _, messages := runPromptTask(t, prompts, prompter, nil)
+	assertIntEqual(t, systools.ExitOk, code)
+	assertIntEqual(t, 2, len(prompter.asked))
+	assertSliceContains(t, messages, "version 2.1.0")
+}
+
+func TestPromptInteractiveSelect(t *testing.T) {
+	prompter := &testPrompter{interactive: true, answers: []string{"", "prod"}}
+	prompts := []configure.Prompt{
+		{Name: "VERSION", Default: "1.2.3"},
+		{Name: "ENV", Question: "target environment", Type: "select", Choices: []string{"dev", "prod"}},
+	}
+	code, dmc, _ := runPromptTask(t, prompts, prompter, nil)
+	assertIntEqual(t, systools.ExitOk, code)
+	assertStrEqual(t, "prod", dmc.GetPH("ENV"))
+	assertStrEqual(t, "1.2.3", dmc.GetPH("VERSION"))
+}
+
+func TestPromptInvalidSelectChoice(t *testing.T) {
+	prompter := &testPrompter{interactive: true, answers: []string{"", "stage", "stage", "stage"}}
+	prompts := []configure.Prompt{
+		{Name: "VERSION", Default: "1.2.3"},
+		{Name: "ENV", Type: "select", Choices: []string{"dev", "prod"}},
+	}
+	code, _, _ := runPromptTask(t, prompts, prompter, nil)
+	assertIntEqual(t, systools.ExitByMissingInput, code)
+	assertIntEqual(t, 4, len(prompter.asked))
+}
@@ -27,7 +27,6 @@ package tasks
 import (
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/swaros/contxt/module/configure"
 	"github.com/swaros/contxt/module/dirhandle"
@@ -39,6 +38,12 @@ type Requires interface {
 	CheckReason(checkReason configure.Trigger, output string, e error) (bool, string)
 }
 
+// RequiresInDir is implemented by requirement handlers they can resolve
+// relative paths against the working dir of the task
+type RequiresInDir interface {
+	CheckRequirementsInDir(require configure.Require, dir string) (bool, string)
+}
+
 type DefaultRequires struct {
 	variables PlaceHolder // taking care about the variables
 	logger    mimiclog.Logger
@@ -78,6 +83,12 @@ func RequirementCheck(require configure.Require) (bool, string) {
 // - environment variables
 // returns bool and the message what is checked.
 func (d *DefaultRequires) CheckRequirements(require configure.Require) (bool, string) {
+	return d.CheckRequirementsInDir(require, "")
+}
+
+// CheckRequirementsInDir is the same as CheckRequirements, but relative paths
+// of the exists and notExists checks are resolved against the given directory.
+func (d *DefaultRequires) CheckRequirementsInDir(require configure.Require, dir string) (bool, string) {
 
 	// check operating system
 	if require.System != "" {
@@ -89,7 +100,7 @@ func (d *DefaultRequires) CheckRequirements(require configure.Require) (bool, st
 
 	// check file exists
 	for _, fileExists := range require.Exists {
-		fileExists = d.variables.HandlePlaceHolder(fileExists)
+		fileExists = resolvePath(dir, d.variables.HandlePlaceHolder(fileExists))
 		d.logger.Debug("check file exists", mimiclog.Fields{"file": fileExists})
 		fexists, err := dirhandle.Exists(fileExists)
 		if err != nil || !fexists {
@@ -100,7 +111,7 @@ func (d *DefaultRequires) CheckRequirements(require configure.Require) (bool, st
 
 	// check file not exists
 	for _, fileNotExists := range require.NotExists {
-		fileNotExists = d.variables.HandlePlaceHolder(fileNotExists)
+		fileNotExists = resolvePath(dir, d.variables.HandlePlaceHolder(fileNotExists))
 		fexists, err := dirhandle.Exists(fileNotExists)
 		if err != nil || fexists {
 			return false, "unexpected file (" + fileNotExists + ")  found "
@@ -190,54 +201,6 @@ func (d *DefaultRequires) StringMatchTest(pattern, value string) bool {
 // checks reasons that is used for some triggers
 // returns bool and a message what trigger was matched and the reason
 func (d *DefaultRequires) CheckReason(checkReason configure.Trigger, output string, e error) (bool, string) {
-	logFields := mimiclog.Fields{
-		"contains":   checkReason.OnoutContains,
-		"onError":    checkReason.Onerror,
-		"onLess":     checkReason.OnoutcountLess,
-		"onMore":     checkReason.OnoutcountMore,
-		"testing-at": output}
-	d.logger.Debug("Checking Trigger", logFields)
-
-	var message = ""
-	// now means forcing this trigger
-	if checkReason.Now {
-		message = "reason now match always"
-		return true, message
-	}
-	// checks a error happens
-	if checkReason.Onerror && e != nil {
-		message = fmt.Sprint("reason match because a error happen (", e, ")  ")
-		return true, message
-	}
-
-	// checks if the output from comand contains les then X chars
-	if checkReason.OnoutcountLess > 0 && checkReason.OnoutcountLess > len(output) {
-		message = fmt.Sprint("reason match output len (", len(output), ") is less then ", checkReason.OnoutcountLess)
-		return true, message
-	}
-	// checks if the output contains more then X chars
-	if checkReason.OnoutcountMore > 0 && checkReason.OnoutcountMore < len(output) {
-		message = fmt.Sprint("reason match output len (", len(output), ") is more then ", checkReason.OnoutcountMore)
-		return true, message
-	}
-
-	// checks if the output contains one of the defined text-lines
-	for _, checkText := range checkReason.OnoutContains {
-		checkText = d.variables.HandlePlaceHolder(checkText)
-		if checkText != "" && strings.Contains(output, checkText) {
-			message = fmt.Sprint("reason match because output contains ", checkText)
-			d.logger.Debug("OnoutContains MATCH", mimiclog.Fields{"looking4": checkText, "in": output, "source": checkReason.OnoutContains})
-			return true, message
-		}
-		if checkText != "" {
-			logFields := mimiclog.Fields{
-				"check": checkText,
-				"with":  output,
-				"from":  checkReason.OnoutContains,
-			}
-			d.logger.Debug("OnoutContains NO MATCH", logFields)
-		}
-	}
-
-	return false, message
+	found, message, _ := d.CheckTriggerEvent(checkReason, OutputEvent(output, e))
+	return found, message
 }
@@ -0,0 +1,128 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/swaros/contxt/module/configure"
+)
+
+// RiskPolicy defines the maximum risk level of the anko functions,
+// depending on the source of the task.
+type RiskPolicy struct {
+	Local         RiskLevel // tasks of the local template
+	Trusted       RiskLevel // tasks of the trusted shared libs
+	Untrusted     RiskLevel // tasks of any other shared lib
+	TrustedShared []string  // the names of the trusted shared libs
+}
+
+// NewDefaultRiskPolicy returns the policy they is used, if nothing is configured.
+// any task can use any function, so existing shared libs are working as before.
+// a stricter level for the untrusted shared libs have to be configured.
+func NewDefaultRiskPolicy() *RiskPolicy {
+	return &RiskPolicy{
+		Local:     RISK_LEVEL_HIGH,
+		Trusted:   RISK_LEVEL_HIGH,
+		Untrusted: RISK_LEVEL_HIGH,
+	}
+}
+
+// NewRiskPolicy creates the policy from the user configuration.
+// levels they are not set, are taken from the default policy
+func NewRiskPolicy(cfg configure.AnkoPolicy) (*RiskPolicy, error) {
+	policy := NewDefaultRiskPolicy()
+	policy.TrustedShared = cfg.TrustedShared
+	levels := []struct {
+		value  string
+		target *RiskLevel
+	}{
+		{cfg.Local, &policy.Local},
+		{cfg.Trusted, &policy.Trusted},
+		{cfg.Untrusted, &policy.Untrusted},
+	}
+	for _, level := range levels {
+		if level.value == "" {
+			continue
+		}
+		risk, err := ParseRiskLevel(level.value)
+		if err != nil {
+			return policy, err
+		}
+		*level.target = risk
+	}
+	return policy, nil
+}
+
+// MaxRisk returns the maximum risk level for the anko commands of the task
+func (p *RiskPolicy) MaxRisk(task configure.Task) RiskLevel {
+	if task.Source == "" {
+		return p.Local
+	}
+	if p.IsTrusted(task.Source) {
+		return p.Trusted
+	}
+	return p.Untrusted
+}
+
+// IsTrusted checks if the shared lib is trusted. the version of the shared lib is ignored
+func (p *RiskPolicy) IsTrusted(source string) bool {
+	name := strings.Split(source, "@")[0]
+	for _, trusted := range p.TrustedShared {
+		if strings.Split(trusted, "@")[0] == name {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the name of the risk level
+func (r RiskLevel) String() string {
+	switch r {
+	case RISK_LEVEL_LOW:
+		return "low"
+	case RISK_LEVEL_MEDIUM:
+		return "medium"
+	case RISK_LEVEL_HIGH:
+		return "high"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(r))
+	}
+}
+
+// ParseRiskLevel returns the risk level by the name (low, medium, high)
+func ParseRiskLevel(name string) (RiskLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "low":
+		return RISK_LEVEL_LOW, nil
+	case "medium":
+		return RISK_LEVEL_MEDIUM, nil
+	case "high":
+		return RISK_LEVEL_HIGH, nil
+	default:
+		return RISK_LEVEL_LOW, fmt.Errorf("unknown risk level %q. use low, medium or high", name)
+	}
+}
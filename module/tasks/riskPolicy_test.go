@@ -0,0 +1,102 @@
+package tasks_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+func TestRiskPolicyFromConfig(t *testing.T) {
+	policy, err := tasks.NewRiskPolicy(configure.AnkoPolicy{
+		Local:         "medium",
+		Untrusted:     "LOW",
+		TrustedShared: []string{"swaros/ctx-git"},
+	})
+	assertNoError(t, err)
+	assertIntEqual(t, int(tasks.RISK_LEVEL_MEDIUM), int(policy.MaxRisk(configure.Task{ID: "local"})))
+	assertIntEqual(t, int(tasks.RISK_LEVEL_HIGH), int(policy.MaxRisk(configure.Task{ID: "trusted", Source: "swaros/ctx-git@v0.2.0"})))
+	assertIntEqual(t, int(tasks.RISK_LEVEL_LOW), int(policy.MaxRisk(configure.Task{ID: "other", Source: "someone/lib"})))
+
+	// without configuration, existing shared libs are not restricted
+	defaults, err := tasks.NewRiskPolicy(configure.AnkoPolicy{})
+	assertNoError(t, err)
+	assertIntEqual(t, int(tasks.RISK_LEVEL_HIGH), int(defaults.MaxRisk(configure.Task{ID: "other", Source: "someone/lib"})))
+
+	if _, err := tasks.NewRiskPolicy(configure.AnkoPolicy{Trusted: "dangerous"}); err == nil {
+		t.Error("expected an error for an unknown risk level")
+	}
+}
+
+func TestAnkoBlockedFunction(t *testing.T) {
+	called := false
+	ar := tasks.NewAnkoRunner()
+	ar.SetOutputSupression(true)
+	ar.SetRiskLevel(tasks.RISK_LEVEL_LOW)
+	defs := tasks.ListAnkoFunctions()
+	assertNoError(t, ar.AddDefaultDefines(defs))
+	assertNoError(t, ar.Define("track", func() { called = true }))
+
+	blocked := []string{}
+	for _, def := range ar.GetBlocked() {
+		blocked = append(blocked, def.Symbol())
+	}
+	assertSliceContains(t, blocked, "exec")
+	assertSliceContains(t, blocked, "writeFile")
+	assertSliceNotContains(t, blocked, "varSet")
+
+	_, err := ar.RunAnko(`exec("ls")
+track()`)
+	if err == nil {
+		t.Fatal("expected an error for the blocked function")
+	}
+	if !strings.Contains(err.Error(), "exec is blocked by the risk policy: it needs the risk level high, but only low is allowed") {
+		t.Error("unexpected error:", err)
+	}
+	if called {
+		t.Error("the script should stop at the blocked function")
+	}
+}
+
+func TestRiskPolicyInTask(t *testing.T) {
+	dir := t.TempDir()
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID:     "shared",
+				Source: "someone/untrusted-lib",
+				Cmd: []string{
+					`writeFile("` + filepath.ToSlash(filepath.Join(dir, "shared.txt")) + `", "from shared")`,
+				},
+			},
+			{
+				ID: "local",
+				Cmd: []string{
+					`writeFile("` + filepath.ToSlash(filepath.Join(dir, "local.txt")) + `", "from local")`,
+				},
+			},
+		},
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	outHandler := func(msg ...interface{}) {}
+	policy, err := tasks.NewRiskPolicy(configure.AnkoPolicy{Untrusted: "low"})
+	assertNoError(t, err)
+	tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, req, tasks.NewWatchman(), policy)
+	tsk.SetHardExistToAllTasks(false)
+
+	assertIntEqual(t, systools.ExitCmdError, tsk.RunTarget("shared", false))
+	if _, err := os.Stat(filepath.Join(dir, "shared.txt")); err == nil {
+		t.Error("the untrusted shared task should not be able to write files")
+	}
+
+	assertIntEqual(t, systools.ExitOk, tsk.RunTarget("local", false))
+	if _, err := os.Stat(filepath.Join(dir, "local.txt")); err != nil {
+		t.Error("the local task should be able to write files", err)
+	}
+}
@@ -25,6 +25,7 @@
 package tasks
 
 import (
+	"os"
 	"strings"
 	"sync"
 	"time"
@@ -84,8 +85,11 @@ func (t *targetExecuter) createRunnerForTask(currentTask configure.Task, callbac
 	} else {
 		runner = process.NewProcess(currentTask.Options.Maincmd, currentTask.Options.Mainparams...)
 	}
+	runner.SetWorkingDir(t.resolveWorkingDir(&currentTask))
+	runner.SetEnv(t.commandEnv(currentTask))
 	runner.SetKeepRunning(true)
 	runner.SetOnOutput(callback)
+	trackRunnerProcess(runner)
 	if _, _, err := runner.Exec(); err != nil {
 		return nil, err
 	}
@@ -95,6 +99,26 @@ func (t *targetExecuter) createRunnerForTask(currentTask configure.Task, callbac
 	return
 }
 
+// trackRunnerProcess registers the process group of the runner, if it is started.
+// so the runner gets the interrupt signals, like the script lines.
+func trackRunnerProcess(runner *process.Process) {
+	pid := 0 // onInit and onWaitDone are called by the same goroutine
+	runner.SetOnInit(func(proc *os.Process) {
+		pid = proc.Pid
+		trackProcessGroup(pid)
+	})
+	runner.SetOnWaitDone(func(error) {
+		untrackProcessGroup(pid)
+	})
+}
+
+// untrackRunnerProcess removes the process group of a stopped runner
+func untrackRunnerProcess(runner *process.Process) {
+	if watcher, err := runner.GetProcessWatcher(); err == nil {
+		untrackProcessGroup(watcher.GetPid())
+	}
+}
+
 func (t *targetExecuter) WaitTilAllRunnersAreDone(tick time.Duration) {
 	for {
 		if !t.RunnersActive() {
@@ -109,6 +133,7 @@ func (t *targetExecuter) StopAllTaskRunner() {
 		process := value.(*process.Process)
 		t.getLogger().Debug("tasks.Runner: stopping runner:", mimiclog.Fields{"id": key})
 		process.Stop()
+		untrackRunnerProcess(process)
 		return true
 	})
 	runners = sync.Map{}
@@ -149,6 +174,7 @@ func (t *targetExecuter) StopAndRemoveTaskRunner(currentTask configure.Task) {
 	if ok {
 		runner := val.(*process.Process)
 		runner.Stop()
+		untrackRunnerProcess(runner)
 		runners.Delete(idStr)
 		t.getLogger().Debug("tasks.Runner: stopped and removed runner for task:", mimiclog.Fields{"id": idStr, "task": currentTask.ID})
 	}
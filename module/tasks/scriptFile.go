@@ -0,0 +1,137 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/systools"
+)
+
+const (
+	ScriptModeLine = "line" // default. any script line is executed in his own shell
+	ScriptModeFile = "file" // the whole script is written to a file and executed once
+)
+
+// isScriptFileMode returns true if the script section of the task
+// have to be executed as one file
+func isScriptFileMode(task configure.Task) bool {
+	return strings.EqualFold(strings.TrimSpace(task.Options.ScriptMode), ScriptModeFile)
+}
+
+// runScriptAsFile resolves all macros and placeholders of the script section
+// and writes the result into a temporary file. this file is then passed once
+// to the main command. the output handling is the same as for the line mode.
+// returns true if the execution was aborted, and the exit code
+func (t *targetExecuter) runScriptAsFile(currentTask configure.Task, watchman *Watchman) (bool, int) {
+	var scriptLines []string
+	abort, returnCode, _ := t.TryParse(currentTask.Script, func(codeLine string) (bool, int) {
+		scriptLines = append(scriptLines, t.fullFillTaskVars(codeLine, currentTask))
+		return false, systools.ExitOk
+	})
+	if abort {
+		return abort, returnCode
+	}
+	if len(scriptLines) < 1 {
+		t.getLogger().Debug("script file mode: no script lines left after parsing", currentTask.ID)
+		return false, systools.ExitOk
+	}
+
+	scriptContent := strings.Join(scriptLines, "\n") + "\n"
+	runCmd, runArgs := t.commandFallback.GetMainCmd(currentTask.Options)
+	fileName, err := t.writeScriptFile(currentTask.ID, runCmd, scriptContent)
+	if err != nil {
+		t.getLogger().Error("can not create script file", err)
+		t.out(MsgError(MsgError{Err: errors.New("can not create script file: " + err.Error()), Reference: currentTask.ID, Target: currentTask.ID}))
+		return true, systools.ExitCmdError
+	}
+	defer os.Remove(fileName)
+
+	logFields := mimiclog.Fields{"target": currentTask.ID, "file": fileName, "lines": len(scriptLines)}
+	t.getLogger().Debug("script file mode: execute script file", logFields)
+
+	if currentTask.Options.Displaycmd {
+		t.out(MsgTarget{Target: currentTask.ID, Context: "command", Info: scriptContent}) // output the whole script
+	}
+	t.setPh("RUN."+currentTask.ID+".CMD.LAST", scriptContent)
+	t.setPh("RUN."+currentTask.ID+".SCRIPT_FILE", fileName)
+	t.setPh("RUN.SCRIPT_LINE", scriptContent)
+
+	exitCode, aborted := t.executeResolvedCommand(fileName, fileName, runCmd, scriptFileArgs(runCmd, runArgs), currentTask, watchman)
+	return aborted, exitCode
+}
+
+// writeScriptFile writes the script content into a temporary file.
+// the file extension depends on the main command, so the shell
+// is able to read the file.
+func (t *targetExecuter) writeScriptFile(target, runCmd, content string) (string, error) {
+	ext := ".sh"
+	if isPowershell(runCmd) {
+		ext = ".ps1"
+	}
+	prefix := "contxt-"
+	if clTarget, err := systools.CheckForCleanString(target); err == nil && clTarget != "" {
+		prefix += clTarget + "-"
+	}
+	file, err := os.CreateTemp("", prefix+"*"+ext)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	if _, err := file.WriteString(content); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// scriptFileArgs returns the arguments of the main command, so the script file
+// can be added as the last argument. the file is read by the shell and
+// not executed itself. so it works also if the temp dir is mounted with noexec.
+// the -c argument of the shell is removed, because the file is not a command.
+// powershell needs -File instead.
+func scriptFileArgs(runCmd string, runArgs []string) []string {
+	args := make([]string, 0, len(runArgs)+1)
+	for _, arg := range runArgs {
+		if arg == "-c" {
+			continue
+		}
+		args = append(args, arg)
+	}
+	if isPowershell(runCmd) {
+		args = append(args, "-File")
+	}
+	return args
+}
+
+func isPowershell(cmd string) bool {
+	base := strings.ToLower(filepath.Base(cmd))
+	return base == "powershell" || base == "powershell.exe" || base == "pwsh" || base == "pwsh.exe"
+}
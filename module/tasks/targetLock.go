@@ -0,0 +1,193 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/systools"
+)
+
+const (
+	LockNone      = "none"      // no lock. this is the default
+	LockExclusive = "exclusive" // only one process can run the target at the same time
+	LockShared    = "shared"    // any number of shared holders, but not together with an exclusive one
+
+	lockInfoSuffix = ".holder.json"
+)
+
+// TargetLockDir is the directory, where the lock files and the holder infos are stored.
+var TargetLockDir = filepath.Join(os.TempDir(), "contxt-locks")
+
+// TargetLockInfo describes a process, that holds a lock on a target
+type TargetLockInfo struct {
+	Pid     int       `json:"pid"`
+	Project string    `json:"project"`
+	Target  string    `json:"target"`
+	Mode    string    `json:"mode"`
+	Since   time.Time `json:"since"`
+}
+
+type targetLock struct {
+	lock     *systools.FileLock
+	infoFile string
+}
+
+// TargetLockKey returns the name of the lock for the target in the project.
+// the project path is hashed, so the same target name in different projects
+// will not block each other
+func TargetLockKey(project, target string) string {
+	hash := sha1.Sum([]byte(filepath.Clean(project)))
+	cleanTarget := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, target)
+	return hex.EncodeToString(hash[:])[:12] + "_" + cleanTarget
+}
+
+// ListTargetLocks returns the current holders of target locks.
+// holder infos from processes that are no longer running are removed.
+func ListTargetLocks() ([]TargetLockInfo, error) {
+	files, err := filepath.Glob(filepath.Join(TargetLockDir, "*"+lockInfoSuffix))
+	if err != nil {
+		return nil, err
+	}
+	locks := make([]TargetLockInfo, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var info TargetLockInfo
+		if err := json.Unmarshal(data, &info); err != nil || !systools.IsProcessAlive(info.Pid) {
+			os.Remove(file)
+			continue
+		}
+		locks = append(locks, info)
+	}
+	sort.Slice(locks, func(i, j int) bool {
+		if locks[i].Project != locks[j].Project {
+			return locks[i].Project < locks[j].Project
+		}
+		if locks[i].Target != locks[j].Target {
+			return locks[i].Target < locks[j].Target
+		}
+		return locks[i].Pid < locks[j].Pid
+	})
+	return locks, nil
+}
+
+// lockHolders returns the pids of the processes, that are holding the lock
+func lockHolders(project, target string) []string {
+	pids := []string{}
+	locks, err := ListTargetLocks()
+	if err != nil {
+		return pids
+	}
+	for _, info := range locks {
+		if info.Project == project && info.Target == target && info.Pid != os.Getpid() {
+			pids = append(pids, fmt.Sprintf("%d", info.Pid))
+		}
+	}
+	return pids
+}
+
+// lockProject returns the path that identifies the project of the target
+func (t *targetExecuter) lockProject() string {
+	project := t.resolveWorkingDir(nil)
+	if project == "" {
+		project, _ = os.Getwd()
+	}
+	return project
+}
+
+// acquireTargetLock locks the target, depending on the lock option of the task.
+// it returns nil, if no lock is required.
+// if the lock is held by another process, the lock fails, or we wait for it,
+// depending on the lockWait and lockTimeout options.
+func (t *targetExecuter) acquireTargetLock(target string, options configure.Options) (*targetLock, int) {
+	mode := strings.ToLower(strings.TrimSpace(options.Lock))
+	if mode == "" || mode == LockNone {
+		return nil, systools.ExitOk
+	}
+	if mode != LockExclusive && mode != LockShared {
+		t.out(MsgError(MsgError{Err: fmt.Errorf("invalid lock mode '%s'. use %s, %s or %s", options.Lock, LockExclusive, LockShared, LockNone), Reference: "lock", Target: target}))
+		return nil, systools.ErrorTemplate
+	}
+	shared := mode == LockShared
+	project := t.lockProject()
+	key := TargetLockKey(project, target)
+	lock := systools.NewFileLock(filepath.Join(TargetLockDir, key+".lock"))
+
+	locked, err := lock.TryLock(shared)
+	if err == nil && !locked {
+		holders := strings.Join(lockHolders(project, target), ",")
+		if !options.LockWait {
+			t.out(MsgError(MsgError{Err: fmt.Errorf("target is locked by another process (pid %s)", holders), Reference: "lock", Target: target}))
+			return nil, systools.ExitByLocked
+		}
+		t.out(MsgTarget{Target: target, Context: "lock_wait", Info: "waiting for lock held by pid " + holders})
+		err = lock.Lock(shared, time.Duration(options.LockTimeout)*time.Millisecond)
+		if errors.Is(err, systools.ErrLockTimeout) {
+			t.out(MsgError(MsgError{Err: fmt.Errorf("target is still locked by another process (pid %s): %w", holders, err), Reference: "lock", Target: target}))
+			return nil, systools.ExitByLocked
+		}
+	}
+	if err != nil {
+		t.out(MsgError(MsgError{Err: err, Reference: "lock", Target: target}))
+		return nil, systools.ErrorBySystem
+	}
+
+	tLock := &targetLock{
+		lock:     lock,
+		infoFile: filepath.Join(TargetLockDir, fmt.Sprintf("%s.%d%s", key, os.Getpid(), lockInfoSuffix)),
+	}
+	info := TargetLockInfo{Pid: os.Getpid(), Project: project, Target: target, Mode: mode, Since: time.Now()}
+	if data, err := json.Marshal(info); err == nil {
+		if err := os.WriteFile(tLock.infoFile, data, 0o644); err != nil {
+			t.getLogger().Warn("could not write the lock holder info", err)
+		}
+	}
+	t.getLogger().Debug("target locked", target, mode, project)
+	return tLock, systools.ExitOk
+}
+
+// release removes the holder info and unlocks the target
+func (l *targetLock) release() {
+	os.Remove(l.infoFile)
+	l.lock.Unlock()
+}
@@ -0,0 +1,82 @@
+package tasks_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+// runs the target in a project, that is located in projectDir
+func runLockedTarget(t *testing.T, projectDir string, options configure.Options) int {
+	t.Helper()
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{ID: "migrate", Options: options, Script: []string{"echo migrate"}},
+		},
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	dmc.SetPH("BASEPATH", projectDir)
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, func(msg ...interface{}) {}, tasks.ShellCmd, req, tasks.NewWatchman())
+	tsk.SetHardExistToAllTasks(false)
+	return tsk.RunTarget("migrate", false)
+}
+
+// simulates a lock, hold by an other process
+func holdTargetLock(t *testing.T, projectDir, target string, shared bool) *systools.FileLock {
+	t.Helper()
+	lock := systools.NewFileLock(filepath.Join(tasks.TargetLockDir, tasks.TargetLockKey(projectDir, target)+".lock"))
+	if ok, err := lock.TryLock(shared); !ok || err != nil {
+		t.Fatalf("could not get the lock for the test. %v %v", ok, err)
+	}
+	return lock
+}
+
+func TestTargetLock(t *testing.T) {
+	oldDir := tasks.TargetLockDir
+	tasks.TargetLockDir = t.TempDir()
+	defer func() { tasks.TargetLockDir = oldDir }()
+	projectDir := t.TempDir()
+
+	// no lock is used by default
+	lock := holdTargetLock(t, projectDir, "migrate", false)
+	assertIntEqual(t, systools.ExitOk, runLockedTarget(t, projectDir, configure.Options{}))
+
+	// locked by someone else
+	assertIntEqual(t, systools.ExitByLocked, runLockedTarget(t, projectDir, configure.Options{Lock: tasks.LockExclusive}))
+	assertIntEqual(t, systools.ExitByLocked, runLockedTarget(t, projectDir, configure.Options{Lock: tasks.LockShared}))
+	assertIntEqual(t, systools.ExitByLocked, runLockedTarget(t, projectDir, configure.Options{Lock: tasks.LockExclusive, LockWait: true, LockTimeout: 100}))
+
+	// the same target in an other project is not affected
+	assertIntEqual(t, systools.ExitOk, runLockedTarget(t, t.TempDir(), configure.Options{Lock: tasks.LockExclusive}))
+
+	// waiting until the lock is released
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		lock.Unlock()
+	}()
+	assertIntEqual(t, systools.ExitOk, runLockedTarget(t, projectDir, configure.Options{Lock: tasks.LockExclusive, LockWait: true}))
+
+	// the lock is released after the run
+	locks, err := tasks.ListTargetLocks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locks) != 0 {
+		t.Errorf("expected no lock holders after the run. got %v", locks)
+	}
+
+	// shared locks can be used together
+	shared := holdTargetLock(t, projectDir, "migrate", true)
+	defer shared.Unlock()
+	assertIntEqual(t, systools.ExitOk, runLockedTarget(t, projectDir, configure.Options{Lock: tasks.LockShared}))
+	assertIntEqual(t, systools.ExitByLocked, runLockedTarget(t, projectDir, configure.Options{Lock: tasks.LockExclusive}))
+
+	// invalid lock modes are reported
+	assertIntEqual(t, systools.ErrorTemplate, runLockedTarget(t, projectDir, configure.Options{Lock: "always"}))
+}
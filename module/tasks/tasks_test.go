@@ -769,6 +769,61 @@ task:
 
 }
 
+func TestTryParseNestedConditions(t *testing.T) {
+	ResetWatchmanTaskList(t)
+	source := `
+config:
+    variables:
+        stage: "dev"
+        mode: "debug"
+        json_content: '{"services": ["web", "db", "cache"]}'
+task:
+  - id: nested
+    script:
+      - "#@import-json SERVICES '${json_content}'"
+      - "#@if-equals ${stage} prod"
+      - echo "stage is prod"
+      - "#@set mode changed"
+      - "#@elseif-equals ${stage} dev"
+      - echo "stage is dev"
+      - '#@if $mode == "debug" && $stage != "prod"'
+      - echo "dev in debug mode"
+      - "#@else"
+      - echo "dev in release mode"
+      - "#@end"
+      - "#@foreach SERVICES services"
+      - "#@- #@if-not-equals __LINE__ db"
+      - "#@- echo service __LINE__"
+      - "#@- #@else"
+      - "#@- echo skip __LINE__"
+      - "#@- #@end"
+      - "#@end"
+      - "#@else"
+      - echo "stage is other"
+      - "#@end"
+      - echo "mode is ${mode}"
+`
+	messages := []string{}
+	if taskMain, err := createRuntimeByYamlString(source, &messages); err != nil {
+		t.Errorf("Error parsing yaml: %v", err)
+	} else {
+		code := taskMain.RunTarget("nested", true)
+		if code != 0 {
+			t.Errorf("Expected code 0, got %d", code)
+		}
+	}
+	assert.Contains(t, messages, "stage is dev")
+	assert.Contains(t, messages, "dev in debug mode")
+	assert.Contains(t, messages, "service web")
+	assert.Contains(t, messages, "skip db")
+	assert.Contains(t, messages, "service cache")
+	assert.Contains(t, messages, "mode is debug")
+	assert.NotContains(t, messages, "stage is prod")
+	assert.NotContains(t, messages, "dev in release mode")
+	assert.NotContains(t, messages, "service db")
+	assert.NotContains(t, messages, "stage is other")
+}
+
 func TestTryParseCheatsWithErrors(t *testing.T) {
 	ResetWatchmanTaskList(t)
 	// testing the errorcases of the cheats
@@ -848,6 +903,27 @@ task:
   - id: failure_21
     script:
         - "#@add nonextsting something"
+  - id: failure_22
+    script:
+        - "#@end"
+  - id: failure_23
+    script:
+        - "#@else"
+  - id: failure_24
+    script:
+        - "#@if-equals a a"
+        - "#@else"
+        - "#@elseif-equals a b"
+        - "#@end"
+  - id: failure_25
+    script:
+        - '#@if $notexists == "x"'
+        - "#@end"
+  - id: failure_26
+    script:
+        - "#@if-equals a a"
+        - "#@end"
+        - "#@end"
 
 
 `
@@ -866,9 +942,9 @@ task:
 
 		testRuns := []TestRuns{
 			{target: "test", expectedCode: 8, expectedError: "invalid usage #@if-equals need: str1 str2"},
-			{target: "failure_1", expectedCode: 8, expectedError: "invalid usage #@if-equals can not be used in another if"},
+			{target: "failure_1", expectedCode: 8, expectedError: "invalid usage #@if-equals in line 2. the block is not closed by #@end"},
 			{target: "failure_2", expectedCode: 8, expectedError: "invalid usage #@if-not-equals need: str1 str2"},
-			{target: "failure_3", expectedCode: 8, expectedError: "invalid usage #@if-not-equals can not be used in another if"},
+			{target: "failure_3", expectedCode: 8, expectedError: "invalid usage #@if-not-equals in line 2. the block is not closed by #@end"},
 			{target: "failure_4", expectedCode: 8, expectedError: "invalid usage #@import-json needs 2 arguments. <keyname> <json-source-string>"},
 			{target: "failure_5", expectedCode: 8, expectedError: "error while parsing json: invalid character 't' looking for beginning of object key string"},
 			{target: "failure_6", expectedCode: 8, expectedError: "error while parsing json: invalid character 't' looking for beginning of object key string", linuxOnly: true},
@@ -887,6 +963,11 @@ task:
 			{target: "failure_19", expectedCode: 8, expectedError: "error while executing command: exit status 127", linuxOnly: true},
 			{target: "failure_20", expectedCode: 8, expectedError: "invalid usage #@var needs 2 arguments at least. <varibale-name> <bash-command>", linuxOnly: true},
 			{target: "failure_21", expectedCode: 8, expectedError: "variable must exists for add #@add nonextsting"},
+			{target: "failure_22", expectedCode: 8, expectedError: "invalid usage #@end in line 1. there is no #@if or #@foreach to close"},
+			{target: "failure_23", expectedCode: 8, expectedError: "invalid usage #@else in line 1. there is no #@if to continue"},
+			{target: "failure_24", expectedCode: 8, expectedError: "invalid usage #@elseif-equals in line 3. the #@if in line 1 has already an #@else"},
+			{target: "failure_25", expectedCode: 8, expectedError: "variable notexists is not defined in $notexists == \"x\""},
+			{target: "failure_26", expectedCode: 8, expectedError: "invalid usage #@end in line 3. there is no #@if or #@foreach to close"},
 		}
 
 		for i, testRun := range testRuns {
@@ -915,6 +996,61 @@ task:
 	}
 }
 
+// the macro blocks are checked before the script runs. so no line is executed, if they are not balanced
+func TestTryParseBlocksCheckedBeforeRun(t *testing.T) {
+	ResetWatchmanTaskList(t)
+	source := `
+config:
+    variables:
+        json_content: '{"services": ["web", "db"]}'
+task:
+  - id: stray_end
+    script:
+      - echo "before stray end"
+      - "#@end"
+  - id: not_closed
+    script:
+      - echo "before not closed"
+      - "#@if-equals a a"
+      - echo "inside"
+  - id: foreach_not_closed
+    script:
+      - "#@import-json SERVICES '${json_content}'"
+      - echo "before foreach"
+      - "#@foreach SERVICES services"
+      - "#@- #@if-equals __LINE__ web"
+      - "#@- echo service __LINE__"
+      - "#@end"
+`
+	messages := []string{}
+	errorMsg := []error{}
+	taskMain, err := createRuntimeByYamlStringWithErrors(source, &messages, &errorMsg)
+	if err != nil {
+		t.Fatalf("Error parsing yaml: %v", err)
+	}
+	tests := []struct {
+		target        string
+		expectedError string
+	}{
+		{target: "stray_end", expectedError: "invalid usage #@end in line 2. there is no #@if or #@foreach to close"},
+		{target: "not_closed", expectedError: "invalid usage #@if-equals in line 2. the block is not closed by #@end"},
+		{target: "foreach_not_closed", expectedError: "#@foreach in line 3: invalid usage #@if-equals in line 1. the block is not closed by #@end"},
+	}
+	for _, tt := range tests {
+		messages = []string{}
+		errorMsg = []error{}
+		assertIntEqual(t, systools.ErrorCheatMacros, taskMain.RunTarget(tt.target, false))
+		errStrings := []string{}
+		for _, e := range errorMsg {
+			errStrings = append(errStrings, e.Error())
+		}
+		assert.Contains(t, errStrings, tt.expectedError)
+		if len(messages) > 0 {
+			t.Errorf("expected no line is executed for %s. got %v", tt.target, messages)
+		}
+	}
+}
+
 func TestTriggerExecution(t *testing.T) {
 	ResetWatchmanTaskList(t)
 	source := `
@@ -0,0 +1,3 @@
+service: {{ .SERVICE }}
+image: {{ .compose.services.web.image }}
+replicas: {{ .compose.services.web.replicas }}
@@ -0,0 +1,255 @@
+// MIT License
+//
+// Copyright (c) 2020 Thomas Ziegler <thomas.zglr@googlemail.com>. All rights reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the Software), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED AS IS, WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// AINC-NOTE-0815
+
+package tasks
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+)
+
+const (
+	TriggerOnOutput   = iota // a line of output is written
+	TriggerOnExit            // the command is done
+	TriggerOnDuration        // the command is running longer than the duration of the event
+)
+
+// TriggerEvent is something that happens while a command is executed.
+// the triggers of listener and stopreasons are checked against these events
+type TriggerEvent struct {
+	Kind     int           // one of TriggerOnOutput, TriggerOnExit or TriggerOnDuration
+	Output   string        // the line of output
+	Err      error         // the error of the command, if any
+	Stderr   bool          // the output is written to stderr
+	ExitCode int           // the exit code of the command. only for TriggerOnExit
+	Duration time.Duration // the duration they is exceeded. only for TriggerOnDuration
+}
+
+// OutputEvent creates the event for a line of output
+func OutputEvent(output string, e error) TriggerEvent {
+	return TriggerEvent{Kind: TriggerOnOutput, Output: output, Err: e}
+}
+
+// EventChecker is implemented by requirement handlers they can check triggers against
+// any event, not just the output. the captures are the groups of the onoutRegex match
+type EventChecker interface {
+	CheckTriggerEvent(trigger configure.Trigger, event TriggerEvent) (bool, string, map[string]string)
+}
+
+// CheckTriggerEvent checks if the trigger matches the event.
+// returns true and a message what is matching, and the captured groups of onoutRegex
+func (d *DefaultRequires) CheckTriggerEvent(trigger configure.Trigger, event TriggerEvent) (bool, string, map[string]string) {
+	switch event.Kind {
+	case TriggerOnExit:
+		match, err := MatchExitCode(trigger.OnExitCode, event.ExitCode)
+		if err != nil {
+			d.logger.Error("invalid exit code trigger", err)
+		}
+		if match {
+			return true, fmt.Sprint("reason match because the exit code is ", event.ExitCode), nil
+		}
+		return false, "", nil
+	case TriggerOnDuration:
+		// the event is created for the exact duration of the trigger
+		if trigger.OnDurationExceeds > 0 && time.Duration(trigger.OnDurationExceeds)*time.Millisecond == event.Duration {
+			return true, fmt.Sprint("reason match because the command is running longer then ", event.Duration), nil
+		}
+		return false, "", nil
+	}
+
+	logFields := mimiclog.Fields{
+		"contains":   trigger.OnoutContains,
+		"onError":    trigger.Onerror,
+		"onLess":     trigger.OnoutcountLess,
+		"onMore":     trigger.OnoutcountMore,
+		"testing-at": event.Output}
+	d.logger.Debug("Checking Trigger", logFields)
+
+	// now means forcing this trigger
+	if trigger.Now {
+		return true, "reason now match always", nil
+	}
+	// checks a error happens
+	if trigger.Onerror && event.Err != nil {
+		return true, fmt.Sprint("reason match because a error happen (", event.Err, ")  "), nil
+	}
+	// if the stderr flag is set, only lines from stderr are checked
+	if trigger.OnStderr && !event.Stderr {
+		return false, "", nil
+	}
+
+	output := event.Output
+	// checks if the output from comand contains les then X chars
+	if trigger.OnoutcountLess > 0 && trigger.OnoutcountLess > len(output) {
+		return true, fmt.Sprint("reason match output len (", len(output), ") is less then ", trigger.OnoutcountLess), nil
+	}
+	// checks if the output contains more then X chars
+	if trigger.OnoutcountMore > 0 && trigger.OnoutcountMore < len(output) {
+		return true, fmt.Sprint("reason match output len (", len(output), ") is more then ", trigger.OnoutcountMore), nil
+	}
+
+	// checks if the output contains one of the defined text-lines
+	for _, checkText := range trigger.OnoutContains {
+		checkText = d.variables.HandlePlaceHolder(checkText)
+		if checkText != "" && strings.Contains(output, checkText) {
+			d.logger.Debug("OnoutContains MATCH", mimiclog.Fields{"looking4": checkText, "in": output, "source": trigger.OnoutContains})
+			return true, fmt.Sprint("reason match because output contains ", checkText), nil
+		}
+		if checkText != "" {
+			logFields := mimiclog.Fields{
+				"check": checkText,
+				"with":  output,
+				"from":  trigger.OnoutContains,
+			}
+			d.logger.Debug("OnoutContains NO MATCH", logFields)
+		}
+	}
+
+	// checks the regular expressions. the groups are returned as captures
+	for _, pattern := range trigger.OnoutRegex {
+		pattern = d.variables.HandlePlaceHolder(pattern)
+		if pattern == "" {
+			continue
+		}
+		regex, err := compileCached(pattern)
+		if err != nil {
+			d.logger.Error("invalid regex in trigger", pattern, err)
+			continue
+		}
+		match := regex.FindStringSubmatch(output)
+		if match == nil {
+			continue
+		}
+		captures := make(map[string]string)
+		for i, value := range match {
+			captures[strconv.Itoa(i)] = value
+			if name := regex.SubexpNames()[i]; name != "" {
+				captures[name] = value
+			}
+		}
+		return true, fmt.Sprint("reason match because output matches ", pattern), captures
+	}
+
+	// any line on stderr is matching, if nothing else is defined to check the output
+	if trigger.OnStderr && event.Stderr && !hasOutputChecks(trigger) {
+		return true, "reason match because the output is written to stderr", nil
+	}
+	return false, "", nil
+}
+
+// hasOutputChecks returns true if the trigger checks the content of the output
+func hasOutputChecks(trigger configure.Trigger) bool {
+	return trigger.OnoutcountLess > 0 || trigger.OnoutcountMore > 0 || len(trigger.OnoutContains) > 0 || len(trigger.OnoutRegex) > 0
+}
+
+// usesStderr returns true if one of the triggers of the task needs to know
+// if the output is written to stderr
+func usesStderr(task configure.Task) bool {
+	if task.Stopreasons.OnStderr {
+		return true
+	}
+	for _, listener := range task.Listener {
+		if listener.Trigger.OnStderr {
+			return true
+		}
+	}
+	return false
+}
+
+// startDurationTriggers starts a timer for any trigger of the task they are using onDurationExceeds.
+// the stop function is called, if a stopreason is matching. the returned function stops all timers.
+// the timers are holding the lock while they are checking the triggers, so they are not running
+// at the same time as the output callbacks. after the returned function is done, no timer is checking anymore.
+func (t *targetExecuter) startDurationTriggers(task *configure.Task, lock sync.Locker, stop func(message string)) func() {
+	if t.triggerAction {
+		return func() {}
+	}
+	durations := make(map[int]bool)
+	if task.Stopreasons.OnDurationExceeds > 0 {
+		durations[task.Stopreasons.OnDurationExceeds] = true
+	}
+	for _, listener := range task.Listener {
+		if listener.Trigger.OnDurationExceeds > 0 {
+			durations[listener.Trigger.OnDurationExceeds] = true
+		}
+	}
+	stopped := false // guarded by the lock
+	var timers []*time.Timer
+	for milliseconds := range durations {
+		duration := time.Duration(milliseconds) * time.Millisecond
+		timers = append(timers, time.AfterFunc(duration, func() {
+			lock.Lock()
+			defer lock.Unlock()
+			if stopped {
+				return
+			}
+			event := TriggerEvent{Kind: TriggerOnDuration, Duration: duration}
+			t.listenerWatch(event, task)
+			if found, message, _ := t.checkTrigger(task.Stopreasons, event); found {
+				stop(message)
+			}
+		}))
+	}
+	return func() {
+		for _, timer := range timers {
+			timer.Stop()
+		}
+		lock.Lock()
+		defer lock.Unlock()
+		stopped = true
+	}
+}
+
+// MatchExitCode checks if the exit code is one of the codes.
+// the codes are numbers like "1" or "-1", or ranges like "2-5" or "-5--1"
+func MatchExitCode(codes []string, exitCode int) (bool, error) {
+	for _, code := range codes {
+		code = strings.TrimSpace(code)
+		from, to := code, code
+		// the first sign can be the minus of the lower bound, so the range separator is searched after it
+		if len(code) > 1 {
+			if index := strings.Index(code[1:], "-"); index >= 0 {
+				from, to = code[:index+1], code[index+2:]
+			}
+		}
+		min, err := strconv.ParseInt(strings.TrimSpace(from), 10, 0)
+		if err != nil {
+			return false, fmt.Errorf("invalid exit code %s: %w", code, err)
+		}
+		max, err := strconv.ParseInt(strings.TrimSpace(to), 10, 0)
+		if err != nil {
+			return false, fmt.Errorf("invalid exit code range %s: %w", code, err)
+		}
+		if int64(exitCode) >= min && int64(exitCode) <= max {
+			return true, nil
+		}
+	}
+	return false, nil
+}
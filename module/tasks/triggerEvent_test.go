@@ -0,0 +1,237 @@
+package tasks_test
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+func TestMatchExitCode(t *testing.T) {
+	tests := []struct {
+		codes    []string
+		exitCode int
+		expected bool
+	}{
+		{[]string{"1"}, 1, true},
+		{[]string{"1"}, 2, false},
+		{[]string{"1", "3"}, 3, true},
+		{[]string{"2-5"}, 4, true},
+		{[]string{"2-5"}, 6, false},
+		{[]string{" 10 - 20 "}, 20, true},
+		{[]string{"-1"}, -1, true},
+		{[]string{"-1"}, 1, false},
+		{[]string{"-5--2"}, -3, true},
+		{[]string{"-5--2"}, -1, false},
+		{[]string{"-2-2"}, 0, true},
+		{[]string{}, 0, false},
+	}
+	for _, test := range tests {
+		match, err := tasks.MatchExitCode(test.codes, test.exitCode)
+		if err != nil {
+			t.Errorf("unexpected error for %v: %v", test.codes, err)
+		}
+		if match != test.expected {
+			t.Errorf("expected %v for %v with exit code %d", test.expected, test.codes, test.exitCode)
+		}
+	}
+	for _, invalid := range []string{"one", "-", "1-", "--1"} {
+		if _, err := tasks.MatchExitCode([]string{invalid}, 1); err == nil {
+			t.Errorf("expected an error for the invalid exit code %q", invalid)
+		}
+	}
+}
+
+// runs the target and returns the output of the commands
+func runTriggerTarget(t *testing.T, runCfg configure.RunConfig, target string, expectedCode int) []string {
+	t.Helper()
+	var mu sync.Mutex
+	outputs := []string{}
+	outHandler := func(msg ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range msg {
+			if s, ok := m.(tasks.MsgExecOutput); ok {
+				outputs = append(outputs, s.Output)
+			}
+		}
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, req, tasks.NewWatchman())
+	tsk.SetHardExistToAllTasks(false)
+	assertIntEqual(t, expectedCode, tsk.RunTarget(target, false))
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]string{}, outputs...)
+}
+
+func TestTriggerRegexCaptures(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID:     "serve",
+				Script: []string{"echo 'listen tcp :8080: port 8080 already in use'"},
+				Listener: []configure.Listener{
+					{
+						Trigger: configure.Trigger{OnoutRegex: []string{`port (?P<port>\d+) already in use`}},
+						Action:  configure.Action{Target: "free-port"},
+					},
+				},
+			},
+			{ID: "free-port", Script: []string{"echo 'free ${RUN.serve.TRIGGER.port} ${RUN.serve.TRIGGER.1}'"}},
+		},
+	}
+	outputs := runTriggerTarget(t, runCfg, "serve", systools.ExitOk)
+	assertSliceContains(t, outputs, "free 8080 8080")
+}
+
+func TestTriggerExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID:     "exit-listener",
+				Script: []string{"exit 3"},
+				Listener: []configure.Listener{
+					{Trigger: configure.Trigger{OnExitCode: []string{"1", "2-4"}}, Action: configure.Action{Script: []string{"echo handled exit code"}}},
+					{Trigger: configure.Trigger{OnExitCode: []string{"0"}}, Action: configure.Action{Script: []string{"echo unexpected"}}},
+				},
+			},
+			{
+				ID:          "exit-stop",
+				Options:     configure.Options{IgnoreCmdError: true},
+				Stopreasons: configure.Trigger{OnExitCode: []string{"5"}},
+				Script:      []string{"exit 5", "echo never"},
+			},
+		},
+	}
+	outputs := runTriggerTarget(t, runCfg, "exit-listener", systools.ExitCmdError)
+	assertSliceContains(t, outputs, "handled exit code")
+	assertSliceNotContains(t, outputs, "unexpected")
+
+	outputs = runTriggerTarget(t, runCfg, "exit-stop", systools.ExitByStopReason)
+	assertSliceNotContains(t, outputs, "never")
+}
+
+func TestTriggerStderr(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID:     "stderr",
+				Script: []string{"echo 'warning on stdout'; echo 'warning on stderr' >&2; echo 'fail on stderr' >&2"},
+				Listener: []configure.Listener{
+					{Trigger: configure.Trigger{OnStderr: true, OnoutContains: []string{"warning"}}, Action: configure.Action{Script: []string{"echo stderr warning"}}},
+				},
+			},
+		},
+	}
+	outputs := runTriggerTarget(t, runCfg, "stderr", systools.ExitOk)
+	assertSliceContains(t, outputs, "warning on stdout")
+	assertSliceContains(t, outputs, "fail on stderr")
+	assertContainsCount(t, outputs, "stderr warning", 1)
+}
+
+func TestTriggerDuration(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID:     "slow",
+				Script: []string{"sleep 0.5"},
+				Listener: []configure.Listener{
+					{Trigger: configure.Trigger{OnDurationExceeds: 100}, Action: configure.Action{Script: []string{"echo still running"}}},
+				},
+			},
+			{
+				ID:          "too-slow",
+				Stopreasons: configure.Trigger{OnDurationExceeds: 200},
+				Script:      []string{"sleep 10", "echo never"},
+			},
+		},
+	}
+	outputs := runTriggerTarget(t, runCfg, "slow", systools.ExitOk)
+	assertSliceContains(t, outputs, "still running")
+
+	start := time.Now()
+	outputs = runTriggerTarget(t, runCfg, "too-slow", systools.ExitByStopReason)
+	assertSliceNotContains(t, outputs, "never")
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("expected the command is stopped after the duration. it took %v", time.Since(start))
+	}
+}
+
+// overlapRecorder checks the trigger events like the default requirements,
+// and counts how often the events are checked at the same time
+type overlapRecorder struct {
+	*tasks.DefaultRequires
+	running  atomic.Int32
+	overlaps atomic.Int32
+}
+
+func (o *overlapRecorder) CheckTriggerEvent(trigger configure.Trigger, event tasks.TriggerEvent) (bool, string, map[string]string) {
+	if o.running.Add(1) > 1 {
+		o.overlaps.Add(1)
+	}
+	defer o.running.Add(-1)
+	time.Sleep(2 * time.Millisecond) // keep the check running, so an overlap is likely if there is no lock
+	return o.DefaultRequires.CheckTriggerEvent(trigger, event)
+}
+
+// the duration triggers and the output triggers are checked one after the other
+func TestTriggerDurationWithOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID:     "busy",
+				Script: []string{"for i in $(seq 1 30); do echo line $i; sleep 0.01; done"},
+				Listener: []configure.Listener{
+					{Trigger: configure.Trigger{OnoutContains: []string{"line 1"}}, Action: configure.Action{SetVar: map[string]string{"SEEN": "output"}}},
+					{Trigger: configure.Trigger{OnDurationExceeds: 50}, Action: configure.Action{SetVar: map[string]string{"SEEN": "duration"}}},
+					{Trigger: configure.Trigger{OnDurationExceeds: 60}, Action: configure.Action{Script: []string{"echo duration reached"}}},
+				},
+			},
+		},
+	}
+	var mu sync.Mutex
+	outputs := []string{}
+	outHandler := func(msg ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range msg {
+			if s, ok := m.(tasks.MsgExecOutput); ok {
+				outputs = append(outputs, s.Output)
+			}
+		}
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	recorder := &overlapRecorder{DefaultRequires: tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())}
+	tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, recorder, tasks.NewWatchman())
+	tsk.SetHardExistToAllTasks(false)
+	assertIntEqual(t, systools.ExitOk, tsk.RunTarget("busy", false))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assertSliceContains(t, outputs, "duration reached")
+	assertSliceContains(t, outputs, "line 30")
+	assertIntEqual(t, 0, int(recorder.overlaps.Load()))
+}
@@ -23,6 +23,7 @@ package tasks
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"strings"
 
@@ -46,9 +47,15 @@ const (
 	exportToYaml    = "#@export-to-yaml"
 	exportToJson    = "#@export-to-json"
 	addvarMark      = "#@add"
+	ifMark          = "#@if"
 	equalsMark      = "#@if-equals"
 	notEqualsMark   = "#@if-not-equals"
 	osCheck         = "#@if-os"
+	elseMark        = "#@else"
+	elseIfMark      = "#@elseif"
+	elseIfEquals    = "#@elseif-equals"
+	elseIfNotEquals = "#@elseif-not-equals"
+	elseIfOs        = "#@elseif-os"
 	codeLinePH      = "__LINE__"
 	codeKeyPH       = "__KEY__"
 	writeVarToFile  = "#@var-to-file"
@@ -64,13 +71,15 @@ func (t *targetExecuter) TryParse(script []string, regularScript func(string) (b
 		panic("placeholderHandler is not set")
 	}
 	t.getLogger().Debug("TPARSE: entered")
-	inIteration := false
-	inIfState := false
-	ifState := true
-	var iterationLines []string
+	// the blocks are checked before, so nothing is executed if they are not balanced
+	if invalidLine, err := checkMacroBlocks(script); err != nil {
+		t.out(MsgError(MsgError{Err: err, Reference: invalidLine, Target: t.target}))
+		return true, systools.ErrorCheatMacros, nil
+	}
+	var blocks macroBlocks
 	var parsedScript []string
-	var iterationCollect gjson.Result
-	for _, line := range script {
+	for index, line := range script {
+		lineNr := index + 1
 		if t.phHandler != nil {
 			line = t.phHandler.HandlePlaceHolder(line)
 		}
@@ -82,70 +91,70 @@ func (t *targetExecuter) TryParse(script []string, regularScript func(string) (b
 			if len(parts) < 1 {
 				continue
 			}
+			// macros in a branch, they is not executed, are ignored.
+			// except the macros for the blocks, so we know where the branch ends
+			if !blocks.active() && !isBlockMacro(parts[0]) {
+				t.getLogger().Debug("TPARSE: macro ignored because of if state", line)
+				continue
+			}
 			switch parts[0] {
 
-			case osCheck:
-				t.getLogger().Debug("TPARSE: check os")
-				if !inIfState {
-					if len(parts) == 2 {
-						leftEq := parts[1]
-						rightEq := configure.GetOs()
-						inIfState = true
-						ifState = leftEq == rightEq
-					} else {
-						t.out(MsgError(MsgError{Err: errors.New("invalid usage " + equalsMark + " need: str1 str2"), Reference: line, Target: t.target}))
-						return true, systools.ErrorCheatMacros, parsedScript
-					}
-				} else {
-					t.out(MsgError(MsgError{Err: errors.New("invalid usage " + equalsMark + " can not be used in another if"), Reference: line, Target: t.target}))
+			case ifMark, equalsMark, notEqualsMark, osCheck:
+				t.getLogger().Debug("TPARSE: if", parts[0])
+				if !blocks.active() {
+					// the whole block is in a branch, they is not executed. so we do not check the condition
+					blocks.push(&macroBlock{mark: parts[0], line: lineNr, matched: true})
+					continue
+				}
+				condition, err := t.macroCondition(parts[0], parts, line)
+				if err != nil {
+					t.out(MsgError(MsgError{Err: err, Reference: line, Target: t.target}))
 					return true, systools.ErrorCheatMacros, parsedScript
 				}
+				logFields := mimiclog.Fields{"condition": condition, "line": lineNr}
+				t.getLogger().Debug(parts[0], logFields)
+				blocks.push(&macroBlock{mark: parts[0], line: lineNr, active: condition, parentActive: true, matched: condition})
 
-			case equalsMark:
-				t.getLogger().Debug("TPARSE: equals")
-				if !inIfState {
-					if len(parts) == 3 {
-						leftEq := parts[1]
-						rightEq := parts[2]
-						inIfState = true
-						ifState = leftEq == rightEq
-						logFields := mimiclog.Fields{"condition": ifState, "left": leftEq, "right": rightEq}
-						t.getLogger().Debug(equalsMark, logFields)
-					} else {
-						t.out(MsgError(MsgError{Err: errors.New("invalid usage " + equalsMark + " need: str1 str2"), Reference: line, Target: t.target}))
-						return true, systools.ErrorCheatMacros, parsedScript
-
-					}
-				} else {
-					t.out(MsgError(MsgError{Err: errors.New("invalid usage " + equalsMark + " can not be used in another if"), Reference: line, Target: t.target}))
+			case elseIfMark, elseIfEquals, elseIfNotEquals, elseIfOs:
+				t.getLogger().Debug("TPARSE: else if", parts[0])
+				block, err := blocks.conditionBlock(parts[0], lineNr)
+				if err != nil {
+					t.out(MsgError(MsgError{Err: err, Reference: line, Target: t.target}))
+					return true, systools.ErrorCheatMacros, parsedScript
+				}
+				if !block.parentActive || block.matched {
+					block.active = false
+					continue
+				}
+				condition, err := t.macroCondition(strings.Replace(parts[0], elseIfMark, ifMark, 1), parts, line)
+				if err != nil {
+					t.out(MsgError(MsgError{Err: err, Reference: line, Target: t.target}))
 					return true, systools.ErrorCheatMacros, parsedScript
 				}
+				logFields := mimiclog.Fields{"condition": condition, "line": lineNr}
+				t.getLogger().Debug(parts[0], logFields)
+				block.active = condition
+				block.matched = condition
 
-			case notEqualsMark:
-				t.getLogger().Debug("TPARSE: not equals")
-				if !inIfState {
-					if len(parts) == 3 {
-						leftEq := parts[1]
-						rightEq := parts[2]
-						inIfState = true
-						ifState = leftEq != rightEq
-						logFields := mimiclog.Fields{"condition": ifState, "left": leftEq, "right": rightEq}
-						t.getLogger().Debug(notEqualsMark, logFields)
-					} else {
-						t.out(MsgError(MsgError{Err: errors.New("invalid usage " + notEqualsMark + " need: str1 str2"), Reference: line, Target: t.target}))
-						return true, systools.ErrorCheatMacros, parsedScript
-					}
-				} else {
-					t.out(MsgError(MsgError{Err: errors.New("invalid usage " + notEqualsMark + " can not be used in another if"), Reference: line, Target: t.target}))
+			case elseMark:
+				t.getLogger().Debug("TPARSE: else")
+				block, err := blocks.conditionBlock(parts[0], lineNr)
+				if err != nil {
+					t.out(MsgError(MsgError{Err: err, Reference: line, Target: t.target}))
 					return true, systools.ErrorCheatMacros, parsedScript
 				}
+				block.hasElse = true
+				block.active = block.parentActive && !block.matched
+				block.matched = true
 
 			case inlineMark:
 				t.getLogger().Debug("TPARSE: inline")
-				if inIteration {
-					iterationLines = append(iterationLines, strings.Replace(line, inlineMark+" ", "", 4))
-					logFields := mimiclog.Fields{"iterationLines": iterationLines}
-					t.getLogger().Debug("TPARSE: append to subscript", logFields)
+				if iteration := blocks.iteration(); iteration != nil {
+					if blocks.active() {
+						iteration.lines = append(iteration.lines, strings.Replace(line, inlineMark+" ", "", 4))
+						logFields := mimiclog.Fields{"iterationLines": iteration.lines}
+						t.getLogger().Debug("TPARSE: append to subscript", logFields)
+					}
 				} else {
 					t.out(MsgError(MsgError{Err: errors.New("invalid usage " + inlineMark + " only valid while in iteration"), Reference: line, Target: t.target}))
 					return true, systools.ErrorCheatMacros, parsedScript
@@ -312,21 +321,20 @@ func (t *targetExecuter) TryParse(script []string, regularScript func(string) (b
 				}
 
 			case endMark:
-
-				if inIfState {
-					t.getLogger().Debug("TPARSE: IF: DONE")
-					inIfState = false
-					ifState = true
+				block := blocks.pop()
+				if block == nil {
+					t.out(MsgError(MsgError{Err: fmt.Errorf("invalid usage %s in line %d. there is no #@if or #@foreach to close", endMark, lineNr), Reference: line, Target: t.target}))
+					return true, systools.ErrorCheatMacros, parsedScript
 				}
-				if inIteration {
+				t.getLogger().Debug("TPARSE: BLOCK: DONE", block.mark, block.line)
+				if block.mark == iterateMark && block.active {
 					t.getLogger().Debug("TPARSE: ITERATION: DONE")
-					inIteration = false
 					abortFound := false
 					returnCode := systools.ExitOk
 
-					iterationCollect.ForEach(func(key gjson.Result, value gjson.Result) bool {
+					block.iteration.ForEach(func(key gjson.Result, value gjson.Result) bool {
 						var parsedExecLines []string
-						for _, iLine := range iterationLines {
+						for _, iLine := range block.lines {
 							iLine = strings.Replace(iLine, codeLinePH, value.String(), 1)
 							iLine = strings.Replace(iLine, codeKeyPH, key.String(), 1)
 							parsedExecLines = append(parsedExecLines, iLine)
@@ -350,15 +358,18 @@ func (t *targetExecuter) TryParse(script []string, regularScript func(string) (b
 				}
 
 			case iterateMark:
+				if !blocks.active() {
+					blocks.push(&macroBlock{mark: iterateMark, line: lineNr})
+					continue
+				}
 				if len(parts) == 3 {
 					impMap, found := t.dataHandler.GetJSONPathResult(parts[1], parts[2])
 					if !found {
 						t.out(MsgError(MsgError{Err: errors.New("undefined data from path " + parts[1] + " " + parts[2]), Reference: line, Target: t.target}))
 					} else {
-						inIteration = true
-						iterationCollect = impMap
 						t.getLogger().Debug("TPARSE: ITERATION: START", impMap)
 					}
+					blocks.push(&macroBlock{mark: iterateMark, line: lineNr, active: true, parentActive: true, iteration: impMap})
 				} else {
 					t.out(MsgError(MsgError{Err: errors.New("invalid arguments #@iterate needs <name-of-import> <path-to-data>"), Reference: line, Target: t.target}))
 					return true, systools.ErrorCheatMacros, parsedScript
@@ -372,7 +383,7 @@ func (t *targetExecuter) TryParse(script []string, regularScript func(string) (b
 		} else {
 			parsedScript = append(parsedScript, line)
 			// execute the *real* script lines
-			if ifState {
+			if blocks.active() {
 				abort, returnCode := regularScript(line)
 				if abort {
 					return true, returnCode, parsedScript
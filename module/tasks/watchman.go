@@ -374,6 +374,40 @@ func (w *Watchman) IncTaskDoneCount(target string) (bool, error) {
 	return true, nil                         // return the done flag
 }
 
+// SetTaskExitCode stores the exit code of the task, so other tasks can wait for the result.
+// the exit code have to be set before the done count is increased
+func (w *Watchman) SetTaskExitCode(target string, code int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	taskInfo, found := w.watchTaskList.Load(target)
+	if !found {
+		return fmt.Errorf("can not set the exit code for task %q, because it does not exists", target)
+	}
+	task := taskInfo.(TaskDef)
+	task.exitCode = code
+	w.watchTaskList.Store(target, task)
+	return nil
+}
+
+// WaitForTaskDone waits until all started runs of the task are done, and returns the exit code of the task.
+// the abort function is checked on any tick. if it returns true, or the task not exists, false is returned.
+func (w *Watchman) WaitForTaskDone(target string, tickDuration time.Duration, abort func() bool) (int, bool) {
+	w.logger.Debug("Watchman: wait for task done", target)
+	for {
+		taskInfo, found := w.watchTaskList.Load(target)
+		if !found {
+			return 0, false
+		}
+		if task := taskInfo.(TaskDef); task.done {
+			return task.exitCode, true
+		}
+		if abort != nil && abort() {
+			return 0, false
+		}
+		time.Sleep(tickDuration)
+	}
+}
+
 // ResetAllTaskInfos resets all task infos
 func (w *Watchman) ResetAllTaskInfos() {
 	w.watchTaskList.Range(func(key, _ interface{}) bool {
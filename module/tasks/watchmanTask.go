@@ -41,6 +41,7 @@ type TaskDef struct {
 	count      int
 	done       bool
 	doneCount  int
+	exitCode   int // the exit code of the last run. only set for tasks they are waited for
 	process    *ProcessDef
 	processLog []ProcessLog
 }
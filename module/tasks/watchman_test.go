@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
 	"github.com/swaros/contxt/module/systools"
 	"github.com/swaros/contxt/module/tasks"
 )
@@ -467,3 +469,45 @@ func TestWatchmanVerify(t *testing.T) {
 		t.Errorf("expected to create 2 tasks, but created %d", timesCreated)
 	}
 }
+
+// the needs are running in the executer of the parent target.
+// the process has to be tracked for the task of the need anyway, not for the parent.
+// otherwise the listener actions stop and restart can not find the process of a need.
+func TestWatchmanTracksProcessOfNeeds(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the script is written for bash")
+	}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{ID: "main", Needs: []string{"worker"}, Script: []string{"echo main done"}},
+			{ID: "worker", Script: []string{"sleep 0.5"}},
+		},
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	wman := tasks.NewWatchman()
+	tsk := tasks.NewTaskListExec(runCfg, dmc, func(msg ...interface{}) {}, tasks.ShellCmd, req, wman)
+	tsk.SetHardExistToAllTasks(false)
+
+	done := make(chan int)
+	go func() {
+		done <- tsk.RunTarget("main", false)
+	}()
+	if success, _ := wman.WaitForProcessStart("worker", 10*time.Millisecond, 40); !success {
+		t.Error("expected the process of the need is tracked for the need")
+	}
+	assertIntEqual(t, systools.ExitOk, <-done)
+
+	// any task logs only the commands of its own script
+	for target, expected := range map[string]string{"main": "echo main done", "worker": "sleep 0.5"} {
+		wtask, found := wman.GetTask(target)
+		if !found {
+			t.Fatalf("expected task %q to be found, but it is not", target)
+		}
+		commands := []string{}
+		for _, log := range wtask.GetProcessLog() {
+			commands = append(commands, log.Command)
+		}
+		assertStringEqual(t, expected, strings.Join(commands, ","))
+	}
+}
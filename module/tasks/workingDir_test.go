@@ -0,0 +1,120 @@
+package tasks_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/swaros/contxt/module/configure"
+	"github.com/swaros/contxt/module/mimiclog"
+	"github.com/swaros/contxt/module/systools"
+	"github.com/swaros/contxt/module/tasks"
+)
+
+// creates a directory with a marker file
+func createMarkedDir(t *testing.T, marker string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if realDir, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = realDir
+	}
+	if err := os.WriteFile(filepath.Join(dir, marker), []byte(marker), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestParallelWorkingDirs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("pwd is not available on windows")
+	}
+	dirA := createMarkedDir(t, "marker-a")
+	dirB := createMarkedDir(t, "marker-b")
+	startDir, _ := os.Getwd()
+
+	var mu sync.Mutex
+	outputs := map[string][]string{}
+	outHandler := func(msg ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range msg {
+			if s, ok := m.(tasks.MsgExecOutput); ok {
+				outputs[s.Target] = append(outputs[s.Target], s.Output)
+			}
+		}
+	}
+	script := []string{"pwd", "sleep 0.01", "pwd", "pwd"}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{ID: "a", Options: configure.Options{WorkingDir: dirA}, Requires: configure.Require{Exists: []string{"marker-a"}}, Script: script},
+			{ID: "b", Options: configure.Options{WorkingDir: dirB}, Requires: configure.Require{Exists: []string{"marker-b"}}, Script: script},
+			{ID: "c", Options: configure.Options{WorkingDir: dirA}, Script: script},
+			{ID: "d", Options: configure.Options{WorkingDir: dirB}, Cmd: []string{`content, err = readFile("marker-b")`, `println(content)`}},
+			{ID: "all", Needs: []string{"a", "b", "c", "d"}},
+		},
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, req, tasks.NewWatchman())
+	tsk.SetHardExistToAllTasks(false)
+	code := tsk.RunTarget("all", true)
+	assertIntEqual(t, systools.ExitOk, code)
+
+	expected := map[string]string{"a": dirA, "b": dirB, "c": dirA}
+	for target, dir := range expected {
+		if len(outputs[target]) != 3 {
+			t.Errorf("expected 3 outputs for %s, got %v", target, outputs[target])
+		}
+		for _, out := range outputs[target] {
+			if out != dir {
+				t.Errorf("target %s expected to run in %s, but got %s", target, dir, out)
+			}
+		}
+	}
+	if !strings.Contains(strings.Join(outputs["d"], "\n"), "marker-b") {
+		t.Errorf("expected the content of marker-b, got %v", outputs["d"])
+	}
+
+	// the current directory of the process is not changed
+	if currentDir, _ := os.Getwd(); currentDir != startDir {
+		t.Errorf("the current dir changed from %s to %s", startDir, currentDir)
+	}
+}
+
+func TestRelativeWorkingDir(t *testing.T) {
+	base := createMarkedDir(t, "marker-base")
+	if err := os.Mkdir(filepath.Join(base, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(base, "sub", "marker-sub"), []byte("sub"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runCfg := configure.RunConfig{
+		Task: []configure.Task{
+			{
+				ID:       "sub",
+				Options:  configure.Options{WorkingDir: "sub"},
+				Requires: configure.Require{Exists: []string{"marker-sub"}, NotExists: []string{"marker-base"}},
+				Script:   []string{"cat marker-sub"},
+			},
+		},
+	}
+	messages := []string{}
+	outHandler := func(msg ...interface{}) {
+		for _, m := range msg {
+			if s, ok := m.(tasks.MsgExecOutput); ok {
+				messages = append(messages, s.Output)
+			}
+		}
+	}
+	dmc := tasks.NewCombinedDataHandler()
+	dmc.SetPH("BASEPATH", base)
+	req := tasks.NewDefaultRequires(dmc, mimiclog.NewNullLogger())
+	tsk := tasks.NewTaskListExec(runCfg, dmc, outHandler, tasks.ShellCmd, req, tasks.NewWatchman())
+	tsk.SetHardExistToAllTasks(false)
+	assertIntEqual(t, systools.ExitOk, tsk.RunTarget("sub", false))
+	assertSliceContains(t, messages, "sub")
+}